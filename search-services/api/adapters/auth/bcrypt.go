@@ -0,0 +1,27 @@
+package auth
+
+import "golang.org/x/crypto/bcrypt"
+
+type Bcrypt struct {
+	cost int
+}
+
+// NewBcrypt hashes passwords with the given cost, zero means the default one
+func NewBcrypt(cost int) Bcrypt {
+	if cost == 0 {
+		cost = bcrypt.DefaultCost
+	}
+	return Bcrypt{cost: cost}
+}
+
+func (b Bcrypt) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), b.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func (b Bcrypt) Compare(hash, password string) error {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+}
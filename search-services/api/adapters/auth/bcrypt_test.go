@@ -0,0 +1,20 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestBcrypt(t *testing.T) {
+	hasher := NewBcrypt(bcrypt.MinCost)
+
+	hash, err := hasher.Hash("password")
+	assert.NoError(t, err)
+	assert.NotEqual(t, "password", hash)
+
+	assert.NoError(t, hasher.Compare(hash, "password"))
+	assert.Error(t, hasher.Compare(hash, "wrong"))
+	assert.Error(t, hasher.Compare("not a hash", "password"))
+}
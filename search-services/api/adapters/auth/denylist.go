@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryDenylist forgets entries after their time, so it doesn't grow with old sessions.
+// It is seen by one replica only, deployments keep revoked sessions in the database.
+type MemoryDenylist struct {
+	mu      sync.Mutex
+	entries map[string]time.Time
+	now     func() time.Time
+}
+
+func NewMemoryDenylist() *MemoryDenylist {
+	return &MemoryDenylist{
+		entries: make(map[string]time.Time),
+		now:     time.Now,
+	}
+}
+
+func (d *MemoryDenylist) Add(ctx context.Context, id string, until time.Time) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	for entry, expires := range d.entries {
+		if !expires.After(now) {
+			delete(d.entries, entry)
+		}
+	}
+	if _, ok := d.entries[id]; ok {
+		return false, nil
+	}
+	d.entries[id] = until
+	return true, nil
+}
+
+func (d *MemoryDenylist) Contains(ctx context.Context, id string) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	until, ok := d.entries[id]
+	return ok && until.After(d.now()), nil
+}
@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryDenylist(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	denylist := NewMemoryDenylist()
+	denylist.now = func() time.Time { return now }
+
+	assert.True(t, add(t, denylist, "a", now.Add(time.Minute)))
+	assert.True(t, add(t, denylist, "b", now.Add(time.Hour)))
+	// добавить уже отозванную сессию второй раз нельзя
+	assert.False(t, add(t, denylist, "b", now.Add(time.Hour)))
+	assert.True(t, contains(t, denylist, "a"))
+	assert.True(t, contains(t, denylist, "b"))
+	assert.False(t, contains(t, denylist, "c"))
+
+	now = now.Add(2 * time.Minute)
+	assert.False(t, contains(t, denylist, "a"))
+	assert.True(t, contains(t, denylist, "b"))
+
+	// просроченные записи удаляются при добавлении новых
+	assert.True(t, add(t, denylist, "c", now.Add(time.Minute)))
+	assert.NotContains(t, denylist.entries, "a")
+	assert.Len(t, denylist.entries, 2)
+}
+
+func contains(t *testing.T, denylist *MemoryDenylist, id string) bool {
+	revoked, err := denylist.Contains(context.Background(), id)
+	assert.NoError(t, err)
+	return revoked
+}
+
+func add(t *testing.T, denylist *MemoryDenylist, id string, until time.Time) bool {
+	added, err := denylist.Add(context.Background(), id, until)
+	assert.NoError(t, err)
+	return added
+}
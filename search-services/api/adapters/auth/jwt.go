@@ -1,6 +1,11 @@
 package auth
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -8,45 +13,152 @@ import (
 	"yadro.com/course/api/core"
 )
 
+const (
+	accessType  = "access"
+	refreshType = "refresh"
+)
+
+// ErrNoSigningKey stops the gateway from signing tokens with a secret nobody configured
+var ErrNoSigningKey = errors.New("no jwt signing keys or secret configured")
+
 type JWTAuth struct {
-	config config.AuthConfig
+	config   config.AuthConfig
+	keys     *KeySet
+	denylist core.Denylist
 }
 
-func NewJWTAuth(config config.AuthConfig) *JWTAuth {
+// NewJWTAuth signs tokens with the keys, without them it falls back to HS256 with the secret
+func NewJWTAuth(config config.AuthConfig, keys *KeySet, denylist core.Denylist) (*JWTAuth, error) {
+	if keys == nil && config.SecretKey == "" {
+		return nil, ErrNoSigningKey
+	}
 	return &JWTAuth{
-		config: config,
+		config:   config,
+		keys:     keys,
+		denylist: denylist,
+	}, nil
+}
+
+// GenerateTokens issues an access and refresh token pair of a new session
+func (a *JWTAuth) GenerateTokens(user core.User) (core.TokenPair, error) {
+	session, err := newSessionID()
+	if err != nil {
+		return core.TokenPair{}, err
 	}
+
+	access, err := a.sign(user, session, accessType, a.config.TokenTTL)
+	if err != nil {
+		return core.TokenPair{}, err
+	}
+	refresh, err := a.sign(user, session, refreshType, a.config.RefreshTTL)
+	if err != nil {
+		return core.TokenPair{}, err
+	}
+
+	return core.TokenPair{
+		AccessToken:      access,
+		RefreshToken:     refresh,
+		AccessExpiresIn:  a.config.TokenTTL,
+		RefreshExpiresIn: a.config.RefreshTTL,
+	}, nil
+}
+
+// ValidateToken fails with ErrInvalidToken for bad tokens, other errors come from the denylist
+func (a *JWTAuth) ValidateToken(ctx context.Context, tokenString string) (core.Claims, error) {
+	return a.validate(ctx, tokenString, accessType)
+}
+
+func (a *JWTAuth) ValidateRefreshToken(ctx context.Context, tokenString string) (core.Claims, error) {
+	return a.validate(ctx, tokenString, refreshType)
 }
 
-func (a *JWTAuth) GenerateToken(username, password string) (string, error) {
-	if username != a.config.AdminUser || password != a.config.AdminPassword {
-		return "", core.ErrInvalidCredentials
+func (a *JWTAuth) PublicKeys() []core.JWK {
+	if a.keys == nil {
+		return []core.JWK{}
 	}
+	return a.keys.PublicKeys()
+}
 
+// Revoke keeps the session denied while its refresh token could be alive
+func (a *JWTAuth) Revoke(ctx context.Context, session string) error {
+	added, err := a.denylist.Add(ctx, session, time.Now().Add(a.config.RefreshTTL))
+	if err != nil {
+		return err
+	}
+	if !added {
+		return fmt.Errorf("%w: session already revoked", core.ErrInvalidToken)
+	}
+	return nil
+}
+
+func (a *JWTAuth) sign(user core.User, session, tokenType string, ttl time.Duration) (string, error) {
 	claims := jwt.MapClaims{
-		"sub": "superuser",
-		"exp": time.Now().Add(a.config.TokenTTL).Unix(),
+		"sub":  user.Name,
+		"role": string(user.Role),
+		"sid":  session,
+		"typ":  tokenType,
+		"exp":  time.Now().Add(ttl).Unix(),
+	}
+
+	if a.keys == nil {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString([]byte(a.config.SecretKey))
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(a.config.SecretKey))
+	key := a.keys.signer()
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.id
+	return token.SignedString(key.private)
 }
 
-func (a *JWTAuth) ValidateToken(tokenString string) error {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+// verificationKey picks the key by kid, the algorithm must be the one of the key
+func (a *JWTAuth) verificationKey(token *jwt.Token) (interface{}, error) {
+	if a.keys == nil {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, core.ErrInvalidToken
 		}
 		return []byte(a.config.SecretKey), nil
-	})
+	}
+
+	id, _ := token.Header["kid"].(string)
+	key, ok := a.keys.get(id)
+	if !ok || token.Method.Alg() != key.method.Alg() {
+		return nil, core.ErrInvalidToken
+	}
+	return key.public, nil
+}
+
+func (a *JWTAuth) validate(ctx context.Context, tokenString, tokenType string) (core.Claims, error) {
+	token, err := jwt.Parse(tokenString, a.verificationKey)
 
 	if err != nil {
-		return err
+		return core.Claims{}, fmt.Errorf("%w: %v", core.ErrInvalidToken, err)
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok || !token.Valid || claims["sub"] != "superuser" {
-		return core.ErrInvalidToken
+	if !ok || !token.Valid || claims["typ"] != tokenType {
+		return core.Claims{}, core.ErrInvalidToken
 	}
-	return nil
+	subject, _ := claims["sub"].(string)
+	role, _ := claims["role"].(string)
+	session, _ := claims["sid"].(string)
+	if subject == "" || session == "" || !core.Role(role).Valid() {
+		return core.Claims{}, core.ErrInvalidToken
+	}
+	revoked, err := a.denylist.Contains(ctx, session)
+	if err != nil {
+		return core.Claims{}, fmt.Errorf("failed to check revoked sessions: %w", err)
+	}
+	if revoked {
+		return core.Claims{}, core.ErrInvalidToken
+	}
+	return core.Claims{Subject: subject, Role: core.Role(role), Session: session}, nil
+}
+
+func newSessionID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
 }
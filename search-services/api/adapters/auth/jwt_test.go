@@ -1,78 +1,112 @@
 package auth
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"yadro.com/course/api/config"
 	"yadro.com/course/api/core"
 )
 
-func TestJWTAuth_GenerateToken(t *testing.T) {
-	tests := []struct {
-		name     string
-		config   config.AuthConfig
-		username string
-		password string
-		wantErr  error
-	}{
-		{
-			name: "valid credentials",
-			config: config.AuthConfig{
-				AdminUser:     "admin",
-				AdminPassword: "password",
-				SecretKey:     "secret",
-				TokenTTL:      time.Hour,
-			},
-			username: "admin",
-			password: "password",
-			wantErr:  nil,
-		},
-		{
-			name: "invalid username",
-			config: config.AuthConfig{
-				AdminUser:     "admin",
-				AdminPassword: "password",
-				SecretKey:     "secret",
-				TokenTTL:      time.Hour,
-			},
-			username: "wrong",
-			password: "password",
-			wantErr:  core.ErrInvalidCredentials,
-		},
-		{
-			name: "invalid password",
-			config: config.AuthConfig{
-				AdminUser:     "admin",
-				AdminPassword: "password",
-				SecretKey:     "secret",
-				TokenTTL:      time.Hour,
-			},
-			username: "admin",
-			password: "wrong",
-			wantErr:  core.ErrInvalidCredentials,
-		},
-	}
+func newTestAuth(t *testing.T, cfg config.AuthConfig, keys *KeySet, denylist core.Denylist) *JWTAuth {
+	t.Helper()
+	auth, err := NewJWTAuth(cfg, keys, denylist)
+	require.NoError(t, err)
+	return auth
+}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			auth := NewJWTAuth(tt.config)
-			token, err := auth.GenerateToken(tt.username, tt.password)
+func TestNewJWTAuth_NoSigningKey(t *testing.T) {
+	// без ключей и секрета сервис не должен стартовать с секретом по умолчанию
+	_, err := NewJWTAuth(config.AuthConfig{TokenTTL: time.Minute}, nil, NewMemoryDenylist())
+	assert.ErrorIs(t, err, ErrNoSigningKey)
+}
 
-			if tt.wantErr != nil {
-				assert.Error(t, err)
-				assert.ErrorIs(t, err, tt.wantErr)
-				assert.Empty(t, token)
-				return
-			}
+func TestJWTAuth_GenerateTokens(t *testing.T) {
+	config := config.AuthConfig{SecretKey: "secret", TokenTTL: time.Minute, RefreshTTL: time.Hour}
+	auth := newTestAuth(t, config, nil, NewMemoryDenylist())
 
-			assert.NoError(t, err)
-			assert.NotEmpty(t, token)
-		})
-	}
+	tokens, err := auth.GenerateTokens(core.User{Name: "alice", Role: core.RoleOperator})
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, tokens.AccessExpiresIn)
+	assert.Equal(t, time.Hour, tokens.RefreshExpiresIn)
+
+	access, err := auth.ValidateToken(context.Background(), tokens.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", access.Subject)
+	assert.Equal(t, core.RoleOperator, access.Role)
+	assert.NotEmpty(t, access.Session)
+
+	refresh, err := auth.ValidateRefreshToken(context.Background(), tokens.RefreshToken)
+	assert.NoError(t, err)
+	assert.Equal(t, access, refresh)
+
+	// токены разных типов не взаимозаменяемы
+	_, err = auth.ValidateToken(context.Background(), tokens.RefreshToken)
+	assert.ErrorIs(t, err, core.ErrInvalidToken)
+	_, err = auth.ValidateRefreshToken(context.Background(), tokens.AccessToken)
+	assert.ErrorIs(t, err, core.ErrInvalidToken)
+
+	other, err := auth.GenerateTokens(core.User{Name: "alice", Role: core.RoleOperator})
+	assert.NoError(t, err)
+	otherClaims, err := auth.ValidateToken(context.Background(), other.AccessToken)
+	assert.NoError(t, err)
+	assert.NotEqual(t, access.Session, otherClaims.Session)
+}
+
+func TestJWTAuth_Revoke(t *testing.T) {
+	config := config.AuthConfig{SecretKey: "secret", TokenTTL: time.Minute, RefreshTTL: time.Hour}
+	auth := newTestAuth(t, config, nil, NewMemoryDenylist())
+
+	tokens, err := auth.GenerateTokens(core.User{Name: "alice", Role: core.RoleAdmin})
+	assert.NoError(t, err)
+	other, err := auth.GenerateTokens(core.User{Name: "alice", Role: core.RoleAdmin})
+	assert.NoError(t, err)
+
+	claims, err := auth.ValidateToken(context.Background(), tokens.AccessToken)
+	assert.NoError(t, err)
+	assert.NoError(t, auth.Revoke(context.Background(), claims.Session))
+
+	_, err = auth.ValidateToken(context.Background(), tokens.AccessToken)
+	assert.ErrorIs(t, err, core.ErrInvalidToken)
+	_, err = auth.ValidateRefreshToken(context.Background(), tokens.RefreshToken)
+	assert.ErrorIs(t, err, core.ErrInvalidToken)
+
+	// другие сессии пользователя не затронуты
+	_, err = auth.ValidateToken(context.Background(), other.AccessToken)
+	assert.NoError(t, err)
+
+	// повторный отзыв — признак повторного использования refresh-токена
+	assert.ErrorIs(t, auth.Revoke(context.Background(), claims.Session), core.ErrInvalidToken)
+}
+
+func TestJWTAuth_DenylistError(t *testing.T) {
+	config := config.AuthConfig{SecretKey: "secret", TokenTTL: time.Minute, RefreshTTL: time.Hour}
+	auth := newTestAuth(t, config, nil, brokenDenylist{})
+
+	tokens, err := auth.GenerateTokens(core.User{Name: "alice", Role: core.RoleAdmin})
+	assert.NoError(t, err)
+
+	// недоступная база — не повод считать токен плохим, но и пропускать его нельзя
+	_, err = auth.ValidateToken(context.Background(), tokens.AccessToken)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, core.ErrInvalidToken)
+	assert.Error(t, auth.Revoke(context.Background(), "session"))
+}
+
+type brokenDenylist struct{}
+
+func (brokenDenylist) Add(ctx context.Context, id string, until time.Time) (bool, error) {
+	return false, errors.New("db is down")
+}
+
+func (brokenDenylist) Contains(ctx context.Context, id string) (bool, error) {
+	return false, errors.New("db is down")
 }
 
 func TestJWTAuth_ValidateToken(t *testing.T) {
@@ -81,6 +115,7 @@ func TestJWTAuth_ValidateToken(t *testing.T) {
 		AdminPassword: "password",
 		SecretKey:     "secret",
 		TokenTTL:      time.Hour,
+		RefreshTTL:    time.Hour,
 	}
 
 	tests := []struct {
@@ -94,9 +129,9 @@ func TestJWTAuth_ValidateToken(t *testing.T) {
 			name:   "valid token",
 			config: validConfig,
 			setupFunc: func() string {
-				auth := NewJWTAuth(validConfig)
-				token, _ := auth.GenerateToken("admin", "password")
-				return token
+				auth := newTestAuth(t, validConfig, nil, NewMemoryDenylist())
+				tokens, _ := auth.GenerateTokens(core.User{Name: "admin", Role: core.RoleAdmin})
+				return tokens.AccessToken
 			},
 			shouldErr: false,
 		},
@@ -111,8 +146,11 @@ func TestJWTAuth_ValidateToken(t *testing.T) {
 			config: validConfig,
 			setupFunc: func() string {
 				claims := jwt.MapClaims{
-					"sub": "superuser",
-					"exp": time.Now().Add(-time.Hour).Unix(),
+					"sub":  "admin",
+					"sid":  "session",
+					"typ":  "access",
+					"role": "admin",
+					"exp":  time.Now().Add(-time.Hour).Unix(),
 				}
 				token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 				tokenString, _ := token.SignedString([]byte(validConfig.SecretKey))
@@ -125,8 +163,11 @@ func TestJWTAuth_ValidateToken(t *testing.T) {
 			config: validConfig,
 			setupFunc: func() string {
 				claims := jwt.MapClaims{
-					"sub": "superuser",
-					"exp": time.Now().Add(time.Hour).Unix(),
+					"sub":  "admin",
+					"sid":  "session",
+					"typ":  "access",
+					"role": "admin",
+					"exp":  time.Now().Add(time.Hour).Unix(),
 				}
 				token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
 				tokenString, _ := token.SignedString([]byte(validConfig.SecretKey))
@@ -134,13 +175,65 @@ func TestJWTAuth_ValidateToken(t *testing.T) {
 			},
 			shouldErr: true,
 		},
+		{
+			name:   "unknown role",
+			config: validConfig,
+			setupFunc: func() string {
+				claims := jwt.MapClaims{
+					"sub":  "admin",
+					"sid":  "session",
+					"typ":  "access",
+					"role": "superuser",
+					"exp":  time.Now().Add(time.Hour).Unix(),
+				}
+				token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+				tokenString, _ := token.SignedString([]byte(validConfig.SecretKey))
+				return tokenString
+			},
+			shouldErr: true,
+		},
+		{
+			name:   "no subject",
+			config: validConfig,
+			setupFunc: func() string {
+				claims := jwt.MapClaims{
+					"sid":  "session",
+					"typ":  "access",
+					"role": "admin",
+					"exp":  time.Now().Add(time.Hour).Unix(),
+				}
+				token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+				tokenString, _ := token.SignedString([]byte(validConfig.SecretKey))
+				return tokenString
+			},
+			shouldErr: true,
+		},
+		{
+			name:   "token without type",
+			config: validConfig,
+			setupFunc: func() string {
+				claims := jwt.MapClaims{
+					"sub":  "admin",
+					"sid":  "session",
+					"role": "admin",
+					"exp":  time.Now().Add(time.Hour).Unix(),
+				}
+				token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+				tokenString, _ := token.SignedString([]byte(validConfig.SecretKey))
+				return tokenString
+			},
+			shouldErr: true,
+		},
 		{
 			name:   "wrong secret key",
 			config: validConfig,
 			setupFunc: func() string {
 				claims := jwt.MapClaims{
-					"sub": "superuser",
-					"exp": time.Now().Add(time.Hour).Unix(),
+					"sub":  "admin",
+					"sid":  "session",
+					"typ":  "access",
+					"role": "admin",
+					"exp":  time.Now().Add(time.Hour).Unix(),
 				}
 				token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 				tokenString, _ := token.SignedString([]byte("wrong_secret"))
@@ -152,13 +245,13 @@ func TestJWTAuth_ValidateToken(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			auth := NewJWTAuth(tt.config)
+			auth := newTestAuth(t, tt.config, nil, NewMemoryDenylist())
 			token := tt.token
 			if tt.setupFunc != nil {
 				token = tt.setupFunc()
 			}
 
-			err := auth.ValidateToken(token)
+			_, err := auth.ValidateToken(context.Background(), token)
 
 			if tt.shouldErr {
 				assert.Error(t, err)
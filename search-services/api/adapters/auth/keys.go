@@ -0,0 +1,189 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"yadro.com/course/api/core"
+)
+
+type signingKey struct {
+	id     string
+	method jwt.SigningMethod
+	// private is nil for keys kept only to validate tokens issued before rotation
+	private crypto.Signer
+	public  crypto.PublicKey
+}
+
+// KeySet holds RS256 and EdDSA keys by kid, one of them signs new tokens.
+// The files are read again by Reload, so keys rotate without a restart.
+type KeySet struct {
+	paths    []string
+	activeID string
+
+	mu     sync.RWMutex
+	keys   map[string]signingKey
+	order  []string
+	active string
+}
+
+// LoadKeySet reads PEM files, the kid of a key is its file name without extension.
+// Private keys can sign, public ones only validate. When active is empty
+// the last private key signs, so a new key is added to the end of the list
+// and the old one stays there until tokens signed by it expire.
+func LoadKeySet(paths []string, active string) (*KeySet, error) {
+	set := &KeySet{paths: paths, activeID: active}
+	if err := set.Reload(); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// Reload reads the key files again, on any error the loaded keys stay in use
+func (s *KeySet) Reload() error {
+	keys := make(map[string]signingKey, len(s.paths))
+	order := make([]string, 0, len(s.paths))
+	active := s.activeID
+	for _, path := range s.paths {
+		key, err := loadKey(path)
+		if err != nil {
+			return fmt.Errorf("failed to load key %s: %w", path, err)
+		}
+		if _, ok := keys[key.id]; ok {
+			return fmt.Errorf("duplicate key id %s", key.id)
+		}
+		keys[key.id] = key
+		order = append(order, key.id)
+		if s.activeID == "" && key.private != nil {
+			active = key.id
+		}
+	}
+
+	key, ok := keys[active]
+	if !ok || key.private == nil {
+		return fmt.Errorf("no private signing key %q", active)
+	}
+
+	s.mu.Lock()
+	s.keys, s.order, s.active = keys, order, active
+	s.mu.Unlock()
+	return nil
+}
+
+// Watch reloads the keys on every value from reload, such as SIGHUP,
+// and every period when it is positive, until ctx is done
+func (s *KeySet) Watch(ctx context.Context, log *slog.Logger, period time.Duration, reload <-chan os.Signal) {
+	var tick <-chan time.Time
+	if period > 0 {
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-reload:
+		case <-tick:
+		}
+		if err := s.Reload(); err != nil {
+			log.Error("failed to reload signing keys, keeping the loaded ones", "error", err)
+			continue
+		}
+		log.Info("signing keys reloaded", "active", s.signer().id)
+	}
+}
+
+func (s *KeySet) signer() signingKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.keys[s.active]
+}
+
+func (s *KeySet) get(id string) (signingKey, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, ok := s.keys[id]
+	return key, ok
+}
+
+// PublicKeys lists all keys in the JWKS format
+func (s *KeySet) PublicKeys() []core.JWK {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	jwks := make([]core.JWK, 0, len(s.order))
+	for _, id := range s.order {
+		key := s.keys[id]
+		jwk := core.JWK{KeyID: id, Use: "sig", Algorithm: key.method.Alg()}
+		switch public := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+		jwks = append(jwks, jwk)
+	}
+	return jwks
+}
+
+func loadKey(path string) (signingKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return signingKey{}, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return signingKey{}, errors.New("no PEM block")
+	}
+
+	var parsed any
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return signingKey{}, fmt.Errorf("unsupported PEM block %s", block.Type)
+	}
+	if err != nil {
+		return signingKey{}, err
+	}
+
+	key := signingKey{id: strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.method, key.private, key.public = jwt.SigningMethodRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.method, key.public = jwt.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		key.method, key.private, key.public = jwt.SigningMethodEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.method, key.public = jwt.SigningMethodEdDSA, k
+	default:
+		return signingKey{}, fmt.Errorf("unsupported key type %T", parsed)
+	}
+	return key, nil
+}
@@ -0,0 +1,223 @@
+package auth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"yadro.com/course/api/config"
+	"yadro.com/course/api/core"
+)
+
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+func writeEd25519(t *testing.T, dir, name string) (string, ed25519.PublicKey) {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+	return writePEM(t, dir, name, "PRIVATE KEY", der), public
+}
+
+func writeRSA(t *testing.T, dir, name string) (string, *rsa.PrivateKey) {
+	t.Helper()
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return writePEM(t, dir, name, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(private)), private
+}
+
+func TestLoadKeySet(t *testing.T) {
+	dir := t.TempDir()
+	ed, edPublic := writeEd25519(t, dir, "2024-01.pem")
+	rs, rsPrivate := writeRSA(t, dir, "2024-02.pem")
+	publicDER, err := x509.MarshalPKIXPublicKey(edPublic)
+	require.NoError(t, err)
+	publicOnly := writePEM(t, dir, "2023-12.pem", "PUBLIC KEY", publicDER)
+
+	keys, err := LoadKeySet([]string{publicOnly, ed, rs}, "")
+	require.NoError(t, err)
+	// подписывает последний закрытый ключ
+	assert.Equal(t, "2024-02", keys.signer().id)
+
+	keys, err = LoadKeySet([]string{publicOnly, ed, rs}, "2024-01")
+	require.NoError(t, err)
+	assert.Equal(t, "2024-01", keys.signer().id)
+
+	jwks := keys.PublicKeys()
+	require.Len(t, jwks, 3)
+	assert.Equal(t, core.JWK{
+		KeyType: "OKP", KeyID: "2023-12", Use: "sig", Algorithm: "EdDSA", Curve: "Ed25519",
+		X: base64.RawURLEncoding.EncodeToString(edPublic),
+	}, jwks[0])
+	assert.Equal(t, "2024-01", jwks[1].KeyID)
+	assert.Equal(t, "RSA", jwks[2].KeyType)
+	assert.Equal(t, "RS256", jwks[2].Algorithm)
+	assert.Equal(t, base64.RawURLEncoding.EncodeToString(rsPrivate.N.Bytes()), jwks[2].N)
+	assert.Equal(t, "AQAB", jwks[2].E)
+}
+
+func TestLoadKeySet_Errors(t *testing.T) {
+	dir := t.TempDir()
+	ed, edPublic := writeEd25519(t, dir, "key.pem")
+	publicDER, err := x509.MarshalPKIXPublicKey(edPublic)
+	require.NoError(t, err)
+	publicOnly := writePEM(t, dir, "public.pem", "PUBLIC KEY", publicDER)
+	other := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(t, os.WriteFile(other, []byte("not a key"), 0o600))
+	cert := writePEM(t, dir, "cert.pem", "CERTIFICATE", []byte("data"))
+
+	tests := []struct {
+		name   string
+		paths  []string
+		active string
+	}{
+		{name: "no keys", paths: nil},
+		{name: "missing file", paths: []string{filepath.Join(dir, "missing.pem")}},
+		{name: "not pem", paths: []string{other}},
+		{name: "unsupported block", paths: []string{cert}},
+		{name: "duplicate kid", paths: []string{ed, writePEM(t, t.TempDir(), "key.pem", "PUBLIC KEY", publicDER)}},
+		{name: "only public keys", paths: []string{publicOnly}},
+		{name: "active is public", paths: []string{ed, publicOnly}, active: "public"},
+		{name: "unknown active", paths: []string{ed}, active: "other"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadKeySet(tt.paths, tt.active)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestJWTAuth_KeyRotation(t *testing.T) {
+	dir := t.TempDir()
+	oldKey, _ := writeEd25519(t, dir, "old.pem")
+	newKey, _ := writeRSA(t, dir, "new.pem")
+	cfg := config.AuthConfig{SecretKey: "secret", TokenTTL: time.Hour, RefreshTTL: time.Hour}
+	user := core.User{Name: "alice", Role: core.RoleAdmin}
+
+	oldKeys, err := LoadKeySet([]string{oldKey}, "")
+	require.NoError(t, err)
+	oldTokens, err := newTestAuth(t, cfg, oldKeys, NewMemoryDenylist()).GenerateTokens(user)
+	require.NoError(t, err)
+
+	// во время ротации старый ключ ещё проверяет выданные им токены
+	bothKeys, err := LoadKeySet([]string{oldKey, newKey}, "")
+	require.NoError(t, err)
+	auth := newTestAuth(t, cfg, bothKeys, NewMemoryDenylist())
+	_, err = auth.ValidateToken(context.Background(), oldTokens.AccessToken)
+	assert.NoError(t, err)
+
+	newTokens, err := auth.GenerateTokens(user)
+	require.NoError(t, err)
+	parsed, _, err := jwt.NewParser().ParseUnverified(newTokens.AccessToken, jwt.MapClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, "new", parsed.Header["kid"])
+	assert.Equal(t, "RS256", parsed.Method.Alg())
+
+	newKeys, err := LoadKeySet([]string{newKey}, "")
+	require.NoError(t, err)
+	auth = newTestAuth(t, cfg, newKeys, NewMemoryDenylist())
+	_, err = auth.ValidateToken(context.Background(), newTokens.AccessToken)
+	assert.NoError(t, err)
+	_, err = auth.ValidateToken(context.Background(), oldTokens.AccessToken)
+	assert.Error(t, err)
+}
+
+func TestJWTAuth_KeysRejectForgedTokens(t *testing.T) {
+	dir := t.TempDir()
+	key, _ := writeEd25519(t, dir, "key.pem")
+	keys, err := LoadKeySet([]string{key}, "")
+	require.NoError(t, err)
+	cfg := config.AuthConfig{SecretKey: "secret", TokenTTL: time.Hour, RefreshTTL: time.Hour}
+	auth := newTestAuth(t, cfg, keys, NewMemoryDenylist())
+
+	claims := jwt.MapClaims{
+		"sub":  "admin",
+		"role": "admin",
+		"sid":  "session",
+		"typ":  "access",
+		"exp":  time.Now().Add(time.Hour).Unix(),
+	}
+
+	// с ключами общий секрет больше не принимается
+	hs := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	hs.Header["kid"] = "key"
+	token, err := hs.SignedString([]byte(cfg.SecretKey))
+	require.NoError(t, err)
+	_, err = auth.ValidateToken(context.Background(), token)
+	assert.Error(t, err)
+
+	_, other, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	for _, kid := range []string{"key", "unknown", ""} {
+		forged := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+		forged.Header["kid"] = kid
+		token, err := forged.SignedString(other)
+		require.NoError(t, err)
+		_, err = auth.ValidateToken(context.Background(), token)
+		assert.Error(t, err, kid)
+	}
+
+	assert.Empty(t, newTestAuth(t, cfg, nil, NewMemoryDenylist()).PublicKeys())
+	assert.Len(t, auth.PublicKeys(), 1)
+}
+
+func TestKeySet_Reload(t *testing.T) {
+	dir := t.TempDir()
+	path, _ := writeEd25519(t, dir, "key.pem")
+	keys, err := LoadKeySet([]string{path}, "")
+	require.NoError(t, err)
+	cfg := config.AuthConfig{TokenTTL: time.Hour, RefreshTTL: time.Hour}
+	auth := newTestAuth(t, cfg, keys, NewMemoryDenylist())
+	user := core.User{Name: "alice", Role: core.RoleAdmin}
+
+	oldTokens, err := auth.GenerateTokens(user)
+	require.NoError(t, err)
+
+	// битый файл не должен оставить сервис без ключей
+	require.NoError(t, os.WriteFile(path, []byte("garbage"), 0o600))
+	assert.Error(t, keys.Reload())
+	_, err = auth.ValidateToken(context.Background(), oldTokens.AccessToken)
+	assert.NoError(t, err)
+
+	writeEd25519(t, dir, "key.pem")
+	reload := make(chan os.Signal, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go keys.Watch(ctx, slog.New(slog.NewTextHandler(io.Discard, nil)), 0, reload)
+	reload <- syscall.SIGHUP
+
+	// после перечитывания подписи старым ключом не принимаются
+	assert.Eventually(t, func() bool {
+		_, err := auth.ValidateToken(context.Background(), oldTokens.AccessToken)
+		return err != nil
+	}, time.Second, 10*time.Millisecond)
+
+	newTokens, err := auth.GenerateTokens(user)
+	require.NoError(t, err)
+	_, err = auth.ValidateToken(context.Background(), newTokens.AccessToken)
+	assert.NoError(t, err)
+}
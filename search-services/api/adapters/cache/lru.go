@@ -0,0 +1,33 @@
+package cache
+
+import (
+	"time"
+
+	"yadro.com/course/api/core"
+	"yadro.com/course/pkg/lru"
+)
+
+var _ core.Cache = (*LRU)(nil)
+
+// LRU is the shared least recently used cache with the stats of the core
+type LRU struct {
+	*lru.Cache
+}
+
+func NewLRU(size int, ttl time.Duration) (*LRU, error) {
+	c, err := lru.New(size, ttl)
+	if err != nil {
+		return nil, err
+	}
+	return &LRU{Cache: c}, nil
+}
+
+func (c *LRU) Stats() core.CacheStats {
+	stats := c.Cache.Stats()
+	return core.CacheStats{
+		Hits:      stats.Hits,
+		Misses:    stats.Misses,
+		Evictions: stats.Evictions,
+		Size:      stats.Size,
+	}
+}
@@ -0,0 +1,93 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"yadro.com/course/api/core"
+)
+
+type apiKey struct {
+	ID         int64        `db:"id"`
+	Name       string       `db:"name"`
+	Prefix     string       `db:"prefix"`
+	Role       string       `db:"role"`
+	CreatedAt  sql.NullTime `db:"created_at"`
+	LastUsedAt sql.NullTime `db:"last_used_at"`
+	RevokedAt  sql.NullTime `db:"revoked_at"`
+}
+
+func (k apiKey) toCore() core.APIKey {
+	return core.APIKey{
+		ID:         k.ID,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		Role:       core.Role(k.Role),
+		CreatedAt:  k.CreatedAt.Time,
+		LastUsedAt: k.LastUsedAt.Time,
+		RevokedAt:  k.RevokedAt.Time,
+	}
+}
+
+const apiKeyColumns = "id, name, prefix, role, created_at, last_used_at, revoked_at"
+
+func (db *DB) CreateKey(ctx context.Context, key core.APIKey, hash string) (core.APIKey, error) {
+	query := `
+		INSERT INTO api_keys (name, prefix, key_hash, role)
+		VALUES ($1, $2, $3, $4)
+		RETURNING ` + apiKeyColumns
+
+	var created apiKey
+	if err := db.conn.GetContext(ctx, &created, query, key.Name, key.Prefix, hash, string(key.Role)); err != nil {
+		db.log.Error("failed to create api key", "error", err, "name", key.Name)
+		return core.APIKey{}, err
+	}
+
+	db.log.Debug("api key created", "id", created.ID, "name", created.Name, "role", created.Role)
+	return created.toCore(), nil
+}
+
+func (db *DB) KeyByHash(ctx context.Context, hash string) (core.APIKey, error) {
+	var key apiKey
+	err := db.conn.GetContext(ctx, &key, "SELECT "+apiKeyColumns+" FROM api_keys WHERE key_hash = $1", hash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return core.APIKey{}, core.ErrNotFound
+		}
+		db.log.Error("failed to get api key", "error", err)
+		return core.APIKey{}, err
+	}
+	return key.toCore(), nil
+}
+
+func (db *DB) Keys(ctx context.Context) ([]core.APIKey, error) {
+	var keys []apiKey
+	if err := db.conn.SelectContext(ctx, &keys, "SELECT "+apiKeyColumns+" FROM api_keys ORDER BY id"); err != nil {
+		db.log.Error("failed to list api keys", "error", err)
+		return nil, err
+	}
+
+	result := make([]core.APIKey, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, key.toCore())
+	}
+	return result, nil
+}
+
+// RevokeKey keeps the first revocation time when called again
+func (db *DB) RevokeKey(ctx context.Context, id int64) error {
+	res, err := db.conn.ExecContext(ctx,
+		"UPDATE api_keys SET revoked_at = COALESCE(revoked_at, now()) WHERE id = $1", id)
+	if err != nil {
+		db.log.Error("failed to revoke api key", "error", err, "id", id)
+		return err
+	}
+	return expectRow(res)
+}
+
+func (db *DB) TouchKey(ctx context.Context, id int64, usedAt time.Time) error {
+	_, err := db.conn.ExecContext(ctx, "UPDATE api_keys SET last_used_at = $2 WHERE id = $1", id, usedAt)
+	return err
+}
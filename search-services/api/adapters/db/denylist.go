@@ -0,0 +1,50 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// Denylist keeps revoked sessions in the database, so a logout is seen
+// by every replica and survives restarts
+type Denylist struct {
+	db *DB
+}
+
+func NewDenylist(db *DB) *Denylist {
+	return &Denylist{db: db}
+}
+
+// Add drops expired sessions on the way, logouts are rare enough for that.
+// The insert is the check: of concurrent requests with one session only one adds it.
+func (d *Denylist) Add(ctx context.Context, id string, until time.Time) (bool, error) {
+	if _, err := d.db.conn.ExecContext(ctx, "DELETE FROM revoked_sessions WHERE expires_at <= now()"); err != nil {
+		d.db.log.Error("failed to delete expired sessions", "error", err)
+		return false, err
+	}
+	result, err := d.db.conn.ExecContext(ctx, `
+		INSERT INTO revoked_sessions (id, expires_at)
+		VALUES ($1, $2)
+		ON CONFLICT (id) DO NOTHING
+	`, id, until)
+	if err != nil {
+		d.db.log.Error("failed to revoke session", "error", err)
+		return false, err
+	}
+	added, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return added == 1, nil
+}
+
+func (d *Denylist) Contains(ctx context.Context, id string) (bool, error) {
+	var revoked bool
+	err := d.db.conn.GetContext(ctx, &revoked,
+		"SELECT EXISTS (SELECT 1 FROM revoked_sessions WHERE id = $1 AND expires_at > now())", id)
+	if err != nil {
+		d.db.log.Error("failed to check revoked session", "error", err)
+		return false, err
+	}
+	return revoked, nil
+}
@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// refilled is the bucket after the time since the last request, $2 is burst and $3 is rate
+const refilled = `least($2::float8, rate_limits.tokens + extract(epoch FROM now() - rate_limits.updated_at)::float8 * $3::float8)`
+
+// TakeToken updates the bucket in one statement, so replicas can't take the same token
+func (db *DB) TakeToken(ctx context.Context, key string, rate float64, burst int) (float64, bool, error) {
+	query := `
+		INSERT INTO rate_limits (key, tokens, allowed)
+		VALUES ($1, $2::float8 - 1, true)
+		ON CONFLICT (key) DO UPDATE SET
+			tokens = CASE WHEN ` + refilled + ` >= 1 THEN ` + refilled + ` - 1 ELSE ` + refilled + ` END,
+			allowed = ` + refilled + ` >= 1,
+			updated_at = now()
+		RETURNING tokens, allowed
+	`
+
+	var bucket struct {
+		Tokens  float64 `db:"tokens"`
+		Allowed bool    `db:"allowed"`
+	}
+	if err := db.conn.GetContext(ctx, &bucket, query, key, burst, rate); err != nil {
+		return 0, false, err
+	}
+	return bucket.Tokens, bucket.Allowed, nil
+}
+
+func (db *DB) DeleteIdleTokens(ctx context.Context, prefix string, idle time.Duration) error {
+	_, err := db.conn.ExecContext(ctx,
+		"DELETE FROM rate_limits WHERE starts_with(key, $1) AND updated_at < now() - make_interval(secs => $2)",
+		prefix, idle.Seconds())
+	return err
+}
+
+// AcquireLease counts live leases under an advisory lock of the key,
+// the lock is held until the transaction ends
+func (db *DB) AcquireLease(ctx context.Context, key string, limit int, ttl time.Duration) (int64, bool, error) {
+	tx, err := db.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", key); err != nil {
+		return 0, false, err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM concurrency_leases WHERE key = $1 AND expires_at <= now()", key); err != nil {
+		return 0, false, err
+	}
+
+	var id int64
+	err = tx.GetContext(ctx, &id, `
+		INSERT INTO concurrency_leases (key, expires_at)
+		SELECT $1, now() + make_interval(secs => $3)
+		WHERE (SELECT count(*) FROM concurrency_leases WHERE key = $1) < $2
+		RETURNING id
+	`, key, limit, ttl.Seconds())
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, false, err
+	}
+	return id, true, nil
+}
+
+func (db *DB) ReleaseLease(ctx context.Context, key string, id int64) error {
+	_, err := db.conn.ExecContext(ctx, "DELETE FROM concurrency_leases WHERE id = $1 AND key = $2", id, key)
+	return err
+}
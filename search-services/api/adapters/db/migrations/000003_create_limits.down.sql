@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS concurrency_leases;
+DROP TABLE IF EXISTS rate_limits;
\ No newline at end of file
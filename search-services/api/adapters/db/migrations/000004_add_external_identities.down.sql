@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS users_identity_idx;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS subject,
+    DROP COLUMN IF EXISTS issuer,
+    DROP COLUMN IF EXISTS display_name;
@@ -0,0 +1,9 @@
+ALTER TABLE users
+    ADD COLUMN display_name TEXT NOT NULL DEFAULT '',
+    ADD COLUMN issuer TEXT,
+    ADD COLUMN subject TEXT;
+
+CREATE UNIQUE INDEX users_identity_idx ON users (issuer, subject);
+
+-- external users were keyed by the username claim, they are provisioned again on the next login
+DELETE FROM users WHERE name LIKE 'oidc:%' AND issuer IS NULL;
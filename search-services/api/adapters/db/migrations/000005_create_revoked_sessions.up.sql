@@ -0,0 +1,6 @@
+CREATE TABLE revoked_sessions (
+    id TEXT PRIMARY KEY,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX revoked_sessions_expires_idx ON revoked_sessions (expires_at);
@@ -0,0 +1,51 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"yadro.com/course/api/core"
+)
+
+// OIDCLogins keeps authorization requests in the database,
+// so the callback may come to another replica than the authorize request
+type OIDCLogins struct {
+	db *DB
+}
+
+func NewOIDCLogins(db *DB) *OIDCLogins {
+	return &OIDCLogins{db: db}
+}
+
+// Put drops expired logins on the way
+func (l *OIDCLogins) Put(ctx context.Context, state string, login core.OIDCLogin) error {
+	if _, err := l.db.conn.ExecContext(ctx, "DELETE FROM oidc_logins WHERE expires_at <= now()"); err != nil {
+		l.db.log.Error("failed to delete expired oidc logins", "error", err)
+		return err
+	}
+	_, err := l.db.conn.ExecContext(ctx,
+		"INSERT INTO oidc_logins (state, verifier, nonce, expires_at) VALUES ($1, $2, $3, $4)",
+		state, login.Verifier, login.Nonce, login.Expires)
+	if err != nil {
+		l.db.log.Error("failed to save oidc login", "error", err)
+		return err
+	}
+	return nil
+}
+
+// Take deletes the login in the same statement, so a state can't be redeemed twice
+func (l *OIDCLogins) Take(ctx context.Context, state string) (core.OIDCLogin, error) {
+	var login core.OIDCLogin
+	err := l.db.conn.QueryRowContext(ctx,
+		"DELETE FROM oidc_logins WHERE state = $1 RETURNING verifier, nonce, expires_at", state).
+		Scan(&login.Verifier, &login.Nonce, &login.Expires)
+	if errors.Is(err, sql.ErrNoRows) {
+		return core.OIDCLogin{}, core.ErrNotFound
+	}
+	if err != nil {
+		l.db.log.Error("failed to take oidc login", "error", err)
+		return core.OIDCLogin{}, err
+	}
+	return login, nil
+}
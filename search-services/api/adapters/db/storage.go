@@ -0,0 +1,220 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jmoiron/sqlx"
+	"yadro.com/course/api/core"
+)
+
+const uniqueViolation = "23505"
+
+type DB struct {
+	log  *slog.Logger
+	conn *sqlx.DB
+}
+
+func New(log *slog.Logger, address string) (*DB, error) {
+	db, err := sqlx.Connect("pgx", address)
+	if err != nil {
+		log.Error("connection problem", "address", address, "error", err)
+		return nil, err
+	}
+
+	return &DB{
+		log:  log,
+		conn: db,
+	}, nil
+}
+
+const userColumns = "id, name, display_name, password_hash, role, created_at"
+
+type user struct {
+	ID           int64        `db:"id"`
+	Name         string       `db:"name"`
+	DisplayName  string       `db:"display_name"`
+	PasswordHash string       `db:"password_hash"`
+	Role         string       `db:"role"`
+	CreatedAt    sql.NullTime `db:"created_at"`
+}
+
+func (u user) toCore() core.User {
+	return core.User{
+		ID:          u.ID,
+		Name:        u.Name,
+		DisplayName: u.DisplayName,
+		Role:        core.Role(u.Role),
+		CreatedAt:   u.CreatedAt.Time,
+	}
+}
+
+func (db *DB) Create(ctx context.Context, u core.User, hash string) (core.User, error) {
+	query := `
+		INSERT INTO users (name, password_hash, role)
+		VALUES ($1, $2, $3)
+		RETURNING ` + userColumns + `
+	`
+
+	var created user
+	if err := db.conn.GetContext(ctx, &created, query, u.Name, hash, string(u.Role)); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return core.User{}, core.ErrAlreadyExists
+		}
+		db.log.Error("failed to create user", "error", err, "name", u.Name)
+		return core.User{}, err
+	}
+
+	db.log.Debug("user created", "name", created.Name, "role", created.Role)
+	return created.toCore(), nil
+}
+
+func (db *DB) Get(ctx context.Context, name string) (core.User, string, error) {
+	var u user
+	err := db.conn.GetContext(ctx, &u,
+		"SELECT "+userColumns+" FROM users WHERE name = $1", name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return core.User{}, "", core.ErrNotFound
+		}
+		db.log.Error("failed to get user", "error", err, "name", name)
+		return core.User{}, "", err
+	}
+	return u.toCore(), u.PasswordHash, nil
+}
+
+func (db *DB) List(ctx context.Context) ([]core.User, error) {
+	var users []user
+	err := db.conn.SelectContext(ctx, &users,
+		"SELECT "+userColumns+" FROM users ORDER BY id")
+	if err != nil {
+		db.log.Error("failed to list users", "error", err)
+		return nil, err
+	}
+
+	result := make([]core.User, 0, len(users))
+	for _, u := range users {
+		result = append(result, u.toCore())
+	}
+	return result, nil
+}
+
+// CreateExternal adds a user without a password bound to an identity provider account
+func (db *DB) CreateExternal(ctx context.Context, u core.User, issuer, subject string) (core.User, error) {
+	query := `
+		INSERT INTO users (name, display_name, password_hash, role, issuer, subject)
+		VALUES ($1, $2, '', $3, $4, $5)
+		RETURNING ` + userColumns + `
+	`
+
+	var created user
+	err := db.conn.GetContext(ctx, &created, query, u.Name, u.DisplayName, string(u.Role), issuer, subject)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return core.User{}, core.ErrAlreadyExists
+		}
+		db.log.Error("failed to create external user", "error", err, "issuer", issuer, "subject", subject)
+		return core.User{}, err
+	}
+
+	db.log.Debug("external user created", "name", created.Name, "display_name", created.DisplayName)
+	return created.toCore(), nil
+}
+
+func (db *DB) GetExternal(ctx context.Context, issuer, subject string) (core.User, error) {
+	var u user
+	err := db.conn.GetContext(ctx, &u,
+		"SELECT "+userColumns+" FROM users WHERE issuer = $1 AND subject = $2", issuer, subject)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return core.User{}, core.ErrNotFound
+		}
+		db.log.Error("failed to get external user", "error", err, "issuer", issuer, "subject", subject)
+		return core.User{}, err
+	}
+	return u.toCore(), nil
+}
+
+func (db *DB) SetDisplayName(ctx context.Context, name, displayName string) error {
+	res, err := db.conn.ExecContext(ctx, "UPDATE users SET display_name = $2 WHERE name = $1", name, displayName)
+	if err != nil {
+		db.log.Error("failed to set display name", "error", err, "name", name)
+		return err
+	}
+	return expectRow(res)
+}
+
+func (db *DB) SetRole(ctx context.Context, name string, role core.Role) error {
+	err := db.keepAdmin(ctx, name, role != core.RoleAdmin, "UPDATE users SET role = $2 WHERE name = $1", name, string(role))
+	if err != nil && !errors.Is(err, core.ErrNotFound) && !errors.Is(err, core.ErrBadArguments) {
+		db.log.Error("failed to set user role", "error", err, "name", name)
+	}
+	return err
+}
+
+func (db *DB) Delete(ctx context.Context, name string) error {
+	err := db.keepAdmin(ctx, name, true, "DELETE FROM users WHERE name = $1", name)
+	if err != nil && !errors.Is(err, core.ErrNotFound) && !errors.Is(err, core.ErrBadArguments) {
+		db.log.Error("failed to delete user", "error", err, "name", name)
+	}
+	return err
+}
+
+// keepAdmin runs the query on the user under an advisory lock of the admins,
+// so two admins can't demote each other at once and leave nobody
+func (db *DB) keepAdmin(ctx context.Context, name string, demotes bool, query string, args ...any) error {
+	tx, err := db.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext('users:admins'))"); err != nil {
+		return err
+	}
+
+	var role string
+	err = tx.GetContext(ctx, &role, "SELECT role FROM users WHERE name = $1", name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return core.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if demotes && core.Role(role) == core.RoleAdmin {
+		var admins int
+		if err := tx.GetContext(ctx, &admins, "SELECT count(*) FROM users WHERE role = $1", string(core.RoleAdmin)); err != nil {
+			return err
+		}
+		if admins <= 1 {
+			return fmt.Errorf("%w: the last admin can't be removed", core.ErrBadArguments)
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if err := expectRow(res); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func expectRow(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return core.ErrNotFound
+	}
+	return nil
+}
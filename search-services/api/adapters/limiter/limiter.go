@@ -1,11 +1,17 @@
 package limiter
 
 import (
+	"context"
+	"math"
+	"sync"
 	"time"
 
-	"go.uber.org/ratelimit"
+	"yadro.com/course/api/core"
 )
 
+// sweepInterval is how often buckets of idle clients are dropped
+const sweepInterval = time.Minute
+
 type ConcurrencyLimiter struct {
 	sem chan struct{}
 }
@@ -16,38 +22,110 @@ func NewConcurrencyLimiter(limit int) *ConcurrencyLimiter {
 	}
 }
 
-func (l *ConcurrencyLimiter) Acquire() bool {
+func (l *ConcurrencyLimiter) Acquire(ctx context.Context, priority bool) (func(), bool, error) {
 	select {
 	case l.sem <- struct{}{}:
-		return true
+		return l.release, true, nil
 	default:
-		return false
+		return nil, false, nil
 	}
 }
 
-func (l *ConcurrencyLimiter) Release() {
+func (l *ConcurrencyLimiter) release() {
 	select {
 	case <-l.sem:
 	default:
 	}
 }
 
-type RateLimiter struct {
-	limiter ratelimit.Limiter
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// take refills the bucket and takes a token when there is a whole one
+func (b *bucket) take(now time.Time, rate float64, burst int) bool {
+	b.tokens = math.Min(float64(burst), b.tokens+now.Sub(b.updated).Seconds()*rate)
+	b.updated = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// full tells whether the bucket has refilled, such a bucket is the same as a new one
+func (b *bucket) full(now time.Time, rate float64, burst int) bool {
+	return b.tokens+now.Sub(b.updated).Seconds()*rate >= float64(burst)
 }
 
-func NewRateLimiter(rps float64) *RateLimiter {
-	return &RateLimiter{
-		limiter: ratelimit.New(int(rps), ratelimit.Per(time.Second)),
+// rateLimit describes a bucket left with the tokens
+func rateLimit(tokens float64, allowed bool, rate float64, burst int) core.RateLimit {
+	limit := core.RateLimit{
+		Allowed:   allowed,
+		Limit:     burst,
+		Remaining: int(tokens),
+		Reset:     refill(float64(burst)-tokens, rate),
+	}
+	if !allowed {
+		limit.RetryAfter = refill(1-tokens, rate)
 	}
+	return limit
 }
 
-func (l *RateLimiter) Allow() bool {
-	now := time.Now()
-	next := l.limiter.Take()
-	return next.Sub(now) <= 0
+// refill is the time to get the tokens back
+func refill(tokens, rate float64) time.Duration {
+	return time.Duration(tokens / rate * float64(time.Second))
+}
+
+// TokenBuckets gives every client its own bucket of burst tokens
+// refilled at rate tokens a second, a request takes one token
+type TokenBuckets struct {
+	rate  float64
+	burst int
+	now   func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
 }
 
-func (l *RateLimiter) Wait() {
-	l.limiter.Take()
+func NewTokenBuckets(rate float64, burst int) *TokenBuckets {
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBuckets{
+		rate:    rate,
+		burst:   burst,
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+func (l *TokenBuckets) Allow(ctx context.Context, key string) (core.RateLimit, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.swept) > sweepInterval {
+		l.sweep(now)
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.burst), updated: now}
+		l.buckets[key] = b
+	}
+	allowed := b.take(now, l.rate, l.burst)
+	return rateLimit(b.tokens, allowed, l.rate, l.burst), nil
+}
+
+// sweep drops full buckets, a new bucket starts full anyway
+func (l *TokenBuckets) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		if b.full(now, l.rate, l.burst) {
+			delete(l.buckets, key)
+		}
+	}
+	l.swept = now
 }
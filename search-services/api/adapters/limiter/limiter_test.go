@@ -1,11 +1,14 @@
 package limiter
 
 import (
+	"context"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"yadro.com/course/api/core"
 )
 
 func TestConcurrencyLimiter(t *testing.T) {
@@ -38,19 +41,21 @@ func TestConcurrencyLimiter(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			limiter := NewConcurrencyLimiter(tt.limit)
-			successfulAcquires := 0
+			var releases []func()
 
 			for i := 0; i < tt.acquires; i++ {
-				if limiter.Acquire() {
-					successfulAcquires++
+				release, ok, err := limiter.Acquire(context.Background(), false)
+				assert.NoError(t, err)
+				if ok {
+					releases = append(releases, release)
 				}
 			}
 
-			assert.Equal(t, tt.expected, successfulAcquires)
+			assert.Equal(t, tt.expected, len(releases))
 
 			// Освобождаем все семафоры
-			for i := 0; i < successfulAcquires; i++ {
-				limiter.Release()
+			for _, release := range releases {
+				release()
 			}
 		})
 	}
@@ -67,12 +72,12 @@ func TestConcurrencyLimiter_Concurrent(t *testing.T) {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			if limiter.Acquire() {
+			if release, ok, _ := limiter.Acquire(context.Background(), false); ok {
 				mu.Lock()
 				successfulAcquires++
 				mu.Unlock()
 				time.Sleep(10 * time.Millisecond) // Имитируем работу
-				limiter.Release()
+				release()
 			}
 		}()
 	}
@@ -81,53 +86,117 @@ func TestConcurrencyLimiter_Concurrent(t *testing.T) {
 	assert.Equal(t, 3, successfulAcquires)
 }
 
-func TestRateLimiter(t *testing.T) {
+func TestTokenBuckets(t *testing.T) {
 	tests := []struct {
-		name     string
-		rps      float64
-		attempts int
-		expected int
+		name      string
+		rate      float64
+		burst     int
+		attempts  int
+		interval  time.Duration
+		expected  int
+		remaining int
 	}{
 		{
-			name:     "low rate",
-			rps:      1.0,
-			attempts: 3,
-			expected: 1,
+			name:      "burst at once",
+			rate:      1,
+			burst:     3,
+			attempts:  5,
+			expected:  3,
+			remaining: 0,
 		},
 		{
-			name:     "high rate",
-			rps:      10.0,
-			attempts: 5,
-			expected: 1,
+			name:      "refill between requests",
+			rate:      10,
+			burst:     1,
+			attempts:  5,
+			interval:  100 * time.Millisecond,
+			expected:  5,
+			remaining: 0,
+		},
+		{
+			name:      "partial refill",
+			rate:      1,
+			burst:     2,
+			attempts:  6,
+			interval:  500 * time.Millisecond,
+			expected:  4,
+			remaining: 0,
+		},
+		{
+			name:      "within burst",
+			rate:      1,
+			burst:     10,
+			attempts:  4,
+			expected:  4,
+			remaining: 6,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			limiter := NewRateLimiter(tt.rps)
-			successfulAttempts := 0
+			limiter := NewTokenBuckets(tt.rate, tt.burst)
+			now := time.Now()
+			limiter.now = func() time.Time { return now }
 
+			allowed := 0
+			var last core.RateLimit
 			for i := 0; i < tt.attempts; i++ {
-				if limiter.Allow() {
-					successfulAttempts++
+				var err error
+				last, err = limiter.Allow(context.Background(), "client")
+				assert.NoError(t, err)
+				if last.Allowed {
+					allowed++
 				}
-				time.Sleep(10 * time.Millisecond) // Даем время для обновления лимитера
+				now = now.Add(tt.interval)
 			}
 
-			assert.Equal(t, tt.expected, successfulAttempts)
+			assert.Equal(t, tt.expected, allowed)
+			assert.Equal(t, tt.burst, last.Limit)
+			assert.Equal(t, tt.remaining, last.Remaining)
 		})
 	}
 }
 
-func TestRateLimiter_Wait(t *testing.T) {
-	limiter := NewRateLimiter(1.0) // 1 запрос в секунду
-	start := time.Now()
+func TestTokenBuckets_RetryAfter(t *testing.T) {
+	limiter := NewTokenBuckets(2, 2)
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
 
-	// Делаем 3 запроса
-	for i := 0; i < 3; i++ {
-		limiter.Wait()
+	for i := 0; i < 2; i++ {
+		limit, err := limiter.Allow(context.Background(), "client")
+		assert.NoError(t, err)
+		assert.True(t, limit.Allowed)
 	}
 
-	duration := time.Since(start)
-	assert.True(t, duration >= 2*time.Second, "Должно пройти минимум 2 секунды между запросами")
+	limit, err := limiter.Allow(context.Background(), "client")
+	assert.NoError(t, err)
+	assert.False(t, limit.Allowed)
+	assert.Equal(t, 500*time.Millisecond, limit.RetryAfter)
+	assert.Equal(t, time.Second, limit.Reset)
+
+	// после паузы токен снова есть
+	now = now.Add(limit.RetryAfter)
+	limit, err = limiter.Allow(context.Background(), "client")
+	assert.NoError(t, err)
+	assert.True(t, limit.Allowed)
+}
+
+func TestTokenBuckets_PerClient(t *testing.T) {
+	limiter := NewTokenBuckets(1, 1)
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+
+	limit, _ := limiter.Allow(context.Background(), "ip:10.0.0.1")
+	assert.True(t, limit.Allowed)
+	limit, _ = limiter.Allow(context.Background(), "ip:10.0.0.1")
+	assert.False(t, limit.Allowed)
+
+	// шумный клиент не мешает остальным
+	limit, _ = limiter.Allow(context.Background(), "alice")
+	assert.True(t, limit.Allowed)
+
+	// полные корзины простаивающих клиентов удаляются
+	now = now.Add(2 * sweepInterval)
+	_, _ = limiter.Allow(context.Background(), "bob")
+	assert.Len(t, limiter.buckets, 1)
 }
@@ -0,0 +1,88 @@
+package limiter
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+type lease struct {
+	key     string
+	expires time.Time
+}
+
+// MemoryStore is a Store inside one process, a stand-in for the shared one
+type MemoryStore struct {
+	now func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	leases  map[int64]lease
+	lastID  int64
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+		leases:  make(map[int64]lease),
+	}
+}
+
+func (s *MemoryStore) TakeToken(ctx context.Context, key string, rate float64, burst int) (float64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(burst), updated: now}
+		s.buckets[key] = b
+	}
+	allowed := b.take(now, rate, burst)
+	return b.tokens, allowed, nil
+}
+
+func (s *MemoryStore) DeleteIdleTokens(ctx context.Context, prefix string, idle time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for key, b := range s.buckets {
+		if strings.HasPrefix(key, prefix) && now.Sub(b.updated) >= idle {
+			delete(s.buckets, key)
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) AcquireLease(ctx context.Context, key string, limit int, ttl time.Duration) (int64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	held := 0
+	for id, l := range s.leases {
+		switch {
+		case now.After(l.expires):
+			delete(s.leases, id)
+		case l.key == key:
+			held++
+		}
+	}
+	if held >= limit {
+		return 0, false, nil
+	}
+
+	s.lastID++
+	s.leases[s.lastID] = lease{key: key, expires: now.Add(ttl)}
+	return s.lastID, true, nil
+}
+
+func (s *MemoryStore) ReleaseLease(ctx context.Context, key string, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.leases, id)
+	return nil
+}
@@ -0,0 +1,200 @@
+package limiter
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"yadro.com/course/api/core"
+)
+
+type waiter struct {
+	ready chan struct{}
+}
+
+// Queue makes requests wait up to maxWait for a slot instead of failing at once.
+// Priority requests are served before the others, the queue holds at most size requests.
+// Slots freed by other replicas come without notice, so the head of the queue
+// also retries every poll, zero poll is for a limiter of this process only.
+type Queue struct {
+	limiter core.ConcurrencyLimiter
+	size    int
+	maxWait time.Duration
+	poll    time.Duration
+	now     func() time.Time
+
+	mu       sync.Mutex
+	priority *list.List
+	regular  *list.List
+	stats    core.QueueStats
+}
+
+func NewQueue(limiter core.ConcurrencyLimiter, size int, maxWait, poll time.Duration) *Queue {
+	return &Queue{
+		limiter:  limiter,
+		size:     size,
+		maxWait:  maxWait,
+		poll:     poll,
+		now:      time.Now,
+		priority: list.New(),
+		regular:  list.New(),
+		stats:    core.QueueStats{Size: size},
+	}
+}
+
+func (q *Queue) Acquire(ctx context.Context, priority bool) (func(), bool, error) {
+	q.mu.Lock()
+	ahead := q.priority.Len()
+	if !priority {
+		ahead += q.regular.Len()
+	}
+	q.mu.Unlock()
+
+	if ahead == 0 {
+		release, ok, err := q.limiter.Acquire(ctx, priority)
+		if err != nil || ok {
+			return q.acquired(release, ok, err)
+		}
+	}
+	return q.wait(ctx, priority)
+}
+
+func (q *Queue) wait(ctx context.Context, priority bool) (func(), bool, error) {
+	q.mu.Lock()
+	if q.priority.Len()+q.regular.Len() >= q.size {
+		q.stats.Rejected++
+		q.mu.Unlock()
+		return nil, false, nil
+	}
+	w := &waiter{ready: make(chan struct{}, 1)}
+	queue := q.regular
+	if priority {
+		queue = q.priority
+	}
+	element := queue.PushBack(w)
+	q.stats.Queued++
+	if q.head() == w {
+		// a slot may have been freed since the first try
+		w.ready <- struct{}{}
+	}
+	q.mu.Unlock()
+
+	start := q.now()
+	timer := time.NewTimer(q.maxWait)
+	defer timer.Stop()
+	var poll <-chan time.Time
+	if q.poll > 0 {
+		ticker := time.NewTicker(q.poll)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+
+	for {
+		select {
+		case <-w.ready:
+		case <-poll:
+			if !q.isHead(w) {
+				continue
+			}
+		case <-timer.C:
+			q.leave(queue, element, w)
+			q.mu.Lock()
+			q.stats.TimedOut++
+			q.mu.Unlock()
+			return nil, false, nil
+		case <-ctx.Done():
+			// the client is gone, nobody waits for the answer
+			q.leave(queue, element, w)
+			return nil, false, nil
+		}
+
+		release, ok, err := q.limiter.Acquire(ctx, priority)
+		if err != nil || ok {
+			q.leave(queue, element, w)
+			q.observe(q.now().Sub(start))
+			// there may be more free slots
+			q.wakeHead()
+			return q.acquired(release, ok, err)
+		}
+	}
+}
+
+// acquired counts the slot and wakes the next waiter when it is given back
+func (q *Queue) acquired(release func(), ok bool, err error) (func(), bool, error) {
+	if err != nil || !ok {
+		return nil, ok, err
+	}
+
+	q.mu.Lock()
+	q.stats.Acquired++
+	q.stats.InFlight++
+	q.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			release()
+			q.mu.Lock()
+			q.stats.InFlight--
+			q.mu.Unlock()
+			q.wakeHead()
+		})
+	}, true, nil
+}
+
+// leave removes the waiter, a wake up it didn't use goes to the next one
+func (q *Queue) leave(queue *list.List, element *list.Element, w *waiter) {
+	q.mu.Lock()
+	queue.Remove(element)
+	q.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		q.wakeHead()
+	default:
+	}
+}
+
+func (q *Queue) head() *waiter {
+	if front := q.priority.Front(); front != nil {
+		return front.Value.(*waiter)
+	}
+	if front := q.regular.Front(); front != nil {
+		return front.Value.(*waiter)
+	}
+	return nil
+}
+
+func (q *Queue) isHead(w *waiter) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.head() == w
+}
+
+func (q *Queue) wakeHead() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if w := q.head(); w != nil {
+		select {
+		case w.ready <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (q *Queue) observe(wait time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.stats.WaitCount++
+	q.stats.WaitTotal += wait
+	q.stats.WaitMax = max(q.stats.WaitMax, wait)
+}
+
+func (q *Queue) Stats() core.QueueStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	stats := q.stats
+	stats.Depth = q.priority.Len() + q.regular.Len()
+	return stats
+}
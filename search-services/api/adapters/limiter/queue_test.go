@@ -0,0 +1,150 @@
+package limiter
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueue_WaitsForSlot(t *testing.T) {
+	queue := NewQueue(NewConcurrencyLimiter(1), 10, time.Second, 0)
+
+	release, ok, err := queue.Acquire(context.Background(), false)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		release()
+	}()
+
+	// второй запрос дожидается слота, а не получает 503
+	second, ok, err := queue.Acquire(context.Background(), false)
+	require.NoError(t, err)
+	require.True(t, ok)
+	second()
+
+	stats := queue.Stats()
+	assert.Equal(t, int64(2), stats.Acquired)
+	assert.Equal(t, int64(1), stats.Queued)
+	assert.Equal(t, int64(1), stats.WaitCount)
+	assert.GreaterOrEqual(t, stats.WaitMax, 10*time.Millisecond)
+	assert.Equal(t, 0, stats.InFlight)
+	assert.Equal(t, 0, stats.Depth)
+}
+
+func TestQueue_Timeout(t *testing.T) {
+	tests := []struct {
+		name     string
+		size     int
+		maxWait  time.Duration
+		timedOut int64
+		rejected int64
+	}{
+		{
+			name:     "max wait",
+			size:     1,
+			maxWait:  20 * time.Millisecond,
+			timedOut: 1,
+		},
+		{
+			name:     "no queue",
+			size:     0,
+			maxWait:  time.Second,
+			rejected: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queue := NewQueue(NewConcurrencyLimiter(1), tt.size, tt.maxWait, 0)
+			release, ok, err := queue.Acquire(context.Background(), false)
+			require.NoError(t, err)
+			require.True(t, ok)
+			defer release()
+
+			_, ok, err = queue.Acquire(context.Background(), false)
+			require.NoError(t, err)
+			assert.False(t, ok)
+
+			stats := queue.Stats()
+			assert.Equal(t, tt.timedOut, stats.TimedOut)
+			assert.Equal(t, tt.rejected, stats.Rejected)
+			assert.Equal(t, 0, stats.Depth)
+		})
+	}
+}
+
+func TestQueue_ContextDone(t *testing.T) {
+	queue := NewQueue(NewConcurrencyLimiter(1), 10, time.Minute, 0)
+	release, _, _ := queue.Acquire(context.Background(), false)
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, ok, err := queue.Acquire(ctx, false)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, 0, queue.Stats().Depth)
+}
+
+func TestQueue_Priority(t *testing.T) {
+	queue := NewQueue(NewConcurrencyLimiter(1), 10, time.Second, 0)
+	release, _, _ := queue.Acquire(context.Background(), false)
+
+	var (
+		mu    sync.Mutex
+		order []string
+		wg    sync.WaitGroup
+	)
+	acquire := func(name string, priority bool) {
+		defer wg.Done()
+		release, ok, err := queue.Acquire(context.Background(), priority)
+		if !assert.NoError(t, err) || !assert.True(t, ok) {
+			return
+		}
+		mu.Lock()
+		order = append(order, name)
+		mu.Unlock()
+		release()
+	}
+
+	// анонимный запрос встал в очередь раньше, но пользователь с токеном проходит первым
+	wg.Add(1)
+	go acquire("anonymous", false)
+	require.Eventually(t, func() bool { return queue.Stats().Depth == 1 }, time.Second, time.Millisecond)
+	wg.Add(1)
+	go acquire("user", true)
+	require.Eventually(t, func() bool { return queue.Stats().Depth == 2 }, time.Second, time.Millisecond)
+
+	release()
+	wg.Wait()
+	assert.Equal(t, []string{"user", "anonymous"}, order)
+}
+
+func TestQueue_PollsSharedSlots(t *testing.T) {
+	store := NewMemoryStore()
+	replica := NewSharedConcurrencyLimiter(testLog, store, "search", 1)
+	other := NewSharedConcurrencyLimiter(testLog, store, "search", 1)
+	queue := NewQueue(replica, 10, time.Second, 5*time.Millisecond)
+
+	// слот занят другой репликой, о его освобождении очередь не узнает
+	release, ok, err := other.Acquire(context.Background(), false)
+	require.NoError(t, err)
+	require.True(t, ok)
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		release()
+	}()
+
+	release, ok, err = queue.Acquire(context.Background(), false)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	release()
+}
@@ -0,0 +1,205 @@
+package limiter
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"yadro.com/course/api/core"
+)
+
+const (
+	// leaseTTL frees slots of a replica that died while serving a request
+	leaseTTL = time.Minute
+	// fallbackPeriod is how long the local limiter stands in before the store is tried again
+	fallbackPeriod = 5 * time.Second
+	// releaseTimeout bounds giving a slot back once the request is over
+	releaseTimeout = 5 * time.Second
+)
+
+// Store keeps limiter state shared by all gateway replicas
+type Store interface {
+	// TakeToken refills the bucket of the key and takes a token from it
+	TakeToken(ctx context.Context, key string, rate float64, burst int) (tokens float64, allowed bool, err error)
+	// DeleteIdleTokens drops buckets of the prefix untouched for idle, they are full by then
+	DeleteIdleTokens(ctx context.Context, prefix string, idle time.Duration) error
+	// AcquireLease takes one of limit slots of the key until it is released or expires
+	AcquireLease(ctx context.Context, key string, limit int, ttl time.Duration) (id int64, ok bool, err error)
+	ReleaseLease(ctx context.Context, key string, id int64) error
+}
+
+// SharedRateLimiter is a token bucket per client kept in the store,
+// name separates quotas of different routes
+type SharedRateLimiter struct {
+	log   *slog.Logger
+	store Store
+	name  string
+	rate  float64
+	burst int
+	now   func() time.Time
+
+	mu    sync.Mutex
+	swept time.Time
+}
+
+func NewSharedRateLimiter(log *slog.Logger, store Store, name string, rate float64, burst int) *SharedRateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &SharedRateLimiter{
+		log:   log,
+		store: store,
+		name:  name,
+		rate:  rate,
+		burst: burst,
+		now:   time.Now,
+	}
+}
+
+func (l *SharedRateLimiter) Allow(ctx context.Context, key string) (core.RateLimit, error) {
+	l.sweep(ctx)
+
+	tokens, allowed, err := l.store.TakeToken(ctx, l.name+":"+key, l.rate, l.burst)
+	if err != nil {
+		return core.RateLimit{}, err
+	}
+	return rateLimit(tokens, allowed, l.rate, l.burst), nil
+}
+
+func (l *SharedRateLimiter) sweep(ctx context.Context) {
+	l.mu.Lock()
+	now := l.now()
+	due := now.Sub(l.swept) > sweepInterval
+	if due {
+		l.swept = now
+	}
+	l.mu.Unlock()
+	if !due {
+		return
+	}
+
+	idle := refill(float64(l.burst), l.rate)
+	if err := l.store.DeleteIdleTokens(ctx, l.name+":", idle); err != nil {
+		l.log.Warn("failed to delete idle rate limits", "name", l.name, "error", err)
+	}
+}
+
+// SharedConcurrencyLimiter leases slots from the store,
+// so the limit holds for all replicas together
+type SharedConcurrencyLimiter struct {
+	log   *slog.Logger
+	store Store
+	name  string
+	limit int
+}
+
+func NewSharedConcurrencyLimiter(log *slog.Logger, store Store, name string, limit int) *SharedConcurrencyLimiter {
+	return &SharedConcurrencyLimiter{
+		log:   log,
+		store: store,
+		name:  name,
+		limit: limit,
+	}
+}
+
+func (l *SharedConcurrencyLimiter) Acquire(ctx context.Context, priority bool) (func(), bool, error) {
+	id, ok, err := l.store.AcquireLease(ctx, l.name, l.limit, leaseTTL)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	release := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+		defer cancel()
+		if err := l.store.ReleaseLease(ctx, l.name, id); err != nil {
+			// the lease expires by itself
+			l.log.Warn("failed to release concurrency lease", "name", l.name, "id", id, "error", err)
+		}
+	}
+	return release, true, nil
+}
+
+// fallback remembers that the shared limiter failed
+type fallback struct {
+	log  *slog.Logger
+	name string
+	now  func() time.Time
+
+	mu    sync.Mutex
+	until time.Time
+}
+
+func (f *fallback) down() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now().Before(f.until)
+}
+
+func (f *fallback) fail(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.now().After(f.until) {
+		f.log.Warn("shared limiter is unreachable, limiting locally", "name", f.name, "error", err)
+	}
+	f.until = f.now().Add(fallbackPeriod)
+}
+
+// FallbackRateLimiter limits locally while the shared limiter is unreachable
+type FallbackRateLimiter struct {
+	fallback
+	shared core.RateLimiter
+	local  core.RateLimiter
+}
+
+func NewFallbackRateLimiter(log *slog.Logger, name string, shared, local core.RateLimiter) *FallbackRateLimiter {
+	return &FallbackRateLimiter{
+		fallback: fallback{log: log, name: name, now: time.Now},
+		shared:   shared,
+		local:    local,
+	}
+}
+
+func (l *FallbackRateLimiter) Allow(ctx context.Context, key string) (core.RateLimit, error) {
+	if !l.down() {
+		limit, err := l.shared.Allow(ctx, key)
+		if err == nil {
+			return limit, nil
+		}
+		// the client went away, the shared limiter is fine
+		if ctx.Err() != nil {
+			return core.RateLimit{}, err
+		}
+		l.fail(err)
+	}
+	return l.local.Allow(ctx, key)
+}
+
+// FallbackConcurrencyLimiter hands out local slots while the shared limiter is unreachable
+type FallbackConcurrencyLimiter struct {
+	fallback
+	shared core.ConcurrencyLimiter
+	local  core.ConcurrencyLimiter
+}
+
+func NewFallbackConcurrencyLimiter(log *slog.Logger, name string, shared, local core.ConcurrencyLimiter) *FallbackConcurrencyLimiter {
+	return &FallbackConcurrencyLimiter{
+		fallback: fallback{log: log, name: name, now: time.Now},
+		shared:   shared,
+		local:    local,
+	}
+}
+
+func (l *FallbackConcurrencyLimiter) Acquire(ctx context.Context, priority bool) (func(), bool, error) {
+	if !l.down() {
+		release, ok, err := l.shared.Acquire(ctx, priority)
+		if err == nil {
+			return release, ok, nil
+		}
+		if ctx.Err() != nil {
+			return nil, false, err
+		}
+		l.fail(err)
+	}
+	return l.local.Acquire(ctx, priority)
+}
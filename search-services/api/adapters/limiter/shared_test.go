@@ -0,0 +1,195 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testLog = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// brokenStore is a shared store that can't be reached
+type brokenStore struct {
+	calls int
+}
+
+var errUnreachable = errors.New("connection refused")
+
+func (s *brokenStore) TakeToken(ctx context.Context, key string, rate float64, burst int) (float64, bool, error) {
+	s.calls++
+	return 0, false, errUnreachable
+}
+
+func (s *brokenStore) DeleteIdleTokens(ctx context.Context, prefix string, idle time.Duration) error {
+	return errUnreachable
+}
+
+func (s *brokenStore) AcquireLease(ctx context.Context, key string, limit int, ttl time.Duration) (int64, bool, error) {
+	s.calls++
+	return 0, false, errUnreachable
+}
+
+func (s *brokenStore) ReleaseLease(ctx context.Context, key string, id int64) error {
+	return errUnreachable
+}
+
+func TestSharedRateLimiter_Replicas(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	// две реплики шлюза делят одну квоту
+	first := NewSharedRateLimiter(testLog, store, "isearch", 1, 3)
+	second := NewSharedRateLimiter(testLog, store, "isearch", 1, 3)
+	other := NewSharedRateLimiter(testLog, store, "login", 1, 3)
+
+	allowed := 0
+	for i := 0; i < 4; i++ {
+		for _, l := range []*SharedRateLimiter{first, second} {
+			limit, err := l.Allow(context.Background(), "ip:10.0.0.1")
+			require.NoError(t, err)
+			if limit.Allowed {
+				allowed++
+			}
+		}
+	}
+	assert.Equal(t, 3, allowed)
+
+	limit, err := first.Allow(context.Background(), "ip:10.0.0.1")
+	require.NoError(t, err)
+	assert.False(t, limit.Allowed)
+	assert.Equal(t, 3, limit.Limit)
+	assert.Equal(t, time.Second, limit.RetryAfter)
+
+	// у другого маршрута своя квота
+	limit, err = other.Allow(context.Background(), "ip:10.0.0.1")
+	require.NoError(t, err)
+	assert.True(t, limit.Allowed)
+	assert.Equal(t, 2, limit.Remaining)
+
+	now = now.Add(time.Second)
+	limit, err = second.Allow(context.Background(), "ip:10.0.0.1")
+	require.NoError(t, err)
+	assert.True(t, limit.Allowed)
+}
+
+func TestSharedRateLimiter_DeleteIdle(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	limiter := NewSharedRateLimiter(testLog, store, "isearch", 1, 10)
+	limiter.now = store.now
+
+	_, err := limiter.Allow(context.Background(), "alice")
+	require.NoError(t, err)
+	_, _, err = store.TakeToken(context.Background(), "login:alice", 1, 10)
+	require.NoError(t, err)
+
+	now = now.Add(sweepInterval + time.Second)
+	_, err = limiter.Allow(context.Background(), "bob")
+	require.NoError(t, err)
+
+	assert.NotContains(t, store.buckets, "isearch:alice")
+	assert.Contains(t, store.buckets, "isearch:bob")
+	// чужой префикс не трогаем
+	assert.Contains(t, store.buckets, "login:alice")
+}
+
+func TestSharedConcurrencyLimiter_Replicas(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	first := NewSharedConcurrencyLimiter(testLog, store, "search", 2)
+	second := NewSharedConcurrencyLimiter(testLog, store, "search", 2)
+
+	release, ok, err := first.Acquire(context.Background(), false)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	_, ok, err = second.Acquire(context.Background(), false)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	_, ok, err = first.Acquire(context.Background(), false)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	release()
+	_, ok, err = second.Acquire(context.Background(), false)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// слоты упавшей реплики освобождаются по истечении аренды
+	now = now.Add(leaseTTL + time.Second)
+	_, ok, err = first.Acquire(context.Background(), false)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestFallbackRateLimiter(t *testing.T) {
+	store := &brokenStore{}
+	now := time.Now()
+	local := NewTokenBuckets(1, 1)
+	local.now = func() time.Time { return now }
+	limiter := NewFallbackRateLimiter(testLog, "isearch", NewSharedRateLimiter(testLog, store, "isearch", 1, 1), local)
+	limiter.now = func() time.Time { return now }
+
+	limit, err := limiter.Allow(context.Background(), "alice")
+	require.NoError(t, err)
+	assert.True(t, limit.Allowed)
+	limit, err = limiter.Allow(context.Background(), "alice")
+	require.NoError(t, err)
+	assert.False(t, limit.Allowed)
+
+	// пока хранилище недоступно, его не дёргают на каждый запрос
+	assert.Equal(t, 1, store.calls)
+
+	now = now.Add(fallbackPeriod + time.Second)
+	_, err = limiter.Allow(context.Background(), "alice")
+	require.NoError(t, err)
+	assert.Equal(t, 2, store.calls)
+}
+
+func TestFallbackConcurrencyLimiter(t *testing.T) {
+	store := &brokenStore{}
+	limiter := NewFallbackConcurrencyLimiter(testLog, "search",
+		NewSharedConcurrencyLimiter(testLog, store, "search", 1), NewConcurrencyLimiter(1))
+
+	release, ok, err := limiter.Acquire(context.Background(), false)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	_, ok, err = limiter.Acquire(context.Background(), false)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	release()
+	_, ok, err = limiter.Acquire(context.Background(), false)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 1, store.calls)
+}
+
+func TestFallback_CanceledRequest(t *testing.T) {
+	store := &brokenStore{}
+	rate := NewFallbackRateLimiter(testLog, "isearch",
+		NewSharedRateLimiter(testLog, store, "isearch", 1, 1), NewTokenBuckets(1, 1))
+	concurrency := NewFallbackConcurrencyLimiter(testLog, "search",
+		NewSharedConcurrencyLimiter(testLog, store, "search", 1), NewConcurrencyLimiter(1))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := rate.Allow(ctx, "alice")
+	assert.Error(t, err)
+	_, _, err = concurrency.Acquire(ctx, false)
+	assert.Error(t, err)
+
+	// отменённый клиентом запрос не переводит лимитеры на локальные квоты
+	assert.False(t, rate.down())
+	assert.False(t, concurrency.down())
+	assert.Equal(t, 2, store.calls)
+}
@@ -0,0 +1,57 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"yadro.com/course/api/core"
+)
+
+// maxMemoryLogins bounds the logins kept by one replica, authorize is open to anyone
+const maxMemoryLogins = 10000
+
+// MemoryLogins keeps authorization requests of a single replica, for tests and local runs.
+// Deployments keep them in the database.
+type MemoryLogins struct {
+	mu     sync.Mutex
+	logins map[string]core.OIDCLogin
+	now    func() time.Time
+}
+
+func NewMemoryLogins() *MemoryLogins {
+	return &MemoryLogins{
+		logins: make(map[string]core.OIDCLogin),
+		now:    time.Now,
+	}
+}
+
+func (m *MemoryLogins) Put(ctx context.Context, state string, login core.OIDCLogin) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	for s, l := range m.logins {
+		if now.After(l.Expires) {
+			delete(m.logins, s)
+		}
+	}
+	if len(m.logins) >= maxMemoryLogins {
+		return errors.New("too many pending oidc logins")
+	}
+	m.logins[state] = login
+	return nil
+}
+
+func (m *MemoryLogins) Take(ctx context.Context, state string) (core.OIDCLogin, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	login, ok := m.logins[state]
+	if !ok {
+		return core.OIDCLogin{}, core.ErrNotFound
+	}
+	delete(m.logins, state)
+	return login, nil
+}
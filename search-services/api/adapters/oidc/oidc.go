@@ -0,0 +1,398 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"yadro.com/course/api/config"
+	"yadro.com/course/api/core"
+)
+
+// pendingTTL is how long a user has to sign in at the identity provider
+const pendingTTL = 10 * time.Minute
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider implements the authorization code flow with PKCE,
+// the provider metadata and keys are fetched on first use
+type Provider struct {
+	log         *slog.Logger
+	config      config.OIDCConfig
+	roles       map[string]core.Role
+	defaultRole core.Role
+	client      *http.Client
+	now         func() time.Time
+
+	logins core.OIDCLogins
+
+	mu   sync.Mutex
+	meta *discovery
+	keys map[string]any
+}
+
+func New(cfg config.OIDCConfig, logins core.OIDCLogins, log *slog.Logger) (*Provider, error) {
+	if cfg.Issuer == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+		return nil, errors.New("oidc issuer, client id and redirect url are required")
+	}
+
+	roles := make(map[string]core.Role, len(cfg.Roles))
+	for value, role := range cfg.Roles {
+		if !core.Role(role).Valid() {
+			return nil, fmt.Errorf("unknown role %q for %q", role, value)
+		}
+		roles[value] = core.Role(role)
+	}
+	if cfg.DefaultRole != "" && !core.Role(cfg.DefaultRole).Valid() {
+		return nil, fmt.Errorf("unknown default role %q", cfg.DefaultRole)
+	}
+
+	return &Provider{
+		log:         log,
+		config:      cfg,
+		roles:       roles,
+		defaultRole: core.Role(cfg.DefaultRole),
+		client:      &http.Client{Timeout: cfg.Timeout},
+		now:         time.Now,
+		logins:      logins,
+		keys:        make(map[string]any),
+	}, nil
+}
+
+func (p *Provider) AuthURL(ctx context.Context) (string, string, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	state, err := randomString()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := randomString()
+	if err != nil {
+		return "", "", err
+	}
+	verifier, err := randomString()
+	if err != nil {
+		return "", "", err
+	}
+
+	login := core.OIDCLogin{Verifier: verifier, Nonce: nonce, Expires: p.now().Add(pendingTTL)}
+	if err := p.logins.Put(ctx, state, login); err != nil {
+		return "", "", fmt.Errorf("failed to save oidc login: %w", err)
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.config.ClientID},
+		"redirect_uri":          {p.config.RedirectURL},
+		"scope":                 {strings.Join(p.config.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	separator := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return meta.AuthorizationEndpoint + separator + params.Encode(), state, nil
+}
+
+// Exchange redeems the code and checks the ID token, every state is used once
+func (p *Provider) Exchange(ctx context.Context, code, state string) (core.Identity, error) {
+	req, err := p.logins.Take(ctx, state)
+	if err != nil && !errors.Is(err, core.ErrNotFound) {
+		return core.Identity{}, fmt.Errorf("failed to get oidc login: %w", err)
+	}
+	if err != nil || p.now().After(req.Expires) {
+		return core.Identity{}, fmt.Errorf("%w: unknown or expired state", core.ErrInvalidCredentials)
+	}
+
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return core.Identity{}, err
+	}
+
+	idToken, err := p.redeem(ctx, meta, code, req.Verifier)
+	if err != nil {
+		return core.Identity{}, err
+	}
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(idToken, claims, p.keyFunc(ctx, meta),
+		jwt.WithIssuer(meta.Issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(p.now),
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "EdDSA"}),
+	)
+	if err != nil {
+		return core.Identity{}, fmt.Errorf("%w: %v", core.ErrInvalidCredentials, err)
+	}
+	if nonce, _ := claims["nonce"].(string); nonce != req.Nonce {
+		return core.Identity{}, fmt.Errorf("%w: nonce mismatch", core.ErrInvalidCredentials)
+	}
+
+	subject, _ := claims["sub"].(string)
+	name, _ := claims[p.config.UsernameClaim].(string)
+	if name == "" {
+		name = subject
+	}
+	if subject == "" {
+		return core.Identity{}, fmt.Errorf("%w: no subject", core.ErrInvalidCredentials)
+	}
+
+	role, ok := p.mapRole(claims[p.config.RoleClaim])
+	if !ok {
+		p.log.Debug("no gateway role for identity", "subject", subject, "claim", claims[p.config.RoleClaim])
+		return core.Identity{}, core.ErrForbidden
+	}
+	return core.Identity{Issuer: meta.Issuer, Subject: subject, Name: name, Role: role}, nil
+}
+
+// mapRole takes the highest role mapped from a string or a list claim
+func (p *Provider) mapRole(claim any) (core.Role, bool) {
+	var values []string
+	switch v := claim.(type) {
+	case string:
+		values = strings.Fields(v)
+	case []any:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+	}
+
+	var role core.Role
+	for _, value := range values {
+		if mapped, ok := p.roles[value]; ok && !role.Allows(mapped) {
+			role = mapped
+		}
+	}
+	if role == "" {
+		role = p.defaultRole
+	}
+	return role, role != ""
+}
+
+func (p *Provider) redeem(ctx context.Context, meta *discovery, code, verifier string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.config.RedirectURL},
+		"client_id":     {p.config.ClientID},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return "", fmt.Errorf("token endpoint status %d", resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		// invalid_grant and friends: a wrong, used or expired code
+		return "", fmt.Errorf("%w: token endpoint status %d", core.ErrInvalidCredentials, resp.StatusCode)
+	}
+
+	var tokens struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokens); err != nil {
+		return "", err
+	}
+	if tokens.IDToken == "" {
+		return "", errors.New("no id token in token response")
+	}
+	return tokens.IDToken, nil
+}
+
+func (p *Provider) discover(ctx context.Context) (*discovery, error) {
+	p.mu.Lock()
+	meta := p.meta
+	p.mu.Unlock()
+	if meta != nil {
+		return meta, nil
+	}
+
+	meta = &discovery{}
+	address := strings.TrimSuffix(p.config.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := p.getJSON(ctx, address, meta); err != nil {
+		return nil, fmt.Errorf("failed to discover oidc provider: %w", err)
+	}
+	if meta.Issuer != p.config.Issuer {
+		return nil, fmt.Errorf("oidc issuer mismatch: %s", meta.Issuer)
+	}
+
+	p.mu.Lock()
+	p.meta = meta
+	p.mu.Unlock()
+	return meta, nil
+}
+
+// keyFunc refetches the provider keys once when a kid is unknown, it may be a rotated key
+func (p *Provider) keyFunc(ctx context.Context, meta *discovery) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+
+		p.mu.Lock()
+		key, ok := p.keys[kid]
+		p.mu.Unlock()
+		if ok {
+			return key, nil
+		}
+
+		keys, err := p.fetchKeys(ctx, meta)
+		if err != nil {
+			return nil, err
+		}
+		p.mu.Lock()
+		p.keys = keys
+		p.mu.Unlock()
+
+		if key, ok := keys[kid]; ok {
+			return key, nil
+		}
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+}
+
+type jwk struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Curve   string `json:"crv"`
+	N       string `json:"n"`
+	E       string `json:"e"`
+	X       string `json:"x"`
+	Y       string `json:"y"`
+}
+
+func (p *Provider) fetchKeys(ctx context.Context, meta *discovery) (map[string]any, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := p.getJSON(ctx, meta.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("failed to get oidc keys: %w", err)
+	}
+
+	keys := make(map[string]any, len(set.Keys))
+	for _, k := range set.Keys {
+		key, err := k.publicKey()
+		if err != nil {
+			p.log.Debug("skipping oidc key", "kid", k.KeyID, "error", err)
+			continue
+		}
+		keys[k.KeyID] = key
+	}
+	return keys, nil
+}
+
+func (k jwk) publicKey() (any, error) {
+	decode := base64.RawURLEncoding.DecodeString
+	switch k.KeyType {
+	case "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", k.Curve)
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	case "OKP":
+		if k.Curve != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %s", k.Curve)
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("bad ed25519 key size")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %s", k.KeyType)
+}
+
+func (p *Provider) getJSON(ctx context.Context, address string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, address, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
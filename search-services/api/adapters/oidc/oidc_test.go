@@ -0,0 +1,295 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"yadro.com/course/api/config"
+	"yadro.com/course/api/core"
+)
+
+// fakeIdP is a minimal identity provider: discovery, token endpoint and keys
+type fakeIdP struct {
+	server    *httptest.Server
+	key       *rsa.PrivateKey
+	challenge string
+	nonce     string
+	status    int
+	modify    func(jwt.MapClaims)
+}
+
+func newFakeIdP(t *testing.T) *fakeIdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	idp := &fakeIdP{key: key, status: http.StatusOK}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("GET /keys", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "idp-key",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		user, secret, ok := r.BasicAuth()
+		verifier := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+		if !ok || user != "comics" || secret != "secret" || r.FormValue("code") != "good-code" ||
+			base64.RawURLEncoding.EncodeToString(verifier[:]) != idp.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if idp.status != http.StatusOK {
+			w.WriteHeader(idp.status)
+			return
+		}
+
+		claims := jwt.MapClaims{
+			"iss":                idp.server.URL,
+			"aud":                "comics",
+			"sub":                "user-1",
+			"exp":                time.Now().Add(time.Minute).Unix(),
+			"nonce":              idp.nonce,
+			"preferred_username": "alice",
+			"groups":             []string{"staff", "comics-operators"},
+		}
+		if idp.modify != nil {
+			idp.modify(claims)
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "idp-key"
+		signed, err := token.SignedString(key)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"id_token": signed})
+	})
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func newTestProvider(t *testing.T, idp *fakeIdP, defaultRole string) *Provider {
+	t.Helper()
+	provider, err := New(config.OIDCConfig{
+		Issuer:        idp.server.URL,
+		ClientID:      "comics",
+		ClientSecret:  "secret",
+		RedirectURL:   "http://localhost/login/oidc/callback",
+		Scopes:        []string{"openid", "profile"},
+		UsernameClaim: "preferred_username",
+		RoleClaim:     "groups",
+		Roles: map[string]string{
+			"comics-admins":    "admin",
+			"comics-operators": "operator",
+		},
+		DefaultRole: defaultRole,
+		Timeout:     time.Second,
+	}, NewMemoryLogins(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	return provider
+}
+
+// authorize plays the browser part: the IdP remembers what the authorization request carried
+func authorize(t *testing.T, provider *Provider, idp *fakeIdP) string {
+	t.Helper()
+	address, state, err := provider.AuthURL(context.Background())
+	require.NoError(t, err)
+
+	u, err := url.Parse(address)
+	require.NoError(t, err)
+	query := u.Query()
+	assert.Equal(t, "/authorize", u.Path)
+	assert.Equal(t, "code", query.Get("response_type"))
+	assert.Equal(t, "comics", query.Get("client_id"))
+	assert.Equal(t, "openid profile", query.Get("scope"))
+	assert.Equal(t, "S256", query.Get("code_challenge_method"))
+	assert.Equal(t, state, query.Get("state"))
+
+	idp.challenge = query.Get("code_challenge")
+	idp.nonce = query.Get("nonce")
+	return state
+}
+
+func TestProvider_Exchange(t *testing.T) {
+	tests := []struct {
+		name        string
+		defaultRole string
+		modify      func(jwt.MapClaims)
+		status      int
+		wantName    string
+		wantRole    core.Role
+		wantErr     error
+	}{
+		{
+			name:     "mapped role",
+			wantName: "alice",
+			wantRole: core.RoleOperator,
+		},
+		{
+			name:     "highest role wins",
+			modify:   func(c jwt.MapClaims) { c["groups"] = []string{"comics-admins", "comics-operators"} },
+			wantName: "alice",
+			wantRole: core.RoleAdmin,
+		},
+		{
+			name:     "string claim",
+			modify:   func(c jwt.MapClaims) { c["groups"] = "comics-admins" },
+			wantName: "alice",
+			wantRole: core.RoleAdmin,
+		},
+		{
+			name:        "default role",
+			defaultRole: "viewer",
+			modify:      func(c jwt.MapClaims) { c["groups"] = []string{"staff"} },
+			wantName:    "alice",
+			wantRole:    core.RoleViewer,
+		},
+		{
+			name:    "no role",
+			modify:  func(c jwt.MapClaims) { delete(c, "groups") },
+			wantErr: core.ErrForbidden,
+		},
+		{
+			name:     "subject as name",
+			modify:   func(c jwt.MapClaims) { delete(c, "preferred_username") },
+			wantName: "user-1",
+			wantRole: core.RoleOperator,
+		},
+		{
+			name:    "wrong nonce",
+			modify:  func(c jwt.MapClaims) { c["nonce"] = "replayed" },
+			wantErr: core.ErrInvalidCredentials,
+		},
+		{
+			name:    "wrong audience",
+			modify:  func(c jwt.MapClaims) { c["aud"] = "other-client" },
+			wantErr: core.ErrInvalidCredentials,
+		},
+		{
+			name:    "wrong issuer",
+			modify:  func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" },
+			wantErr: core.ErrInvalidCredentials,
+		},
+		{
+			name:    "expired",
+			modify:  func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() },
+			wantErr: core.ErrInvalidCredentials,
+		},
+		{
+			name:    "rejected code",
+			status:  http.StatusBadRequest,
+			wantErr: core.ErrInvalidCredentials,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idp := newFakeIdP(t)
+			idp.modify = tt.modify
+			if tt.status != 0 {
+				idp.status = tt.status
+			}
+			provider := newTestProvider(t, idp, tt.defaultRole)
+
+			state := authorize(t, provider, idp)
+			identity, err := provider.Exchange(context.Background(), "good-code", state)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, idp.server.URL, identity.Issuer)
+			assert.Equal(t, "user-1", identity.Subject)
+			assert.Equal(t, tt.wantName, identity.Name)
+			assert.Equal(t, tt.wantRole, identity.Role)
+		})
+	}
+}
+
+func TestProvider_ExchangeState(t *testing.T) {
+	idp := newFakeIdP(t)
+	provider := newTestProvider(t, idp, "")
+
+	_, err := provider.Exchange(context.Background(), "good-code", "unknown")
+	assert.ErrorIs(t, err, core.ErrInvalidCredentials)
+
+	// state одноразовый
+	state := authorize(t, provider, idp)
+	_, err = provider.Exchange(context.Background(), "good-code", state)
+	require.NoError(t, err)
+	_, err = provider.Exchange(context.Background(), "good-code", state)
+	assert.ErrorIs(t, err, core.ErrInvalidCredentials)
+
+	// просроченный запрос авторизации
+	state = authorize(t, provider, idp)
+	provider.now = func() time.Time { return time.Now().Add(pendingTTL + time.Minute) }
+	_, err = provider.Exchange(context.Background(), "good-code", state)
+	assert.ErrorIs(t, err, core.ErrInvalidCredentials)
+}
+
+func TestNew_BadConfig(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	base := config.OIDCConfig{Issuer: "https://sso.example.com", ClientID: "comics", RedirectURL: "http://localhost/cb"}
+
+	_, err := New(base, NewMemoryLogins(), log)
+	assert.NoError(t, err)
+
+	noClient := base
+	noClient.ClientID = ""
+	_, err = New(noClient, NewMemoryLogins(), log)
+	assert.Error(t, err)
+
+	badRole := base
+	badRole.Roles = map[string]string{"admins": "root"}
+	_, err = New(badRole, NewMemoryLogins(), log)
+	assert.Error(t, err)
+
+	badDefault := base
+	badDefault.DefaultRole = "root"
+	_, err = New(badDefault, NewMemoryLogins(), log)
+	assert.Error(t, err)
+}
+
+func TestMemoryLogins_Limit(t *testing.T) {
+	logins := NewMemoryLogins()
+	expires := time.Now().Add(time.Minute)
+	for i := range maxMemoryLogins {
+		require.NoError(t, logins.Put(context.Background(), strconv.Itoa(i), core.OIDCLogin{Expires: expires}))
+	}
+	// открытый authorize не должен раздувать память без предела
+	assert.Error(t, logins.Put(context.Background(), "extra", core.OIDCLogin{Expires: expires}))
+
+	// просроченные записи освобождают место
+	logins.now = func() time.Time { return expires.Add(time.Second) }
+	assert.NoError(t, logins.Put(context.Background(), "extra", core.OIDCLogin{Expires: expires.Add(time.Hour)}))
+	assert.Len(t, logins.logins, 1)
+}
@@ -2,10 +2,10 @@ package rest
 
 import (
 	"encoding/json"
-	"fmt"
 	"log/slog"
 	"net/http"
 	"strconv"
+	"time"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -32,26 +32,68 @@ func NewPingHandler(log *slog.Logger, pingers map[string]core.Pinger) http.Handl
 	}
 }
 
+// NewMetricsHandler reports the queues of concurrency limited routes and the caches
+func NewMetricsHandler(log *slog.Logger, queues map[string]core.QueueMonitor, caches map[string]core.CacheMonitor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp := make(map[string]interface{})
+		for name, queue := range queues {
+			stats := queue.Stats()
+			var waitAvg float64
+			if stats.WaitCount > 0 {
+				waitAvg = (stats.WaitTotal / time.Duration(stats.WaitCount)).Seconds()
+			}
+			resp[name] = map[string]interface{}{
+				"in_flight":        stats.InFlight,
+				"queue_depth":      stats.Depth,
+				"queue_size":       stats.Size,
+				"acquired_total":   stats.Acquired,
+				"queued_total":     stats.Queued,
+				"rejected_total":   stats.Rejected,
+				"timed_out_total":  stats.TimedOut,
+				"wait_seconds_avg": waitAvg,
+				"wait_seconds_max": stats.WaitMax.Seconds(),
+			}
+		}
+		cacheResp := make(map[string]interface{})
+		for name, cache := range caches {
+			stats := cache.Stats()
+			cacheResp[name] = map[string]interface{}{
+				"hits":      stats.Hits,
+				"misses":    stats.Misses,
+				"evictions": stats.Evictions,
+				"size":      stats.Size,
+			}
+		}
+		wrappedResp := map[string]interface{}{
+			"queues": resp,
+			"caches": cacheResp,
+		}
+		err := json.NewEncoder(w).Encode(wrappedResp)
+		if err != nil {
+			log.Error("failed to encode response", "error", err)
+		}
+	}
+}
+
 func NewWordsHandler(log *slog.Logger, normalizer core.Normalizer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		phrase := r.URL.Query().Get("phrase")
 		if phrase == "" {
-			w.WriteHeader(http.StatusBadRequest)
-			fmt.Fprint(w, core.ErrBadArguments.Error())
+			writeError(w, r, http.StatusBadRequest, core.ErrBadArguments.Error())
 			return
 		}
 
-		words, err := normalizer.Norm(r.Context(), phrase)
+		words, err := normalizer.Norm(r.Context(), phrase, r.URL.Query().Get("lang"))
 		if err != nil {
-			currStatus := http.StatusInternalServerError
-			if code := status.Code(err); code == codes.ResourceExhausted {
-				currStatus = http.StatusBadRequest
+			// too long phrase is the client's fault, not a lack of resources
+			if status.Code(err) == codes.ResourceExhausted {
 				log.Debug("received message larger than 4KB", "phrase", phrase)
-			} else {
-				log.Error("failed to normalize phrase", "error", err)
+				writeErrorDetails(w, r, http.StatusBadRequest, "error normalizing phrase", map[string]interface{}{
+					"reason": core.ErrMessageTooLarge.Error(),
+				})
+				return
 			}
-			w.WriteHeader(currStatus)
-			fmt.Fprintf(w, "error normalizing phrase")
+			writeFailure(w, r, log, err, "error normalizing phrase")
 			return
 		}
 
@@ -70,26 +112,143 @@ func NewUpdateHandler(log *slog.Logger, updater core.Updater) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		err := updater.Update(r.Context())
 		if err != nil {
-			if code := status.Code(err); code == codes.AlreadyExists {
+			if status.Code(err) == codes.AlreadyExists {
+				log.Debug("already updating", "error", err)
+				w.WriteHeader(http.StatusAccepted)
+				return
+			}
+			writeFailure(w, r, log, err, "error updating")
+			return
+		}
+	}
+}
+
+func NewReindexHandler(log *slog.Logger, updater core.Updater) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, err := parseReindexFilter(r)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, core.ErrBadArguments.Error())
+			return
+		}
+
+		err = updater.Reindex(r.Context(), filter)
+		if err != nil {
+			if status.Code(err) == codes.AlreadyExists {
 				log.Debug("already updating", "error", err)
 				w.WriteHeader(http.StatusAccepted)
 				return
 			}
-			log.Error("failed to update", "error", err)
-			w.WriteHeader(http.StatusInternalServerError)
-			fmt.Fprintf(w, "error updating")
+			writeFailure(w, r, log, err, "error reindexing")
 			return
 		}
 	}
 }
 
+func NewRenormalizeHandler(log *slog.Logger, updater core.Updater) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		onlyStale := false
+		if stale := r.URL.Query().Get("stale"); stale != "" {
+			var err error
+			onlyStale, err = strconv.ParseBool(stale)
+			if err != nil {
+				writeError(w, r, http.StatusBadRequest, core.ErrBadArguments.Error())
+				return
+			}
+		}
+
+		err := updater.Renormalize(r.Context(), onlyStale)
+		if err != nil {
+			if status.Code(err) == codes.AlreadyExists {
+				log.Debug("already updating", "error", err)
+				w.WriteHeader(http.StatusAccepted)
+				return
+			}
+			writeFailure(w, r, log, err, "error renormalizing")
+			return
+		}
+	}
+}
+
+func parseReindexFilter(r *http.Request) (core.ReindexFilter, error) {
+	var filter core.ReindexFilter
+	query := r.URL.Query()
+	filter.Source = query.Get("source")
+
+	if from := query.Get("from"); from != "" {
+		id, err := strconv.Atoi(from)
+		if err != nil || id < 0 {
+			return filter, core.ErrBadArguments
+		}
+		filter.FromID = id
+	}
+
+	if to := query.Get("to"); to != "" {
+		id, err := strconv.Atoi(to)
+		if err != nil || id < 0 {
+			return filter, core.ErrBadArguments
+		}
+		filter.ToID = id
+	}
+
+	if olderThan := query.Get("older_than"); olderThan != "" {
+		t, err := time.Parse(time.RFC3339, olderThan)
+		if err != nil {
+			return filter, core.ErrBadArguments
+		}
+		filter.OlderThan = t
+	}
+
+	return filter, nil
+}
+
+func NewComicImageHandler(log *slog.Logger, images core.ImageProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil || id <= 0 {
+			writeError(w, r, http.StatusBadRequest, core.ErrBadArguments.Error())
+			return
+		}
+
+		thumbnail := false
+		switch r.URL.Query().Get("size") {
+		case "", "original":
+		case "thumb":
+			thumbnail = true
+		default:
+			writeError(w, r, http.StatusBadRequest, core.ErrBadArguments.Error())
+			return
+		}
+
+		image, err := images.Image(r.Context(), r.URL.Query().Get("source"), id, thumbnail)
+		if err != nil {
+			writeFailure(w, r, log, err, "error getting image")
+			return
+		}
+
+		etag := `"` + image.Hash + `"`
+		if thumbnail {
+			etag = `"` + image.Hash + `-thumb"`
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		if etagMatch(r.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("Content-Type", image.ContentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(image.Data)))
+		if _, err := w.Write(image.Data); err != nil {
+			log.Error("failed to write image", "id", id, "error", err)
+		}
+	}
+}
+
 func NewUpdateStatsHandler(log *slog.Logger, updater core.Updater) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		stats, err := updater.Stats(r.Context())
 		if err != nil {
-			log.Error("failed to get stats", "error", err)
-			w.WriteHeader(http.StatusInternalServerError)
-			fmt.Fprintf(w, "error getting stats")
+			writeFailure(w, r, log, err, "error getting stats")
 			return
 		}
 
@@ -98,6 +257,7 @@ func NewUpdateStatsHandler(log *slog.Logger, updater core.Updater) http.HandlerF
 			"words_unique":   stats.WordsUnique,
 			"comics_fetched": stats.ComicsFetched,
 			"comics_total":   stats.ComicsTotal,
+			"comics_stale":   stats.ComicsStale,
 		}
 		err = json.NewEncoder(w).Encode(response)
 		if err != nil {
@@ -110,9 +270,7 @@ func NewUpdateStatusHandler(log *slog.Logger, updater core.Updater) http.Handler
 	return func(w http.ResponseWriter, r *http.Request) {
 		status, err := updater.Status(r.Context())
 		if err != nil {
-			log.Error("failed to get status", "error", err)
-			w.WriteHeader(http.StatusInternalServerError)
-			fmt.Fprintf(w, "error getting status")
+			writeFailure(w, r, log, err, "error getting status")
 			return
 		}
 		response := map[string]interface{}{
@@ -129,9 +287,7 @@ func NewDropHandler(log *slog.Logger, updater core.Updater) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		err := updater.Drop(r.Context())
 		if err != nil {
-			log.Error("failed to drop", "error", err)
-			w.WriteHeader(http.StatusInternalServerError)
-			fmt.Fprintf(w, "error dropping")
+			writeFailure(w, r, log, err, "error dropping")
 			return
 		}
 	}
@@ -142,8 +298,7 @@ func NewSearchHandler(log *slog.Logger, searcher core.Searcher) http.HandlerFunc
 		phrase := r.URL.Query().Get("phrase")
 		limit := r.URL.Query().Get("limit")
 		if phrase == "" {
-			w.WriteHeader(http.StatusBadRequest)
-			fmt.Fprint(w, core.ErrBadArguments.Error())
+			writeError(w, r, http.StatusBadRequest, core.ErrBadArguments.Error())
 			return
 		}
 
@@ -153,22 +308,18 @@ func NewSearchHandler(log *slog.Logger, searcher core.Searcher) http.HandlerFunc
 
 		limitInt, err := strconv.Atoi(limit)
 		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			fmt.Fprint(w, core.ErrBadArguments.Error())
+			writeError(w, r, http.StatusBadRequest, core.ErrBadArguments.Error())
 			return
 		}
 
 		if limitInt < 0 {
-			w.WriteHeader(http.StatusBadRequest)
-			fmt.Fprint(w, core.ErrBadArguments.Error())
+			writeError(w, r, http.StatusBadRequest, core.ErrBadArguments.Error())
 			return
 		}
 
-		comics, total, err := searcher.Search(r.Context(), phrase, limitInt)
+		comics, total, err := searcher.Search(r.Context(), phrase, limitInt, r.URL.Query().Get("source"))
 		if err != nil {
-			log.Error("failed to search", "error", err)
-			w.WriteHeader(http.StatusInternalServerError)
-			fmt.Fprintf(w, "error searching")
+			writeFailure(w, r, log, err, "error searching")
 			return
 		}
 
@@ -188,8 +339,7 @@ func NewISearchHandler(log *slog.Logger, searcher core.Searcher) http.HandlerFun
 		phrase := r.URL.Query().Get("phrase")
 		limit := r.URL.Query().Get("limit")
 		if phrase == "" {
-			w.WriteHeader(http.StatusBadRequest)
-			fmt.Fprint(w, core.ErrBadArguments.Error())
+			writeError(w, r, http.StatusBadRequest, core.ErrBadArguments.Error())
 			return
 		}
 
@@ -199,22 +349,18 @@ func NewISearchHandler(log *slog.Logger, searcher core.Searcher) http.HandlerFun
 
 		limitInt, err := strconv.Atoi(limit)
 		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			fmt.Fprint(w, core.ErrBadArguments.Error())
+			writeError(w, r, http.StatusBadRequest, core.ErrBadArguments.Error())
 			return
 		}
 
 		if limitInt < 0 {
-			w.WriteHeader(http.StatusBadRequest)
-			fmt.Fprint(w, core.ErrBadArguments.Error())
+			writeError(w, r, http.StatusBadRequest, core.ErrBadArguments.Error())
 			return
 		}
 
-		comics, total, err := searcher.ISearch(r.Context(), phrase, limitInt)
+		comics, total, err := searcher.ISearch(r.Context(), phrase, limitInt, r.URL.Query().Get("source"))
 		if err != nil {
-			log.Error("failed to search", "error", err)
-			w.WriteHeader(http.StatusInternalServerError)
-			fmt.Fprintf(w, "error searching")
+			writeFailure(w, r, log, err, "error searching")
 			return
 		}
 
@@ -0,0 +1,121 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"yadro.com/course/api/core"
+)
+
+type apiKeyRequest struct {
+	Name string    `json:"name"`
+	Role core.Role `json:"role"`
+}
+
+type apiKeyResponse struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Role       core.Role  `json:"role"`
+	Key        string     `json:"key,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+func toAPIKeyResponse(key core.APIKey) apiKeyResponse {
+	resp := apiKeyResponse{
+		ID:        key.ID,
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		Role:      key.Role,
+		CreatedAt: key.CreatedAt,
+	}
+	if !key.LastUsedAt.IsZero() {
+		resp.LastUsedAt = &key.LastUsedAt
+	}
+	if key.Revoked() {
+		resp.RevokedAt = &key.RevokedAt
+	}
+	return resp
+}
+
+func NewListAPIKeysHandler(log *slog.Logger, keys core.APIKeyManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		list, err := keys.List(r.Context())
+		if err != nil {
+			log.Error("failed to list api keys", "error", err)
+			writeError(w, r, http.StatusInternalServerError, "error listing api keys")
+			return
+		}
+
+		resp := make([]apiKeyResponse, 0, len(list))
+		for _, key := range list {
+			resp = append(resp, toAPIKeyResponse(key))
+		}
+		response := map[string]interface{}{
+			"keys":  resp,
+			"total": len(resp),
+		}
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Error("failed to encode response", "error", err)
+		}
+	}
+}
+
+// NewCreateAPIKeyHandler returns the key in the response only once
+func NewCreateAPIKeyHandler(log *slog.Logger, keys core.APIKeyManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req apiKeyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, r, http.StatusBadRequest, core.ErrBadArguments.Error())
+			return
+		}
+		if req.Role == "" {
+			req.Role = core.RoleViewer
+		}
+
+		key, secret, err := keys.Create(r.Context(), req.Name, req.Role)
+		if err != nil {
+			if errors.Is(err, core.ErrBadArguments) {
+				writeError(w, r, http.StatusBadRequest, core.ErrBadArguments.Error())
+				return
+			}
+			log.Error("failed to create api key", "error", err)
+			writeError(w, r, http.StatusInternalServerError, "error creating api key")
+			return
+		}
+
+		resp := toAPIKeyResponse(key)
+		resp.Key = secret
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			log.Error("failed to encode response", "error", err)
+		}
+	}
+}
+
+func NewRevokeAPIKeyHandler(log *slog.Logger, keys core.APIKeyManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil || id <= 0 {
+			writeError(w, r, http.StatusBadRequest, core.ErrBadArguments.Error())
+			return
+		}
+
+		if err := keys.Revoke(r.Context(), id); err != nil {
+			if errors.Is(err, core.ErrNotFound) {
+				writeError(w, r, http.StatusNotFound, "api key not found")
+				return
+			}
+			log.Error("failed to revoke api key", "id", id, "error", err)
+			writeError(w, r, http.StatusInternalServerError, "error revoking api key")
+			return
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package rest
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"yadro.com/course/api/core"
+)
+
+type stubImages struct{}
+
+func (stubImages) Image(ctx context.Context, source string, id int, thumbnail bool) (core.Image, error) {
+	return core.Image{Data: []byte("png"), ContentType: "image/png", Hash: "abc"}, nil
+}
+
+func TestComicImageHandler_IfNoneMatch(t *testing.T) {
+	handler := NewComicImageHandler(slog.New(slog.NewTextHandler(io.Discard, nil)), stubImages{})
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		expected    int
+	}{
+		{name: "no header", expected: http.StatusOK},
+		{name: "exact", ifNoneMatch: `"abc"`, expected: http.StatusNotModified},
+		{name: "weak", ifNoneMatch: `W/"abc"`, expected: http.StatusNotModified},
+		{name: "list", ifNoneMatch: `"old", "abc"`, expected: http.StatusNotModified},
+		{name: "other", ifNoneMatch: `"old"`, expected: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/comics/1/image", nil)
+			req.SetPathValue("id", "1")
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.Equal(t, tt.expected, rec.Code)
+		})
+	}
+}
+
+func TestWithETag(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	handler := WithETag(time.Minute, log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "9")
+		_, _ = io.WriteString(w, `{"comics":[]}`)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/search?phrase=cat", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	// ответ с лимитами клиента не должен попадать в общие кэши
+	assert.Equal(t, "private, max-age=60", rec.Header().Get("Cache-Control"))
+	assert.Equal(t, "Authorization, X-API-Key", rec.Header().Get("Vary"))
+
+	etag := rec.Header().Get("ETag")
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Body.String())
+}
@@ -0,0 +1,144 @@
+package rest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"yadro.com/course/api/core"
+)
+
+// statusClientClosedRequest is used by nginx and grpc-gateway for requests the client gave up on
+const statusClientClosedRequest = 499
+
+type requestIDKey struct{}
+
+// requestIDPattern keeps client supplied ids safe to log and echo back
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// grpcStatuses follow the mapping of grpc-gateway
+var grpcStatuses = map[codes.Code]int{
+	codes.OK:                 http.StatusOK,
+	codes.Canceled:           statusClientClosedRequest,
+	codes.Unknown:            http.StatusInternalServerError,
+	codes.InvalidArgument:    http.StatusBadRequest,
+	codes.DeadlineExceeded:   http.StatusGatewayTimeout,
+	codes.NotFound:           http.StatusNotFound,
+	codes.AlreadyExists:      http.StatusConflict,
+	codes.PermissionDenied:   http.StatusForbidden,
+	codes.Unauthenticated:    http.StatusUnauthorized,
+	codes.ResourceExhausted:  http.StatusTooManyRequests,
+	codes.FailedPrecondition: http.StatusBadRequest,
+	codes.Aborted:            http.StatusConflict,
+	codes.OutOfRange:         http.StatusBadRequest,
+	codes.Unimplemented:      http.StatusNotImplemented,
+	codes.Internal:           http.StatusInternalServerError,
+	codes.Unavailable:        http.StatusServiceUnavailable,
+	codes.DataLoss:           http.StatusInternalServerError,
+}
+
+type errorResponse struct {
+	Error errorBody `json:"error"`
+}
+
+type errorBody struct {
+	Code      string                 `json:"code"`
+	Message   string                 `json:"message"`
+	RequestID string                 `json:"request_id,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+}
+
+// WithRequestID takes X-Request-ID from the client or makes a new one,
+// it is returned in the response, every error and passed to the services
+func WithRequestID(log *slog.Logger) core.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get("X-Request-ID")
+			if !requestIDPattern.MatchString(id) {
+				b := make([]byte, 8)
+				if _, err := rand.Read(b); err != nil {
+					log.Error("failed to make request id", "error", err)
+				}
+				id = hex.EncodeToString(b)
+			}
+
+			w.Header().Set("X-Request-ID", id)
+			ctx := context.WithValue(r.Context(), requestIDKey{}, id)
+			ctx = metadata.AppendToOutgoingContext(ctx, "x-request-id", id)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func requestID(r *http.Request) string {
+	id, _ := r.Context().Value(requestIDKey{}).(string)
+	return id
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	writeErrorDetails(w, r, status, message, nil)
+}
+
+// writeErrorDetails sends the error envelope, the code is the status text in snake case
+func writeErrorDetails(w http.ResponseWriter, r *http.Request, status int, message string, details map[string]interface{}) {
+	code := strings.ToLower(strings.ReplaceAll(http.StatusText(status), " ", "_"))
+	if status == statusClientClosedRequest {
+		code = "client_closed_request"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(errorResponse{Error: errorBody{
+		Code:      code,
+		Message:   message,
+		RequestID: requestID(r),
+		Details:   details,
+	}})
+}
+
+// writeFailure answers with the status of err, only server side failures are logged as errors
+func writeFailure(w http.ResponseWriter, r *http.Request, log *slog.Logger, err error, message string) {
+	status := errorStatus(err)
+	if status >= http.StatusInternalServerError {
+		log.Error(message, "error", err, "request_id", requestID(r))
+	} else {
+		log.Debug(message, "error", err, "request_id", requestID(r))
+	}
+	writeError(w, r, status, message)
+}
+
+// errorStatus maps core errors and gRPC statuses of the services onto HTTP
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, core.ErrBadArguments), errors.Is(err, core.ErrMessageTooLarge):
+		return http.StatusBadRequest
+	case errors.Is(err, core.ErrInvalidCredentials), errors.Is(err, core.ErrInvalidToken):
+		return http.StatusUnauthorized
+	case errors.Is(err, core.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, core.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, core.ErrAlreadyExists):
+		return http.StatusConflict
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, context.Canceled):
+		return statusClientClosedRequest
+	}
+	if st, ok := status.FromError(err); ok {
+		if code, ok := grpcStatuses[st.Code()]; ok {
+			return code
+		}
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,122 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"yadro.com/course/api/core"
+)
+
+func TestErrorStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{name: "bad arguments", err: core.ErrBadArguments, expected: http.StatusBadRequest},
+		{name: "wrapped not found", err: fmt.Errorf("image: %w", core.ErrNotFound), expected: http.StatusNotFound},
+		{name: "invalid token", err: core.ErrInvalidToken, expected: http.StatusUnauthorized},
+		{name: "forbidden", err: core.ErrForbidden, expected: http.StatusForbidden},
+		{name: "already exists", err: core.ErrAlreadyExists, expected: http.StatusConflict},
+		{name: "deadline", err: context.DeadlineExceeded, expected: http.StatusGatewayTimeout},
+		{name: "canceled", err: context.Canceled, expected: statusClientClosedRequest},
+		{name: "grpc invalid argument", err: status.Error(codes.InvalidArgument, "bad"), expected: http.StatusBadRequest},
+		{name: "grpc unavailable", err: status.Error(codes.Unavailable, "down"), expected: http.StatusServiceUnavailable},
+		{name: "grpc exhausted", err: status.Error(codes.ResourceExhausted, "busy"), expected: http.StatusTooManyRequests},
+		{name: "grpc deadline", err: status.Error(codes.DeadlineExceeded, "slow"), expected: http.StatusGatewayTimeout},
+		{name: "unknown", err: fmt.Errorf("boom"), expected: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, errorStatus(tt.err))
+		})
+	}
+}
+
+func TestWriteFailure_Envelope(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	handler := WithRequestID(log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeFailure(w, r, log, status.Error(codes.NotFound, "no comics"), "error searching")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/search", nil)
+	req.Header.Set("X-Request-ID", "req-42")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "req-42", rec.Header().Get("X-Request-ID"))
+
+	var body errorResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.Equal(t, errorBody{Code: "not_found", Message: "error searching", RequestID: "req-42"}, body.Error)
+}
+
+func TestWithRequestID_Generated(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	var seen string
+	handler := WithRequestID(log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = requestID(r)
+	}))
+
+	// небезопасный id заменяется новым
+	req := httptest.NewRequest(http.MethodGet, "/api/ping", nil)
+	req.Header.Set("X-Request-ID", "bad id\n")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Len(t, seen, 16)
+	assert.Equal(t, seen, rec.Header().Get("X-Request-ID"))
+}
+
+func TestWithAuth_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		role     core.Role
+		token    string
+		expected int
+		code     string
+	}{
+		{name: "no token", role: core.RoleViewer, expected: http.StatusUnauthorized, code: "unauthorized"},
+		{name: "low role", role: core.RoleAdmin, token: "viewer", expected: http.StatusForbidden, code: "forbidden"},
+	}
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := WithPrincipal(stubAuth{}, nil, nil)(WithAuth(tt.role, log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+			req := httptest.NewRequest(http.MethodGet, "/api/users", nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Token "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expected, rec.Code)
+			var body errorResponse
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+			assert.Equal(t, tt.code, body.Error.Code)
+		})
+	}
+}
+
+type stubAuth struct {
+	core.Authenticator
+}
+
+func (stubAuth) ValidateToken(ctx context.Context, token string) (core.Claims, error) {
+	return core.Claims{Subject: token, Role: core.Role(token)}, nil
+}
@@ -1,10 +1,17 @@
 package rest
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"yadro.com/course/api/core"
 )
@@ -14,24 +21,26 @@ type loginRequest struct {
 	Password string `json:"password"`
 }
 
-func WithAuth(auth core.Authenticator, log *slog.Logger) core.Middleware {
+// WithAuth lets through requests with a valid token or API key
+// of a role not lower than the required one, the caller comes from WithPrincipal
+func WithAuth(role core.Role, log *slog.Logger) core.Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			authHeader := r.Header.Get("Authorization")
-			if authHeader == "" {
-				w.WriteHeader(http.StatusUnauthorized)
+			claims, err := principalFrom(r).Claims()
+			if err != nil {
+				if errors.Is(err, core.ErrInvalidToken) {
+					log.Debug("unauthorized", "error", err)
+					writeError(w, r, http.StatusUnauthorized, "invalid or missing credentials")
+					return
+				}
+				writeFailure(w, r, log, err, "failed to authenticate")
 				return
 			}
-
-			parts := strings.Split(authHeader, " ")
-			if len(parts) != 2 || parts[0] != "Token" {
-				w.WriteHeader(http.StatusUnauthorized)
-				return
-			}
-
-			if err := auth.ValidateToken(parts[1]); err != nil {
-				log.Error("invalid token", "error", err)
-				w.WriteHeader(http.StatusUnauthorized)
+			if !claims.Role.Allows(role) {
+				log.Debug("not enough privileges", "user", claims.Subject, "role", claims.Role, "required", role)
+				writeErrorDetails(w, r, http.StatusForbidden, "not enough privileges", map[string]interface{}{
+					"required_role": role,
+				})
 				return
 			}
 
@@ -40,51 +49,187 @@ func WithAuth(auth core.Authenticator, log *slog.Logger) core.Middleware {
 	}
 }
 
+// authenticate takes an API key from X-API-Key or a token from "Authorization: Token ...",
+// keys may be nil
+func authenticate(r *http.Request, auth core.Authenticator, keys core.APIKeyManager) (core.Claims, error) {
+	if key := r.Header.Get("X-API-Key"); key != "" && keys != nil {
+		apiKey, err := keys.Authenticate(r.Context(), key)
+		if err != nil {
+			return core.Claims{}, err
+		}
+		// names are not unique, two keys named alike must not share a quota
+		return core.Claims{Subject: "key:" + strconv.FormatInt(apiKey.ID, 10), Role: apiKey.Role}, nil
+	}
+
+	parts := strings.Split(r.Header.Get("Authorization"), " ")
+	if len(parts) != 2 || parts[0] != "Token" {
+		return core.Claims{}, core.ErrInvalidToken
+	}
+	return auth.ValidateToken(r.Context(), parts[1])
+}
+
+// WithRateLimit refuses requests over the quota of the client with 429,
+// clients are told apart by API key or user and otherwise by address
 func WithRateLimit(limiter core.RateLimiter, log *slog.Logger) core.Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			limiter.Wait()
+			key := clientKey(r)
+			limit, err := limiter.Allow(r.Context(), key)
+			if err != nil {
+				// a broken limiter should not take the route down
+				log.Error("failed to check rate limit", "client", key, "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(limit.Limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(limit.Remaining))
+			w.Header().Set("X-RateLimit-Reset", strconv.Itoa(seconds(limit.Reset)))
+			if !limit.Allowed {
+				log.Debug("rate limit exceeded", "client", key, "path", r.URL.Path)
+				w.Header().Set("Retry-After", strconv.Itoa(max(1, seconds(limit.RetryAfter))))
+				writeErrorDetails(w, r, http.StatusTooManyRequests, "rate limit exceeded", map[string]interface{}{
+					"limit":       limit.Limit,
+					"retry_after": max(1, seconds(limit.RetryAfter)),
+				})
+				return
+			}
 			next.ServeHTTP(w, r)
 		})
 	}
 }
 
+// clientKey is the key or user name, local names can't contain ':'
+// so they don't clash with keys and addresses
+func clientKey(r *http.Request) string {
+	p := principalFrom(r)
+	if claims, err := p.Claims(); err == nil {
+		return claims.Subject
+	}
+	return "ip:" + p.addr
+}
+
+// seconds rounds up, clients should not come back before the time
+func seconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
+
+// WithConcurrencyLimit answers 503 when there is no slot,
+// requests with a valid token or API key go first in the queue
 func WithConcurrencyLimit(limiter core.ConcurrencyLimiter, log *slog.Logger) core.Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if !limiter.Acquire() {
-				w.WriteHeader(http.StatusServiceUnavailable)
+			_, err := principalFrom(r).Claims()
+			priority := err == nil
+
+			// waiting in the queue stops when the client goes away
+			release, ok, err := limiter.Acquire(r.Context(), priority)
+			if err != nil {
+				log.Error("failed to check concurrency limit", "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !ok {
 				log.Debug("concurrency limit exceeded")
+				writeError(w, r, http.StatusServiceUnavailable, "too many concurrent requests")
 				return
 			}
-			defer limiter.Release()
+			defer release()
 			next.ServeHTTP(w, r)
 			log.Debug("concurrency limit released")
 		})
 	}
 }
 
-func NewLoginHandler(auth core.Authenticator, log *slog.Logger) http.HandlerFunc {
+// WithETag tags successful responses with a hash of the body, so clients
+// can reuse them for maxAge and revalidate with If-None-Match afterwards.
+// Responses are private: they carry per-client rate limit headers and depend on credentials.
+func WithETag(maxAge time.Duration, log *slog.Logger) core.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			buffered := &bufferedWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(buffered, r)
+
+			if buffered.status == http.StatusOK {
+				sum := sha256.Sum256(buffered.body.Bytes())
+				etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+				w.Header().Set("ETag", etag)
+				w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", int(maxAge.Seconds())))
+				w.Header().Add("Vary", "Authorization, X-API-Key")
+				if etagMatch(r.Header.Get("If-None-Match"), etag) {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+			}
+
+			w.WriteHeader(buffered.status)
+			if _, err := w.Write(buffered.body.Bytes()); err != nil {
+				log.Debug("failed to write response", "error", err)
+			}
+		})
+	}
+}
+
+// bufferedWriter holds the body until the handler is done, headers go through
+type bufferedWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+func etagMatch(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func NewLoginHandler(auth core.Authenticator, users core.UserManager, log *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req loginRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			log.Error("failed to decode login request", "error", err)
-			w.WriteHeader(http.StatusBadRequest)
+			log.Debug("failed to decode login request", "error", err)
+			writeError(w, r, http.StatusBadRequest, "invalid request body")
 			return
 		}
 
-		token, err := auth.GenerateToken(req.Name, req.Password)
+		user, err := users.Authenticate(r.Context(), req.Name, req.Password)
 		if err != nil {
-			log.Error("failed to generate token", "error", err)
-			log.Info("admin user: %s, admin password: %s", req.Name, req.Password)
-			w.WriteHeader(http.StatusUnauthorized)
+			if errors.Is(err, core.ErrInvalidCredentials) {
+				log.Debug("invalid credentials", "user", req.Name)
+				writeError(w, r, http.StatusUnauthorized, "invalid name or password")
+				return
+			}
+			writeFailure(w, r, log, err, "failed to authenticate")
 			return
 		}
 
-		w.Header().Set("Content-Type", "text/plain")
-		_, err = w.Write([]byte(token))
+		tokens, err := auth.GenerateTokens(user)
 		if err != nil {
-			log.Error("failed to write token", "error", err)
+			log.Error("failed to generate token", "error", err)
+			writeError(w, r, http.StatusInternalServerError, "failed to generate token")
+			return
+		}
+
+		// plain access token is kept for old clients
+		if !strings.Contains(r.Header.Get("Accept"), "application/json") {
+			w.Header().Set("Content-Type", "text/plain")
+			if _, err := w.Write([]byte(tokens.AccessToken)); err != nil {
+				log.Error("failed to write token", "error", err)
+			}
+			return
 		}
+		writeTokens(w, log, tokens)
 	}
 }
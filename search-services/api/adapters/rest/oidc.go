@@ -0,0 +1,81 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"yadro.com/course/api/core"
+)
+
+type oidcCallbackRequest struct {
+	Code  string `json:"code"`
+	State string `json:"state"`
+}
+
+// NewOIDCAuthorizeHandler returns the identity provider URL to send the user to,
+// the state has to come back with the code
+func NewOIDCAuthorizeHandler(provider core.IdentityProvider, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		url, state, err := provider.AuthURL(r.Context())
+		if err != nil {
+			log.Error("failed to start oidc login", "error", err)
+			writeError(w, r, http.StatusBadGateway, "identity provider is unavailable")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		response := map[string]interface{}{
+			"url":   url,
+			"state": state,
+		}
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Error("failed to encode response", "error", err)
+		}
+	}
+}
+
+// NewOIDCCallbackHandler exchanges the code for an identity
+// and issues a token pair for the provisioned user
+func NewOIDCCallbackHandler(provider core.IdentityProvider, auth core.Authenticator, users core.UserManager, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req oidcCallbackRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code == "" || req.State == "" {
+			writeError(w, r, http.StatusBadRequest, "code and state are required")
+			return
+		}
+
+		identity, err := provider.Exchange(r.Context(), req.Code, req.State)
+		if err != nil {
+			switch {
+			case errors.Is(err, core.ErrInvalidCredentials):
+				log.Debug("oidc login rejected", "error", err)
+				writeError(w, r, http.StatusUnauthorized, "oidc login rejected")
+			case errors.Is(err, core.ErrForbidden):
+				writeError(w, r, http.StatusForbidden, "no role is mapped for the user")
+			default:
+				log.Error("failed to exchange oidc code", "error", err)
+				writeError(w, r, http.StatusBadGateway, "identity provider is unavailable")
+			}
+			return
+		}
+
+		user, err := users.Provision(r.Context(), identity)
+		if err != nil {
+			log.Error("failed to provision oidc user", "subject", identity.Subject, "error", err)
+			writeError(w, r, http.StatusInternalServerError, "failed to provision user")
+			return
+		}
+
+		tokens, err := auth.GenerateTokens(user)
+		if err != nil {
+			log.Error("failed to generate token", "error", err)
+			writeError(w, r, http.StatusInternalServerError, "failed to generate token")
+			return
+		}
+		log.Debug("oidc login", "user", user.Name, "role", user.Role)
+		writeTokens(w, log, tokens)
+	}
+}
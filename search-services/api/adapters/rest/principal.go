@@ -0,0 +1,149 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+
+	"yadro.com/course/api/core"
+)
+
+type principalKey struct{}
+
+// principal is the caller of a request, its credentials are checked once on first use
+// and shared by the middlewares, so an API key is looked up once per request
+type principal struct {
+	addr    string
+	resolve func() (core.Claims, error)
+	once    sync.Once
+	claims  core.Claims
+	err     error
+}
+
+func (p *principal) Claims() (core.Claims, error) {
+	p.once.Do(func() {
+		p.claims, p.err = p.resolve()
+	})
+	return p.claims, p.err
+}
+
+// WithPrincipal puts the caller into the request context. The client address is taken
+// from X-Forwarded-For or X-Real-IP only when the request came from a trusted proxy.
+func WithPrincipal(auth core.Authenticator, keys core.APIKeyManager, trustedProxies []netip.Prefix) core.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p := &principal{
+				addr: clientAddr(r, trustedProxies),
+				resolve: func() (core.Claims, error) {
+					return authenticate(r, auth, keys)
+				},
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, p)))
+		})
+	}
+}
+
+// principalFrom treats callers as anonymous when WithPrincipal is not installed
+func principalFrom(r *http.Request) *principal {
+	if p, ok := r.Context().Value(principalKey{}).(*principal); ok {
+		return p
+	}
+	return &principal{
+		addr: remoteHost(r),
+		resolve: func() (core.Claims, error) {
+			return core.Claims{}, core.ErrInvalidToken
+		},
+	}
+}
+
+// ParseTrustedProxies accepts addresses and CIDR ranges
+func ParseTrustedProxies(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if strings.Contains(value, "/") {
+			prefix, err := netip.ParsePrefix(value)
+			if err != nil {
+				return nil, fmt.Errorf("bad trusted proxy %q: %w", value, err)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(value)
+		if err != nil {
+			return nil, fmt.Errorf("bad trusted proxy %q: %w", value, err)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// clientAddr walks X-Forwarded-For from the nearest hop, the first address
+// that is not a trusted proxy is the client, everything before it can be forged
+func clientAddr(r *http.Request, trustedProxies []netip.Prefix) string {
+	addr := remoteHost(r)
+	if !trusted(addr, trustedProxies) {
+		return addr
+	}
+
+	hops := forwardedFor(r.Header)
+	if len(hops) == 0 {
+		if real, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+			return real.Unmap().String()
+		}
+		return addr
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(hops[i])
+		if err != nil {
+			return addr
+		}
+		addr = hop.Unmap().String()
+		if !trusted(addr, trustedProxies) {
+			return addr
+		}
+	}
+	return addr
+}
+
+func forwardedFor(header http.Header) []string {
+	var hops []string
+	for _, value := range header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(value, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	return hops
+}
+
+func trusted(addr string, trustedProxies []netip.Prefix) bool {
+	ip, err := netip.ParseAddr(addr)
+	if err != nil {
+		return false
+	}
+	ip = ip.Unmap()
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
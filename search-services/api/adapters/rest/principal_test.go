@@ -0,0 +1,141 @@
+package rest
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"yadro.com/course/api/core"
+)
+
+func TestClientAddr(t *testing.T) {
+	trustedProxies, err := ParseTrustedProxies([]string{"172.28.0.10", "10.0.0.0/8"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		realIP     string
+		expected   string
+	}{
+		{
+			name:       "direct client",
+			remoteAddr: "203.0.113.7:5000",
+			expected:   "203.0.113.7",
+		},
+		{
+			name:       "forged header from untrusted client",
+			remoteAddr: "203.0.113.7:5000",
+			forwarded:  []string{"198.51.100.1"},
+			expected:   "203.0.113.7",
+		},
+		{
+			name:       "client behind frontend",
+			remoteAddr: "172.28.0.10:41000",
+			forwarded:  []string{"198.51.100.1"},
+			expected:   "198.51.100.1",
+		},
+		{
+			name:       "forged hop before trusted chain",
+			remoteAddr: "172.28.0.10:41000",
+			forwarded:  []string{"192.0.2.99, 198.51.100.1", "10.1.2.3"},
+			expected:   "198.51.100.1",
+		},
+		{
+			name:       "only trusted hops",
+			remoteAddr: "172.28.0.10:41000",
+			forwarded:  []string{"10.1.2.3"},
+			expected:   "10.1.2.3",
+		},
+		{
+			name:       "garbage hop",
+			remoteAddr: "172.28.0.10:41000",
+			forwarded:  []string{"not-an-ip"},
+			expected:   "172.28.0.10",
+		},
+		{
+			name:       "real ip header",
+			remoteAddr: "172.28.0.10:41000",
+			realIP:     "198.51.100.2",
+			expected:   "198.51.100.2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/search", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwarded {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+			assert.Equal(t, tt.expected, clientAddr(req, trustedProxies))
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.1/8", " ::ffff:172.28.0.10 ", ""})
+	require.NoError(t, err)
+	require.Len(t, proxies, 2)
+	assert.Equal(t, "10.0.0.0/8", proxies[0].String())
+	assert.Equal(t, "172.28.0.10/32", proxies[1].String())
+
+	_, err = ParseTrustedProxies([]string{"frontend"})
+	assert.Error(t, err)
+}
+
+func TestWithPrincipal_AuthenticatesOnce(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	keys := &countingKeys{}
+	rateLimiter := &recordingRateLimiter{}
+
+	handler := WithPrincipal(stubAuth{}, keys, nil)(
+		WithRateLimit(rateLimiter, log)(
+			WithConcurrencyLimit(openLimiter{}, log)(
+				WithAuth(core.RoleOperator, log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/search", nil)
+	req.Header.Set("X-API-Key", "secret")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	// ключ проверяется один раз на весь запрос
+	assert.Equal(t, 1, keys.calls)
+	assert.Equal(t, "key:7", rateLimiter.key)
+}
+
+type countingKeys struct {
+	core.APIKeyManager
+	calls int
+}
+
+func (k *countingKeys) Authenticate(ctx context.Context, key string) (core.APIKey, error) {
+	k.calls++
+	return core.APIKey{ID: 7, Name: "script", Role: core.RoleOperator}, nil
+}
+
+type recordingRateLimiter struct {
+	key string
+}
+
+func (l *recordingRateLimiter) Allow(ctx context.Context, key string) (core.RateLimit, error) {
+	l.key = key
+	return core.RateLimit{Allowed: true, Limit: 1, Remaining: 1}, nil
+}
+
+type openLimiter struct{}
+
+func (openLimiter) Acquire(ctx context.Context, priority bool) (func(), bool, error) {
+	return func() {}, true, nil
+}
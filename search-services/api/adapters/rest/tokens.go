@@ -0,0 +1,146 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"yadro.com/course/api/core"
+)
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type tokensResponse struct {
+	AccessToken      string `json:"access_token"`
+	RefreshToken     string `json:"refresh_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int    `json:"expires_in"`
+	RefreshExpiresIn int    `json:"refresh_expires_in"`
+}
+
+// NewRefreshHandler rotates the pair: the role is read again in case it was changed,
+// and the new pair is given out only by the request that revoked the old session
+func NewRefreshHandler(auth core.Authenticator, users core.UserManager, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req refreshRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+			writeError(w, r, http.StatusBadRequest, "refresh_token is required")
+			return
+		}
+
+		claims, err := auth.ValidateRefreshToken(r.Context(), req.RefreshToken)
+		if err != nil {
+			if errors.Is(err, core.ErrInvalidToken) {
+				log.Debug("invalid refresh token", "error", err)
+				writeError(w, r, http.StatusUnauthorized, "invalid refresh token")
+				return
+			}
+			writeFailure(w, r, log, err, "failed to validate token")
+			return
+		}
+		user, err := users.Get(r.Context(), claims.Subject)
+		if err != nil {
+			if errors.Is(err, core.ErrNotFound) {
+				log.Debug("refresh for deleted user", "user", claims.Subject)
+				writeError(w, r, http.StatusUnauthorized, "invalid refresh token")
+				return
+			}
+			writeFailure(w, r, log, err, "failed to get user")
+			return
+		}
+
+		tokens, err := auth.GenerateTokens(user)
+		if err != nil {
+			log.Error("failed to generate token", "error", err)
+			writeError(w, r, http.StatusInternalServerError, "failed to generate token")
+			return
+		}
+
+		if err := auth.Revoke(r.Context(), claims.Session); err != nil {
+			if errors.Is(err, core.ErrInvalidToken) {
+				log.Debug("refresh token reused", "user", claims.Subject)
+				writeError(w, r, http.StatusUnauthorized, "invalid refresh token")
+				return
+			}
+			writeFailure(w, r, log, err, "failed to revoke session")
+			return
+		}
+		writeTokens(w, log, tokens)
+	}
+}
+
+// NewLogoutHandler revokes the session of a refresh token from the body
+// or of an access token from the Authorization header
+func NewLogoutHandler(auth core.Authenticator, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req refreshRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		var (
+			claims core.Claims
+			err    error
+		)
+		if req.RefreshToken != "" {
+			claims, err = auth.ValidateRefreshToken(r.Context(), req.RefreshToken)
+		} else {
+			parts := strings.Split(r.Header.Get("Authorization"), " ")
+			if len(parts) != 2 || parts[0] != "Token" {
+				writeError(w, r, http.StatusUnauthorized, "invalid or missing credentials")
+				return
+			}
+			claims, err = auth.ValidateToken(r.Context(), parts[1])
+		}
+		if err != nil {
+			if errors.Is(err, core.ErrInvalidToken) {
+				log.Debug("invalid token on logout", "error", err)
+				writeError(w, r, http.StatusUnauthorized, "invalid token")
+				return
+			}
+			writeFailure(w, r, log, err, "failed to validate token")
+			return
+		}
+
+		if err := auth.Revoke(r.Context(), claims.Session); err != nil {
+			if errors.Is(err, core.ErrInvalidToken) {
+				writeError(w, r, http.StatusUnauthorized, "invalid token")
+				return
+			}
+			writeFailure(w, r, log, err, "failed to revoke session")
+			return
+		}
+		log.Debug("logged out", "user", claims.Subject)
+	}
+}
+
+func writeTokens(w http.ResponseWriter, log *slog.Logger, tokens core.TokenPair) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	err := json.NewEncoder(w).Encode(tokensResponse{
+		AccessToken:      tokens.AccessToken,
+		RefreshToken:     tokens.RefreshToken,
+		TokenType:        "Token",
+		ExpiresIn:        int(tokens.AccessExpiresIn.Seconds()),
+		RefreshExpiresIn: int(tokens.RefreshExpiresIn.Seconds()),
+	})
+	if err != nil {
+		log.Error("failed to encode response", "error", err)
+	}
+}
+
+// NewJWKSHandler publishes public keys for services that validate tokens themselves
+func NewJWKSHandler(auth core.Authenticator, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		response := map[string]interface{}{
+			"keys": auth.PublicKeys(),
+		}
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Error("failed to encode response", "error", err)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"yadro.com/course/api/core"
+)
+
+// sessionAuth отзывает сессию один раз, как база с ON CONFLICT DO NOTHING
+type sessionAuth struct {
+	core.Authenticator
+	mu      sync.Mutex
+	revoked map[string]bool
+}
+
+func (a *sessionAuth) ValidateRefreshToken(ctx context.Context, token string) (core.Claims, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.revoked[token] {
+		return core.Claims{}, core.ErrInvalidToken
+	}
+	return core.Claims{Subject: "alice", Role: core.RoleViewer, Session: token}, nil
+}
+
+func (a *sessionAuth) Revoke(ctx context.Context, session string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.revoked[session] {
+		return core.ErrInvalidToken
+	}
+	a.revoked[session] = true
+	return nil
+}
+
+func (a *sessionAuth) GenerateTokens(user core.User) (core.TokenPair, error) {
+	return core.TokenPair{AccessToken: "access", RefreshToken: "refresh"}, nil
+}
+
+type stubUsers struct {
+	core.UserManager
+	err error
+}
+
+func (u stubUsers) Get(ctx context.Context, name string) (core.User, error) {
+	return core.User{Name: name, Role: core.RoleViewer}, u.err
+}
+
+func refresh(handler http.Handler, token string) int {
+	req := httptest.NewRequest(http.MethodPost, "/api/refresh", strings.NewReader(`{"refresh_token": "`+token+`"}`))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestRefreshHandler_Reuse(t *testing.T) {
+	auth := &sessionAuth{revoked: map[string]bool{}}
+	handler := NewRefreshHandler(auth, stubUsers{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	// из одновременных запросов с одним токеном новую пару получает только один
+	var ok atomic.Int32
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if refresh(handler, "session") == http.StatusOK {
+				ok.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), ok.Load())
+	assert.Equal(t, http.StatusUnauthorized, refresh(handler, "session"))
+}
+
+func TestRefreshHandler_KeepsSessionOnFailure(t *testing.T) {
+	auth := &sessionAuth{revoked: map[string]bool{}}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	// сбой базы не должен разлогинивать пользователя
+	failing := NewRefreshHandler(auth, stubUsers{err: errors.New("db is down")}, log)
+	assert.Equal(t, http.StatusInternalServerError, refresh(failing, "session"))
+
+	assert.Equal(t, http.StatusOK, refresh(NewRefreshHandler(auth, stubUsers{}, log), "session"))
+}
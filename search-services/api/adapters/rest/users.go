@@ -0,0 +1,133 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"yadro.com/course/api/core"
+)
+
+type userRequest struct {
+	Name     string    `json:"name"`
+	Password string    `json:"password"`
+	Role     core.Role `json:"role"`
+}
+
+type userResponse struct {
+	Name        string    `json:"name"`
+	DisplayName string    `json:"display_name,omitempty"`
+	Role        core.Role `json:"role"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func toUserResponse(user core.User) userResponse {
+	return userResponse{Name: user.Name, DisplayName: user.DisplayName, Role: user.Role, CreatedAt: user.CreatedAt}
+}
+
+func NewRegisterHandler(log *slog.Logger, users core.UserManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req userRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, r, http.StatusBadRequest, core.ErrBadArguments.Error())
+			return
+		}
+
+		user, err := users.Register(r.Context(), req.Name, req.Password)
+		if err != nil {
+			writeUserError(w, r, log, err)
+			return
+		}
+		writeUser(w, log, http.StatusCreated, user)
+	}
+}
+
+func NewListUsersHandler(log *slog.Logger, users core.UserManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		list, err := users.List(r.Context())
+		if err != nil {
+			writeUserError(w, r, log, err)
+			return
+		}
+
+		resp := make([]userResponse, 0, len(list))
+		for _, user := range list {
+			resp = append(resp, toUserResponse(user))
+		}
+		response := map[string]interface{}{
+			"users": resp,
+			"total": len(resp),
+		}
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Error("failed to encode response", "error", err)
+		}
+	}
+}
+
+func NewCreateUserHandler(log *slog.Logger, users core.UserManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req userRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, r, http.StatusBadRequest, core.ErrBadArguments.Error())
+			return
+		}
+		if req.Role == "" {
+			req.Role = core.RoleViewer
+		}
+
+		user, err := users.Create(r.Context(), req.Name, req.Password, req.Role)
+		if err != nil {
+			writeUserError(w, r, log, err)
+			return
+		}
+		writeUser(w, log, http.StatusCreated, user)
+	}
+}
+
+func NewUserRoleHandler(log *slog.Logger, users core.UserManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req userRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, r, http.StatusBadRequest, core.ErrBadArguments.Error())
+			return
+		}
+
+		if err := users.SetRole(r.Context(), r.PathValue("name"), req.Role); err != nil {
+			writeUserError(w, r, log, err)
+			return
+		}
+	}
+}
+
+func NewDeleteUserHandler(log *slog.Logger, users core.UserManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := users.Delete(r.Context(), r.PathValue("name")); err != nil {
+			writeUserError(w, r, log, err)
+			return
+		}
+	}
+}
+
+func writeUser(w http.ResponseWriter, log *slog.Logger, status int, user core.User) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(toUserResponse(user)); err != nil {
+		log.Error("failed to encode response", "error", err)
+	}
+}
+
+func writeUserError(w http.ResponseWriter, r *http.Request, log *slog.Logger, err error) {
+	switch {
+	case errors.Is(err, core.ErrBadArguments):
+		writeError(w, r, http.StatusBadRequest, core.ErrBadArguments.Error())
+	case errors.Is(err, core.ErrAlreadyExists):
+		writeError(w, r, http.StatusConflict, "user already exists")
+	case errors.Is(err, core.ErrNotFound):
+		writeError(w, r, http.StatusNotFound, "user not found")
+	default:
+		log.Error("failed to manage users", "error", err)
+		writeError(w, r, http.StatusInternalServerError, "error managing users")
+	}
+}
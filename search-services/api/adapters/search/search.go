@@ -38,10 +38,11 @@ func (c Client) Ping(ctx context.Context) error {
 	return nil
 }
 
-func (c Client) Search(ctx context.Context, phrase string, limit int) ([]core.Comics, int, error) {
+func (c Client) Search(ctx context.Context, phrase string, limit int, source string) ([]core.Comics, int, error) {
 	response, err := c.client.Search(ctx, &searchpb.SearchRequest{
-		Query: phrase,
-		Limit: int64(limit),
+		Query:  phrase,
+		Limit:  int64(limit),
+		Source: source,
 	})
 	if err != nil {
 		c.log.Error("cannot search comics", "error", err)
@@ -51,18 +52,20 @@ func (c Client) Search(ctx context.Context, phrase string, limit int) ([]core.Co
 	comics := make([]core.Comics, 0, len(response.Items))
 	for _, item := range response.Items {
 		comics = append(comics, core.Comics{
-			ID:  item.Id,
-			URL: item.Url,
+			ID:     item.Id,
+			Source: item.Source,
+			URL:    item.Url,
 		})
 	}
 
 	return comics, int(response.Total), nil
 }
 
-func (c Client) ISearch(ctx context.Context, phrase string, limit int) ([]core.Comics, int, error) {
+func (c Client) ISearch(ctx context.Context, phrase string, limit int, source string) ([]core.Comics, int, error) {
 	response, err := c.client.ISearch(ctx, &searchpb.ISearchRequest{
-		Query: phrase,
-		Limit: int64(limit),
+		Query:  phrase,
+		Limit:  int64(limit),
+		Source: source,
 	})
 	if err != nil {
 		c.log.Error("cannot Isearch comics", "error", err)
@@ -72,8 +75,9 @@ func (c Client) ISearch(ctx context.Context, phrase string, limit int) ([]core.C
 	comics := make([]core.Comics, 0, len(response.Items))
 	for _, item := range response.Items {
 		comics = append(comics, core.Comics{
-			ID:  item.Id,
-			URL: item.Url,
+			ID:     item.Id,
+			Source: item.Source,
+			URL:    item.Url,
 		})
 	}
 	return comics, int(response.Total), nil
@@ -129,11 +129,13 @@ func TestClient_Search(t *testing.T) {
 		{
 			name: "successful search",
 			searchFunc: func(ctx context.Context, req *searchpb.SearchRequest, opts ...grpc.CallOption) (*searchpb.ComicsResponse, error) {
+				assert.Equal(t, "xkcd", req.Source)
 				return &searchpb.ComicsResponse{
 					Items: []*searchpb.Comics{
 						{
-							Id:  1,
-							Url: "http://example.com/1",
+							Id:     1,
+							Url:    "http://example.com/1",
+							Source: "xkcd",
 						},
 						{
 							Id:  2,
@@ -147,8 +149,9 @@ func TestClient_Search(t *testing.T) {
 			limit:  10,
 			expected: []core.Comics{
 				{
-					ID:  1,
-					URL: "http://example.com/1",
+					ID:     1,
+					Source: "xkcd",
+					URL:    "http://example.com/1",
 				},
 				{
 					ID:  2,
@@ -194,7 +197,7 @@ func TestClient_Search(t *testing.T) {
 				},
 			}
 
-			comics, total, err := client.Search(context.Background(), tt.phrase, tt.limit)
+			comics, total, err := client.Search(context.Background(), tt.phrase, tt.limit, "xkcd")
 
 			if tt.expectedErr != nil {
 				assert.Error(t, err)
@@ -286,7 +289,7 @@ func TestClient_ISearch(t *testing.T) {
 				},
 			}
 
-			comics, total, err := client.ISearch(context.Background(), tt.phrase, tt.limit)
+			comics, total, err := client.ISearch(context.Background(), tt.phrase, tt.limit, "")
 
 			if tt.expectedErr != nil {
 				assert.Error(t, err)
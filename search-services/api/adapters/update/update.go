@@ -6,19 +6,28 @@ import (
 	"log/slog"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 	"yadro.com/course/api/core"
 	updatepb "yadro.com/course/proto/update"
 )
 
+const maxMessageSize = 32 << 20
+
 type Client struct {
 	log    *slog.Logger
 	client updatepb.UpdateClient
 }
 
 func NewClient(address string, log *slog.Logger) (*Client, error) {
-	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := grpc.NewClient(
+		address,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(maxMessageSize)),
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -68,6 +77,7 @@ func (c Client) Stats(ctx context.Context) (core.UpdateStats, error) {
 		WordsUnique:   int(stats.WordsUnique),
 		ComicsTotal:   int(stats.ComicsTotal),
 		ComicsFetched: int(stats.ComicsFetched),
+		ComicsStale:   int(stats.ComicsStale),
 	}, nil
 
 }
@@ -91,3 +101,51 @@ func (c Client) Drop(ctx context.Context) error {
 
 	return nil
 }
+
+func (c Client) Reindex(ctx context.Context, filter core.ReindexFilter) error {
+	req := &updatepb.ReindexRequest{
+		Source: filter.Source,
+		FromId: int64(filter.FromID),
+		ToId:   int64(filter.ToID),
+	}
+	if !filter.OlderThan.IsZero() {
+		req.OlderThan = timestamppb.New(filter.OlderThan)
+	}
+
+	_, err := c.client.Reindex(ctx, req)
+	if err != nil {
+		c.log.Error("cannot reindex update service", "error", err)
+		return err
+	}
+
+	return nil
+}
+
+func (c Client) Renormalize(ctx context.Context, onlyStale bool) error {
+	_, err := c.client.Renormalize(ctx, &updatepb.RenormalizeRequest{OnlyStale: onlyStale})
+	if err != nil {
+		c.log.Error("cannot renormalize update service", "error", err)
+		return err
+	}
+
+	return nil
+}
+
+func (c Client) Image(ctx context.Context, source string, id int, thumbnail bool) (core.Image, error) {
+	reply, err := c.client.Image(ctx, &updatepb.ImageRequest{Source: source, Id: int64(id), Thumbnail: thumbnail})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return core.Image{}, core.ErrNotFound
+		}
+		c.log.Error("cannot get comic image", "id", id, "error", err)
+		return core.Image{}, err
+	}
+
+	return core.Image{
+		Data:        reply.Data,
+		ContentType: reply.ContentType,
+		Hash:        reply.Hash,
+		Width:       int(reply.Width),
+		Height:      int(reply.Height),
+	}, nil
+}
@@ -6,10 +6,13 @@ import (
 	"io"
 	"log/slog"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
 
 	"yadro.com/course/api/core"
@@ -23,6 +26,9 @@ type mockUpdateClient struct {
 	statsFunc  func(ctx context.Context, req *emptypb.Empty, opts ...grpc.CallOption) (*updatepb.StatsReply, error)
 	updateFunc func(ctx context.Context, req *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	dropFunc   func(ctx context.Context, req *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	reindexFn  func(ctx context.Context, req *updatepb.ReindexRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	renormFn   func(ctx context.Context, req *updatepb.RenormalizeRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	imageFn    func(ctx context.Context, req *updatepb.ImageRequest, opts ...grpc.CallOption) (*updatepb.ImageReply, error)
 }
 
 func (m *mockUpdateClient) Ping(ctx context.Context, req *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error) {
@@ -45,6 +51,18 @@ func (m *mockUpdateClient) Drop(ctx context.Context, req *emptypb.Empty, opts ..
 	return m.dropFunc(ctx, req, opts...)
 }
 
+func (m *mockUpdateClient) Reindex(ctx context.Context, req *updatepb.ReindexRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	return m.reindexFn(ctx, req, opts...)
+}
+
+func (m *mockUpdateClient) Renormalize(ctx context.Context, req *updatepb.RenormalizeRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	return m.renormFn(ctx, req, opts...)
+}
+
+func (m *mockUpdateClient) Image(ctx context.Context, req *updatepb.ImageRequest, opts ...grpc.CallOption) (*updatepb.ImageReply, error) {
+	return m.imageFn(ctx, req, opts...)
+}
+
 func newTestLogger() *slog.Logger {
 	return slog.New(slog.NewTextHandler(io.Discard, nil))
 }
@@ -210,6 +228,7 @@ func TestClient_Stats(t *testing.T) {
 					WordsUnique:   50,
 					ComicsTotal:   10,
 					ComicsFetched: 5,
+					ComicsStale:   2,
 				}, nil
 			},
 			expected: core.UpdateStats{
@@ -217,6 +236,7 @@ func TestClient_Stats(t *testing.T) {
 				WordsUnique:   50,
 				ComicsTotal:   10,
 				ComicsFetched: 5,
+				ComicsStale:   2,
 			},
 			expectedErr: nil,
 		},
@@ -337,3 +357,181 @@ func TestClient_Drop(t *testing.T) {
 		})
 	}
 }
+
+func TestClient_Reindex(t *testing.T) {
+	olderThan := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		filter      core.ReindexFilter
+		reindexErr  error
+		check       func(t *testing.T, req *updatepb.ReindexRequest)
+		expectedErr error
+	}{
+		{
+			name:   "reindex all",
+			filter: core.ReindexFilter{},
+			check: func(t *testing.T, req *updatepb.ReindexRequest) {
+				assert.Zero(t, req.FromId)
+				assert.Zero(t, req.ToId)
+				assert.Nil(t, req.OlderThan)
+			},
+		},
+		{
+			name:   "range and date",
+			filter: core.ReindexFilter{Source: "xkcd", FromID: 1, ToID: 100, OlderThan: olderThan},
+			check: func(t *testing.T, req *updatepb.ReindexRequest) {
+				assert.Equal(t, "xkcd", req.Source)
+				assert.Equal(t, int64(1), req.FromId)
+				assert.Equal(t, int64(100), req.ToId)
+				assert.True(t, olderThan.Equal(req.OlderThan.AsTime()))
+			},
+		},
+		{
+			name:        "grpc error",
+			filter:      core.ReindexFilter{},
+			reindexErr:  errors.New("grpc error"),
+			expectedErr: errors.New("grpc error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &Client{
+				log: newTestLogger(),
+				client: &mockUpdateClient{
+					reindexFn: func(ctx context.Context, req *updatepb.ReindexRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+						if tt.check != nil {
+							tt.check(t, req)
+						}
+						if tt.reindexErr != nil {
+							return nil, tt.reindexErr
+						}
+						return &emptypb.Empty{}, nil
+					},
+				},
+			}
+
+			err := client.Reindex(context.Background(), tt.filter)
+
+			if tt.expectedErr != nil {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErr.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestClient_Renormalize(t *testing.T) {
+	tests := []struct {
+		name        string
+		onlyStale   bool
+		renormErr   error
+		expectedErr error
+	}{
+		{
+			name:      "successful renormalize",
+			onlyStale: true,
+		},
+		{
+			name:        "grpc error",
+			renormErr:   errors.New("grpc error"),
+			expectedErr: errors.New("grpc error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &Client{
+				log: newTestLogger(),
+				client: &mockUpdateClient{
+					renormFn: func(ctx context.Context, req *updatepb.RenormalizeRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+						assert.Equal(t, tt.onlyStale, req.OnlyStale)
+						if tt.renormErr != nil {
+							return nil, tt.renormErr
+						}
+						return &emptypb.Empty{}, nil
+					},
+				},
+			}
+
+			err := client.Renormalize(context.Background(), tt.onlyStale)
+
+			if tt.expectedErr != nil {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErr.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestClient_Image(t *testing.T) {
+	tests := []struct {
+		name        string
+		thumbnail   bool
+		imageErr    error
+		expected    core.Image
+		expectedErr error
+	}{
+		{
+			name:      "successful thumbnail",
+			thumbnail: true,
+			expected: core.Image{
+				Data:        []byte("png"),
+				ContentType: "image/png",
+				Hash:        "abcd",
+				Width:       20,
+				Height:      10,
+			},
+		},
+		{
+			name:        "not found",
+			imageErr:    status.Error(codes.NotFound, "image not found"),
+			expectedErr: core.ErrNotFound,
+		},
+		{
+			name:        "grpc error",
+			imageErr:    errors.New("grpc error"),
+			expectedErr: errors.New("grpc error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &Client{
+				log: newTestLogger(),
+				client: &mockUpdateClient{
+					imageFn: func(ctx context.Context, req *updatepb.ImageRequest, opts ...grpc.CallOption) (*updatepb.ImageReply, error) {
+						assert.Equal(t, "feed", req.Source)
+						assert.Equal(t, int64(42), req.Id)
+						assert.Equal(t, tt.thumbnail, req.Thumbnail)
+						if tt.imageErr != nil {
+							return nil, tt.imageErr
+						}
+						return &updatepb.ImageReply{
+							Data:        tt.expected.Data,
+							ContentType: tt.expected.ContentType,
+							Hash:        tt.expected.Hash,
+							Width:       int64(tt.expected.Width),
+							Height:      int64(tt.expected.Height),
+						}, nil
+					},
+				},
+			}
+
+			image, err := client.Image(context.Background(), "feed", 42, tt.thumbnail)
+
+			if tt.expectedErr != nil {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, image)
+		})
+	}
+}
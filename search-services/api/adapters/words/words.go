@@ -28,8 +28,8 @@ func NewClient(address string, log *slog.Logger) (*Client, error) {
 	}, nil
 }
 
-func (c Client) Norm(ctx context.Context, phrase string) ([]string, error) {
-	response, err := c.client.Norm(ctx, &wordspb.WordsRequest{Phrase: phrase})
+func (c Client) Norm(ctx context.Context, phrase, language string) ([]string, error) {
+	response, err := c.client.Norm(ctx, &wordspb.WordsRequest{Phrase: phrase, Language: language})
 	if err != nil {
 		c.log.Error("cannot normalize phrase", "error", err, "phrase", phrase)
 		return nil, err
@@ -116,7 +116,7 @@ func TestClient_Norm(t *testing.T) {
 				},
 			}
 
-			words, err := client.Norm(context.Background(), tt.phrase)
+			words, err := client.Norm(context.Background(), tt.phrase, "")
 
 			if tt.expectedErr != nil {
 				assert.Error(t, err)
@@ -0,0 +1,96 @@
+package core
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+const (
+	apiKeyScheme = "csk_"
+	// apiKeyPrefixLength characters of a key are stored to tell keys apart in listings
+	apiKeyPrefixLength = len(apiKeyScheme) + 8
+	apiKeyBytes        = 32
+)
+
+type APIKeys struct {
+	log   *slog.Logger
+	store APIKeyStore
+	now   func() time.Time
+}
+
+func NewAPIKeys(log *slog.Logger, store APIKeyStore) *APIKeys {
+	return &APIKeys{
+		log:   log,
+		store: store,
+		now:   time.Now,
+	}
+}
+
+// Create returns the stored key and the key itself, which can't be recovered later
+func (k *APIKeys) Create(ctx context.Context, name string, role Role) (APIKey, string, error) {
+	if !validName(name) || !role.Valid() {
+		return APIKey{}, "", ErrBadArguments
+	}
+
+	secret := make([]byte, apiKeyBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return APIKey{}, "", err
+	}
+	key := apiKeyScheme + base64.RawURLEncoding.EncodeToString(secret)
+
+	created, err := k.store.CreateKey(ctx, APIKey{
+		Name:   name,
+		Prefix: key[:apiKeyPrefixLength],
+		Role:   role,
+	}, hashAPIKey(key))
+	if err != nil {
+		return APIKey{}, "", err
+	}
+	return created, key, nil
+}
+
+func (k *APIKeys) List(ctx context.Context) ([]APIKey, error) {
+	return k.store.Keys(ctx)
+}
+
+func (k *APIKeys) Revoke(ctx context.Context, id int64) error {
+	return k.store.RevokeKey(ctx, id)
+}
+
+// Authenticate finds an active key and records its use
+func (k *APIKeys) Authenticate(ctx context.Context, key string) (APIKey, error) {
+	if !strings.HasPrefix(key, apiKeyScheme) {
+		return APIKey{}, ErrInvalidToken
+	}
+
+	apiKey, err := k.store.KeyByHash(ctx, hashAPIKey(key))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return APIKey{}, ErrInvalidToken
+		}
+		return APIKey{}, err
+	}
+	if apiKey.Revoked() {
+		return APIKey{}, ErrInvalidToken
+	}
+
+	// the key is valid even if its usage can't be recorded
+	apiKey.LastUsedAt = k.now()
+	if err := k.store.TouchKey(ctx, apiKey.ID, apiKey.LastUsedAt); err != nil {
+		k.log.Error("failed to record api key use", "id", apiKey.ID, "error", err)
+	}
+	return apiKey, nil
+}
+
+// keys are random, so a fast hash is enough unlike passwords
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
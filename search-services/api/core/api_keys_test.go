@@ -0,0 +1,131 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockKeyStore struct {
+	keys     map[string]APIKey
+	touched  map[int64]time.Time
+	touchErr error
+}
+
+func newMockKeyStore() *mockKeyStore {
+	return &mockKeyStore{keys: map[string]APIKey{}, touched: map[int64]time.Time{}}
+}
+
+func (m *mockKeyStore) CreateKey(ctx context.Context, key APIKey, hash string) (APIKey, error) {
+	key.ID = int64(len(m.keys) + 1)
+	m.keys[hash] = key
+	return key, nil
+}
+
+func (m *mockKeyStore) KeyByHash(ctx context.Context, hash string) (APIKey, error) {
+	key, ok := m.keys[hash]
+	if !ok {
+		return APIKey{}, ErrNotFound
+	}
+	return key, nil
+}
+
+func (m *mockKeyStore) Keys(ctx context.Context) ([]APIKey, error) {
+	var keys []APIKey
+	for _, key := range m.keys {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (m *mockKeyStore) RevokeKey(ctx context.Context, id int64) error {
+	for hash, key := range m.keys {
+		if key.ID == id {
+			key.RevokedAt = time.Now()
+			m.keys[hash] = key
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (m *mockKeyStore) TouchKey(ctx context.Context, id int64, usedAt time.Time) error {
+	m.touched[id] = usedAt
+	return m.touchErr
+}
+
+func TestAPIKeys_Create(t *testing.T) {
+	tests := []struct {
+		name    string
+		keyName string
+		role    Role
+		wantErr error
+	}{
+		{name: "ok", keyName: "cron", role: RoleOperator},
+		{name: "empty name", keyName: "", role: RoleViewer, wantErr: ErrBadArguments},
+		{name: "unknown role", keyName: "bot", role: "root", wantErr: ErrBadArguments},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMockKeyStore()
+			keys := NewAPIKeys(slog.Default(), store)
+
+			key, secret, err := keys.Create(context.Background(), tt.keyName, tt.role)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, store.keys)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.True(t, strings.HasPrefix(secret, apiKeyScheme))
+			assert.Equal(t, secret[:apiKeyPrefixLength], key.Prefix)
+			assert.Equal(t, tt.role, key.Role)
+			// ключ хранится только в виде хеша
+			assert.Contains(t, store.keys, hashAPIKey(secret))
+			assert.NotContains(t, store.keys, secret)
+		})
+	}
+}
+
+func TestAPIKeys_Authenticate(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	store := newMockKeyStore()
+	keys := NewAPIKeys(slog.Default(), store)
+	keys.now = func() time.Time { return now }
+
+	key, secret, err := keys.Create(context.Background(), "cron", RoleOperator)
+	assert.NoError(t, err)
+	_, other, err := keys.Create(context.Background(), "bot", RoleViewer)
+	assert.NoError(t, err)
+	assert.NotEqual(t, secret, other)
+
+	got, err := keys.Authenticate(context.Background(), secret)
+	assert.NoError(t, err)
+	assert.Equal(t, key.ID, got.ID)
+	assert.Equal(t, RoleOperator, got.Role)
+	assert.Equal(t, now, got.LastUsedAt)
+	assert.Equal(t, now, store.touched[key.ID])
+
+	for _, bad := range []string{"", "token", apiKeyScheme + "unknown", secret + "x"} {
+		_, err := keys.Authenticate(context.Background(), bad)
+		assert.ErrorIs(t, err, ErrInvalidToken, bad)
+	}
+
+	// ошибка записи времени использования не мешает аутентификации
+	store.touchErr = errors.New("db is down")
+	_, err = keys.Authenticate(context.Background(), secret)
+	assert.NoError(t, err)
+
+	assert.NoError(t, keys.Revoke(context.Background(), key.ID))
+	_, err = keys.Authenticate(context.Background(), secret)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	assert.ErrorIs(t, keys.Revoke(context.Background(), 100), ErrNotFound)
+}
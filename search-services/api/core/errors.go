@@ -8,4 +8,6 @@ var (
 	ErrMessageTooLarge    = errors.New("message is too large")
 	ErrInvalidCredentials = errors.New("invalid credentials")
 	ErrInvalidToken       = errors.New("invalid token")
+	ErrNotFound           = errors.New("resource not found")
+	ErrForbidden          = errors.New("not enough privileges")
 )
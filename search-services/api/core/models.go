@@ -2,6 +2,7 @@ package core
 
 import (
 	"net/http"
+	"time"
 )
 
 type UpdateStatus string
@@ -17,20 +18,157 @@ type UpdateStats struct {
 	WordsUnique   int
 	ComicsFetched int
 	ComicsTotal   int
+	ComicsStale   int
+}
+
+type ReindexFilter struct {
+	Source    string
+	FromID    int
+	ToID      int
+	OlderThan time.Time
+}
+
+type Image struct {
+	Data        []byte
+	ContentType string
+	Hash        string
+	Width       int
+	Height      int
 }
 
 type Comics struct {
-	ID  int64
-	URL string
+	ID     int64
+	Source string
+	URL    string
 }
 
 type Middleware func(http.Handler) http.Handler
 
+// RateLimit is the state of a client bucket after a request,
+// RetryAfter is set when the request is refused
+type RateLimit struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+type CacheStats struct {
+	Hits      int
+	Misses    int
+	Evictions int
+	Size      int
+}
+
+// QueueStats of requests waiting for a concurrency slot,
+// waits are counted only for requests that were queued
+type QueueStats struct {
+	InFlight  int
+	Depth     int
+	Size      int
+	Acquired  int64
+	Queued    int64
+	Rejected  int64
+	TimedOut  int64
+	WaitTotal time.Duration
+	WaitMax   time.Duration
+	WaitCount int64
+}
+
 type RateLimitConfig struct {
 	SearchRate        float64
 	SearchConcurrency int
 }
 
+// Claims of a token, Session is shared by an access and refresh token pair
 type Claims struct {
 	Subject string `json:"sub"`
+	Role    Role   `json:"role"`
+	Session string `json:"sid"`
+}
+
+// JWK is a public token signing key in the JSON Web Key format
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Curve     string `json:"crv,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+type TokenPair struct {
+	AccessToken      string
+	RefreshToken     string
+	AccessExpiresIn  time.Duration
+	RefreshExpiresIn time.Duration
+}
+
+type Role string
+
+const (
+	RoleViewer   Role = "viewer"
+	RoleOperator Role = "operator"
+	RoleAdmin    Role = "admin"
+)
+
+var roleRanks = map[Role]int{
+	RoleViewer:   1,
+	RoleOperator: 2,
+	RoleAdmin:    3,
+}
+
+func (r Role) Valid() bool {
+	_, ok := roleRanks[r]
+	return ok
+}
+
+// Allows reports whether the role has at least the privileges of the required one
+func (r Role) Allows(required Role) bool {
+	return r.Valid() && roleRanks[r] >= roleRanks[required]
+}
+
+// APIKey is a long-lived credential for scripts, its Role limits what the key can do.
+// The key itself is shown once on creation, only its hash is stored.
+type APIKey struct {
+	ID         int64
+	Name       string
+	Prefix     string
+	Role       Role
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	RevokedAt  time.Time
+}
+
+func (k APIKey) Revoked() bool {
+	return !k.RevokedAt.IsZero()
+}
+
+// Identity is a user signed in through an external identity provider,
+// OIDCLogin is an authorization request waiting for its callback
+type OIDCLogin struct {
+	Verifier string
+	Nonce    string
+	Expires  time.Time
+}
+
+// Issuer and Subject identify the account, Name is only shown to people.
+// Role is already mapped onto the gateway roles.
+type Identity struct {
+	Issuer  string
+	Subject string
+	Name    string
+	Role    Role
+}
+
+// User DisplayName is set for external users, their Name is derived from the identity
+type User struct {
+	ID          int64
+	Name        string
+	DisplayName string
+	Role        Role
+	CreatedAt   time.Time
 }
@@ -1,9 +1,12 @@
 package core
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type Normalizer interface {
-	Norm(context.Context, string) ([]string, error)
+	Norm(ctx context.Context, phrase, language string) ([]string, error)
 }
 
 type Pinger interface {
@@ -11,8 +14,8 @@ type Pinger interface {
 }
 
 type Searcher interface {
-	Search(context.Context, string, int) ([]Comics, int, error)
-	ISearch(context.Context, string, int) ([]Comics, int, error)
+	Search(ctx context.Context, phrase string, limit int, source string) ([]Comics, int, error)
+	ISearch(ctx context.Context, phrase string, limit int, source string) ([]Comics, int, error)
 }
 
 type Updater interface {
@@ -20,19 +23,118 @@ type Updater interface {
 	Stats(context.Context) (UpdateStats, error)
 	Status(context.Context) (UpdateStatus, error)
 	Drop(context.Context) error
+	Reindex(context.Context, ReindexFilter) error
+	Renormalize(ctx context.Context, onlyStale bool) error
+}
+
+type ImageProvider interface {
+	Image(ctx context.Context, source string, id int, thumbnail bool) (Image, error)
 }
 
 type Authenticator interface {
-	GenerateToken(username, password string) (string, error)
-	ValidateToken(token string) error
+	GenerateTokens(user User) (TokenPair, error)
+	// ValidateToken accepts only access tokens
+	ValidateToken(ctx context.Context, token string) (Claims, error)
+	ValidateRefreshToken(ctx context.Context, token string) (Claims, error)
+	// Revoke invalidates both tokens of the session,
+	// ErrInvalidToken means it was already revoked, e.g. by a concurrent refresh
+	Revoke(ctx context.Context, session string) error
+	// PublicKeys are published as JWKS, empty for symmetric signing
+	PublicKeys() []JWK
+}
+
+// Denylist keeps revoked sessions until their tokens expire
+type Denylist interface {
+	// Add reports false when the id was already there, checking and adding is one step
+	Add(ctx context.Context, id string, until time.Time) (bool, error)
+	Contains(ctx context.Context, id string) (bool, error)
+}
+
+// UserStore keeps users with their password hashes,
+// external users are also found by the issuer and subject of their identity.
+// SetRole and Delete refuse to take away the last admin with ErrBadArguments,
+// the check and the change are atomic.
+type UserStore interface {
+	Create(ctx context.Context, user User, hash string) (User, error)
+	Get(ctx context.Context, name string) (User, string, error)
+	List(ctx context.Context) ([]User, error)
+	SetRole(ctx context.Context, name string, role Role) error
+	Delete(ctx context.Context, name string) error
+	CreateExternal(ctx context.Context, user User, issuer, subject string) (User, error)
+	GetExternal(ctx context.Context, issuer, subject string) (User, error)
+	SetDisplayName(ctx context.Context, name, displayName string) error
+}
+
+// APIKeyStore keeps API keys by the hash of the key
+type APIKeyStore interface {
+	CreateKey(ctx context.Context, key APIKey, hash string) (APIKey, error)
+	KeyByHash(ctx context.Context, hash string) (APIKey, error)
+	Keys(ctx context.Context) ([]APIKey, error)
+	RevokeKey(ctx context.Context, id int64) error
+	TouchKey(ctx context.Context, id int64, usedAt time.Time) error
+}
+
+type APIKeyManager interface {
+	Create(ctx context.Context, name string, role Role) (APIKey, string, error)
+	List(ctx context.Context) ([]APIKey, error)
+	Revoke(ctx context.Context, id int64) error
+	Authenticate(ctx context.Context, key string) (APIKey, error)
+}
+
+// IdentityProvider runs the OpenID Connect authorization code flow,
+// state ties the callback to the authorization request
+type IdentityProvider interface {
+	AuthURL(ctx context.Context) (url, state string, err error)
+	Exchange(ctx context.Context, code, state string) (Identity, error)
 }
 
+// OIDCLogins keeps authorization requests until their callback. Deployments keep
+// them in the database, so the callback may come to any gateway replica.
+type OIDCLogins interface {
+	Put(ctx context.Context, state string, login OIDCLogin) error
+	// Take removes the login, so every state is used once; ErrNotFound for unknown states
+	Take(ctx context.Context, state string) (OIDCLogin, error)
+}
+
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Compare(hash, password string) error
+}
+
+type UserManager interface {
+	Authenticate(ctx context.Context, name, password string) (User, error)
+	Get(ctx context.Context, name string) (User, error)
+	Register(ctx context.Context, name, password string) (User, error)
+	Provision(ctx context.Context, identity Identity) (User, error)
+	Create(ctx context.Context, name, password string, role Role) (User, error)
+	List(ctx context.Context) ([]User, error)
+	SetRole(ctx context.Context, name string, role Role) error
+	Delete(ctx context.Context, name string) error
+}
+
+// RateLimiter keeps a quota per client key
 type RateLimiter interface {
-	Allow() bool
-	Wait()
+	Allow(ctx context.Context, key string) (RateLimit, error)
 }
 
+// ConcurrencyLimiter hands out a limited number of slots,
+// release gives the slot back and is nil when none was taken.
+// Priority requests go first when the limiter queues them.
 type ConcurrencyLimiter interface {
-	Acquire() bool
-	Release()
+	Acquire(ctx context.Context, priority bool) (release func(), ok bool, err error)
+}
+
+type QueueMonitor interface {
+	Stats() QueueStats
+}
+
+type CacheMonitor interface {
+	Stats() CacheStats
+}
+
+type Cache interface {
+	Get(key string) (any, bool)
+	Add(key string, value any)
+	Purge()
+	Stats() CacheStats
 }
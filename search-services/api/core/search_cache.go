@@ -0,0 +1,139 @@
+package core
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+type searchResult struct {
+	comics []Comics
+	total  int
+}
+
+// CachedSearcher keeps search results until they expire or the comics change.
+// The generation is a part of the key, so a search that was running
+// during invalidation can't put an old result back.
+type CachedSearcher struct {
+	log        *slog.Logger
+	searcher   Searcher
+	cache      Cache
+	generation atomic.Uint64
+}
+
+func NewCachedSearcher(log *slog.Logger, searcher Searcher, cache Cache) *CachedSearcher {
+	return &CachedSearcher{
+		log:      log,
+		searcher: searcher,
+		cache:    cache,
+	}
+}
+
+func (s *CachedSearcher) Search(ctx context.Context, phrase string, limit int, source string) ([]Comics, int, error) {
+	return s.cached(ctx, "search", phrase, limit, source, s.searcher.Search)
+}
+
+func (s *CachedSearcher) ISearch(ctx context.Context, phrase string, limit int, source string) ([]Comics, int, error) {
+	return s.cached(ctx, "isearch", phrase, limit, source, s.searcher.ISearch)
+}
+
+func (s *CachedSearcher) cached(
+	ctx context.Context, mode, phrase string, limit int, source string,
+	search func(context.Context, string, int, string) ([]Comics, int, error),
+) ([]Comics, int, error) {
+	key := s.key(mode, phrase, limit, source)
+	if value, ok := s.cache.Get(key); ok {
+		result := value.(searchResult)
+		return result.comics, result.total, nil
+	}
+
+	comics, total, err := search(ctx, phrase, limit, source)
+	if err != nil {
+		return nil, 0, err
+	}
+	s.cache.Add(key, searchResult{comics: comics, total: total})
+	return comics, total, nil
+}
+
+// key ignores case and spacing of the phrase, the normalizer drops them anyway
+func (s *CachedSearcher) key(mode, phrase string, limit int, source string) string {
+	return strings.Join([]string{
+		strconv.FormatUint(s.generation.Load(), 10),
+		mode,
+		strings.Join(strings.Fields(strings.ToLower(phrase)), " "),
+		strconv.Itoa(limit),
+		source,
+	}, "\x00")
+}
+
+func (s *CachedSearcher) Invalidate() {
+	s.generation.Add(1)
+	s.cache.Purge()
+}
+
+func (s *CachedSearcher) Stats() CacheStats {
+	return s.cache.Stats()
+}
+
+// WatchUpdates invalidates the cache when an update finishes,
+// also the ones started by the schedule or by another gateway replica
+func (s *CachedSearcher) WatchUpdates(ctx context.Context, updater Updater, period time.Duration) {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	last := StatusUpdateUnknown
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		status, err := updater.Status(ctx)
+		if err != nil {
+			s.log.Debug("failed to get update status", "error", err)
+			continue
+		}
+		if last == StatusUpdateRunning && status != StatusUpdateRunning {
+			s.log.Debug("update finished, invalidating search cache")
+			s.Invalidate()
+		}
+		last = status
+	}
+}
+
+// InvalidatingUpdater drops cached searches once the comics have been changed through it
+type InvalidatingUpdater struct {
+	Updater
+	searches *CachedSearcher
+}
+
+func NewInvalidatingUpdater(updater Updater, searches *CachedSearcher) *InvalidatingUpdater {
+	return &InvalidatingUpdater{Updater: updater, searches: searches}
+}
+
+func (u *InvalidatingUpdater) Update(ctx context.Context) error {
+	return u.invalidate(u.Updater.Update(ctx))
+}
+
+func (u *InvalidatingUpdater) Drop(ctx context.Context) error {
+	return u.invalidate(u.Updater.Drop(ctx))
+}
+
+func (u *InvalidatingUpdater) Reindex(ctx context.Context, filter ReindexFilter) error {
+	return u.invalidate(u.Updater.Reindex(ctx, filter))
+}
+
+func (u *InvalidatingUpdater) Renormalize(ctx context.Context, onlyStale bool) error {
+	return u.invalidate(u.Updater.Renormalize(ctx, onlyStale))
+}
+
+func (u *InvalidatingUpdater) invalidate(err error) error {
+	if err == nil {
+		u.searches.Invalidate()
+	}
+	return err
+}
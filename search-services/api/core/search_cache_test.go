@@ -0,0 +1,229 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockSearcher struct {
+	mu    sync.Mutex
+	calls int
+	err   error
+}
+
+func (m *mockSearcher) Search(ctx context.Context, phrase string, limit int, source string) ([]Comics, int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls++
+	if m.err != nil {
+		return nil, 0, m.err
+	}
+	return []Comics{{ID: int64(m.calls), Source: source}}, m.calls, nil
+}
+
+func (m *mockSearcher) ISearch(ctx context.Context, phrase string, limit int, source string) ([]Comics, int, error) {
+	return m.Search(ctx, phrase, limit, source)
+}
+
+func (m *mockSearcher) count() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.calls
+}
+
+// кэш без вытеснения и срока жизни
+type mapCache struct {
+	mu    sync.Mutex
+	items map[string]any
+}
+
+func (c *mapCache) Get(key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok := c.items[key]
+	return value, ok
+}
+
+func (c *mapCache) Add(key string, value any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items[key] = value
+}
+
+func (c *mapCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items = map[string]any{}
+}
+
+func (c *mapCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CacheStats{Size: len(c.items)}
+}
+
+type mockUpdater struct {
+	Updater
+	mu     sync.Mutex
+	status UpdateStatus
+	err    error
+}
+
+func (m *mockUpdater) Update(ctx context.Context) error {
+	return m.err
+}
+
+func (m *mockUpdater) Drop(ctx context.Context) error {
+	return m.err
+}
+
+func (m *mockUpdater) Status(ctx context.Context) (UpdateStatus, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.status, nil
+}
+
+func (m *mockUpdater) setStatus(status UpdateStatus) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.status = status
+}
+
+func newTestCachedSearcher() (*CachedSearcher, *mockSearcher) {
+	searcher := &mockSearcher{}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewCachedSearcher(log, searcher, &mapCache{items: map[string]any{}}), searcher
+}
+
+func TestCachedSearcher_Key(t *testing.T) {
+	tests := []struct {
+		name   string
+		search func(s *CachedSearcher) error
+		calls  int
+	}{
+		{
+			name: "same query",
+			search: func(s *CachedSearcher) error {
+				_, _, err := s.Search(context.Background(), "linux cpu", 10, "")
+				return err
+			},
+			calls: 1,
+		},
+		{
+			name: "case and spaces",
+			search: func(s *CachedSearcher) error {
+				_, _, err := s.Search(context.Background(), "  Linux   CPU ", 10, "")
+				return err
+			},
+			calls: 1,
+		},
+		{
+			name: "other limit",
+			search: func(s *CachedSearcher) error {
+				_, _, err := s.Search(context.Background(), "linux cpu", 5, "")
+				return err
+			},
+			calls: 2,
+		},
+		{
+			name: "other source",
+			search: func(s *CachedSearcher) error {
+				_, _, err := s.Search(context.Background(), "linux cpu", 10, "xkcd")
+				return err
+			},
+			calls: 2,
+		},
+		{
+			name: "other mode",
+			search: func(s *CachedSearcher) error {
+				_, _, err := s.ISearch(context.Background(), "linux cpu", 10, "")
+				return err
+			},
+			calls: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cached, searcher := newTestCachedSearcher()
+			comics, total, err := cached.Search(context.Background(), "linux cpu", 10, "")
+			require.NoError(t, err)
+			assert.Equal(t, 1, total)
+			assert.Len(t, comics, 1)
+
+			require.NoError(t, tt.search(cached))
+			assert.Equal(t, tt.calls, searcher.count())
+		})
+	}
+}
+
+func TestCachedSearcher_ErrorsNotCached(t *testing.T) {
+	cached, searcher := newTestCachedSearcher()
+	searcher.err = errors.New("search is down")
+
+	_, _, err := cached.Search(context.Background(), "linux", 10, "")
+	assert.Error(t, err)
+
+	searcher.err = nil
+	_, total, err := cached.Search(context.Background(), "linux", 10, "")
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+}
+
+func TestInvalidatingUpdater(t *testing.T) {
+	cached, searcher := newTestCachedSearcher()
+	updater := &mockUpdater{}
+	invalidating := NewInvalidatingUpdater(updater, cached)
+
+	_, _, err := cached.Search(context.Background(), "linux", 10, "")
+	require.NoError(t, err)
+
+	// неудачное обновление кэш не сбрасывает
+	updater.err = errors.New("already updating")
+	assert.Error(t, invalidating.Update(context.Background()))
+	_, _, err = cached.Search(context.Background(), "linux", 10, "")
+	require.NoError(t, err)
+	assert.Equal(t, 1, searcher.count())
+
+	updater.err = nil
+	require.NoError(t, invalidating.Update(context.Background()))
+	_, _, err = cached.Search(context.Background(), "linux", 10, "")
+	require.NoError(t, err)
+	assert.Equal(t, 2, searcher.count())
+
+	require.NoError(t, invalidating.Drop(context.Background()))
+	_, _, err = cached.Search(context.Background(), "linux", 10, "")
+	require.NoError(t, err)
+	assert.Equal(t, 3, searcher.count())
+}
+
+func TestCachedSearcher_WatchUpdates(t *testing.T) {
+	cached, searcher := newTestCachedSearcher()
+	updater := &mockUpdater{status: StatusUpdateRunning}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go cached.WatchUpdates(ctx, updater, time.Millisecond)
+
+	_, _, err := cached.Search(context.Background(), "linux", 10, "")
+	require.NoError(t, err)
+	time.Sleep(10 * time.Millisecond)
+	_, _, err = cached.Search(context.Background(), "linux", 10, "")
+	require.NoError(t, err)
+	assert.Equal(t, 1, searcher.count())
+
+	// обновление закончилось — кэш сбрасывается
+	updater.setStatus(StatusUpdateIdle)
+	require.Eventually(t, func() bool { return cached.Stats().Size == 0 }, time.Second, time.Millisecond)
+	_, _, err = cached.Search(context.Background(), "linux", 10, "")
+	require.NoError(t, err)
+	assert.Equal(t, 2, searcher.count())
+}
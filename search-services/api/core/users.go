@@ -0,0 +1,155 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"unicode"
+)
+
+const (
+	maxNameLength     = 64
+	minPasswordLength = 8
+	// bcrypt ignores everything after 72 bytes
+	maxPasswordLength = 72
+	// externalUserPrefix can't appear in local user names
+	externalUserPrefix = "oidc:"
+)
+
+type Users struct {
+	store  UserStore
+	hasher PasswordHasher
+	// dummyHash is compared for unknown users, so the answer takes as long
+	// as for a wrong password and doesn't tell which names exist
+	dummyHash func() string
+}
+
+func NewUsers(store UserStore, hasher PasswordHasher) *Users {
+	return &Users{
+		store:  store,
+		hasher: hasher,
+		dummyHash: sync.OnceValue(func() string {
+			hash, _ := hasher.Hash("dummy password")
+			return hash
+		}),
+	}
+}
+
+func (u *Users) Authenticate(ctx context.Context, name, password string) (User, error) {
+	user, hash, err := u.store.Get(ctx, name)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			_ = u.hasher.Compare(u.dummyHash(), password)
+			return User{}, ErrInvalidCredentials
+		}
+		return User{}, err
+	}
+	if hash == "" || u.hasher.Compare(hash, password) != nil {
+		return User{}, ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+func (u *Users) Get(ctx context.Context, name string) (User, error) {
+	user, _, err := u.store.Get(ctx, name)
+	return user, err
+}
+
+// Register creates a user with the lowest role
+func (u *Users) Register(ctx context.Context, name, password string) (User, error) {
+	return u.Create(ctx, name, password, RoleViewer)
+}
+
+func (u *Users) Create(ctx context.Context, name, password string, role Role) (User, error) {
+	if !validName(name) || len(password) < minPasswordLength || len(password) > maxPasswordLength || !role.Valid() {
+		return User{}, ErrBadArguments
+	}
+	hash, err := u.hasher.Hash(password)
+	if err != nil {
+		return User{}, err
+	}
+	return u.store.Create(ctx, User{Name: name, Role: role}, hash)
+}
+
+// Provision creates or updates a user signed in through an identity provider.
+// Users are found by issuer and subject: the username claim can be changed
+// or reused by another account, so it is kept only as the display name.
+// Such users have no password and their names can't clash with local ones.
+func (u *Users) Provision(ctx context.Context, identity Identity) (User, error) {
+	if identity.Issuer == "" || identity.Subject == "" || !identity.Role.Valid() {
+		return User{}, ErrBadArguments
+	}
+	displayName := identity.Name
+	if displayName == "" {
+		displayName = identity.Subject
+	}
+
+	user, err := u.store.GetExternal(ctx, identity.Issuer, identity.Subject)
+	if errors.Is(err, ErrNotFound) {
+		user = User{Name: externalName(identity), DisplayName: displayName, Role: identity.Role}
+		return u.store.CreateExternal(ctx, user, identity.Issuer, identity.Subject)
+	}
+	if err != nil {
+		return User{}, err
+	}
+
+	// the identity provider is the source of truth for the role and the name
+	if user.Role != identity.Role {
+		if err := u.store.SetRole(ctx, user.Name, identity.Role); err != nil {
+			return User{}, err
+		}
+		user.Role = identity.Role
+	}
+	if user.DisplayName != displayName {
+		if err := u.store.SetDisplayName(ctx, user.Name, displayName); err != nil {
+			return User{}, err
+		}
+		user.DisplayName = displayName
+	}
+	return user, nil
+}
+
+// externalName is stable for the identity and fits maxNameLength
+func externalName(identity Identity) string {
+	sum := sha256.Sum256([]byte(identity.Issuer + "\x00" + identity.Subject))
+	return externalUserPrefix + hex.EncodeToString(sum[:16])
+}
+
+func (u *Users) List(ctx context.Context) ([]User, error) {
+	return u.store.List(ctx)
+}
+
+// SetRole and Delete fail with ErrBadArguments when no admin would be left
+func (u *Users) SetRole(ctx context.Context, name string, role Role) error {
+	if !role.Valid() {
+		return ErrBadArguments
+	}
+	return u.store.SetRole(ctx, name, role)
+}
+
+func (u *Users) Delete(ctx context.Context, name string) error {
+	return u.store.Delete(ctx, name)
+}
+
+// EnsureAdmin creates the configured admin unless a user with this name exists
+func (u *Users) EnsureAdmin(ctx context.Context, name, password string) error {
+	_, err := u.Create(ctx, name, password, RoleAdmin)
+	if errors.Is(err, ErrAlreadyExists) {
+		return nil
+	}
+	return err
+}
+
+func validName(name string) bool {
+	if name == "" || len(name) > maxNameLength {
+		return false
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' && r != '.' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,314 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockStore struct {
+	users      map[string]User
+	hashes     map[string]string
+	identities map[string]string
+	err        error
+}
+
+func newMockStore() *mockStore {
+	return &mockStore{users: map[string]User{}, hashes: map[string]string{}, identities: map[string]string{}}
+}
+
+func (m *mockStore) Create(ctx context.Context, user User, hash string) (User, error) {
+	if m.err != nil {
+		return User{}, m.err
+	}
+	if _, ok := m.users[user.Name]; ok {
+		return User{}, ErrAlreadyExists
+	}
+	user.ID = int64(len(m.users) + 1)
+	m.users[user.Name] = user
+	m.hashes[user.Name] = hash
+	return user, nil
+}
+
+func (m *mockStore) Get(ctx context.Context, name string) (User, string, error) {
+	if m.err != nil {
+		return User{}, "", m.err
+	}
+	user, ok := m.users[name]
+	if !ok {
+		return User{}, "", ErrNotFound
+	}
+	return user, m.hashes[name], nil
+}
+
+func (m *mockStore) List(ctx context.Context) ([]User, error) {
+	var users []User
+	for _, user := range m.users {
+		users = append(users, user)
+	}
+	return users, m.err
+}
+
+func (m *mockStore) SetRole(ctx context.Context, name string, role Role) error {
+	user, ok := m.users[name]
+	if !ok {
+		return ErrNotFound
+	}
+	if role != RoleAdmin && m.lastAdmin(name) {
+		return ErrBadArguments
+	}
+	user.Role = role
+	m.users[name] = user
+	return nil
+}
+
+func (m *mockStore) Delete(ctx context.Context, name string) error {
+	if _, ok := m.users[name]; !ok {
+		return ErrNotFound
+	}
+	if m.lastAdmin(name) {
+		return ErrBadArguments
+	}
+	delete(m.users, name)
+	return nil
+}
+
+func (m *mockStore) lastAdmin(name string) bool {
+	if m.users[name].Role != RoleAdmin {
+		return false
+	}
+	admins := 0
+	for _, user := range m.users {
+		if user.Role == RoleAdmin {
+			admins++
+		}
+	}
+	return admins == 1
+}
+
+func (m *mockStore) CreateExternal(ctx context.Context, user User, issuer, subject string) (User, error) {
+	if _, ok := m.identities[issuer+" "+subject]; ok {
+		return User{}, ErrAlreadyExists
+	}
+	user, err := m.Create(ctx, user, "")
+	if err != nil {
+		return User{}, err
+	}
+	m.identities[issuer+" "+subject] = user.Name
+	return user, nil
+}
+
+func (m *mockStore) GetExternal(ctx context.Context, issuer, subject string) (User, error) {
+	name, ok := m.identities[issuer+" "+subject]
+	if !ok {
+		return User{}, ErrNotFound
+	}
+	user, _, err := m.Get(ctx, name)
+	return user, err
+}
+
+func (m *mockStore) SetDisplayName(ctx context.Context, name, displayName string) error {
+	user, ok := m.users[name]
+	if !ok {
+		return ErrNotFound
+	}
+	user.DisplayName = displayName
+	m.users[name] = user
+	return nil
+}
+
+// хешер-заглушка, чтобы не ждать bcrypt
+type mockHasher struct{}
+
+func (mockHasher) Hash(password string) (string, error) {
+	return "hash:" + password, nil
+}
+
+func (mockHasher) Compare(hash, password string) error {
+	if hash != "hash:"+password {
+		return errors.New("mismatch")
+	}
+	return nil
+}
+
+// countingHasher считает сравнения, чтобы проверить время ответа для неизвестного имени
+type countingHasher struct {
+	mockHasher
+	compared int
+}
+
+func (h *countingHasher) Compare(hash, password string) error {
+	h.compared++
+	return h.mockHasher.Compare(hash, password)
+}
+
+func TestRole_Allows(t *testing.T) {
+	tests := []struct {
+		role     Role
+		required Role
+		want     bool
+	}{
+		{RoleViewer, RoleViewer, true},
+		{RoleViewer, RoleOperator, false},
+		{RoleOperator, RoleOperator, true},
+		{RoleOperator, RoleAdmin, false},
+		{RoleAdmin, RoleViewer, true},
+		{RoleAdmin, RoleAdmin, true},
+		{Role("superuser"), RoleViewer, false},
+		{Role(""), RoleViewer, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.role)+"_"+string(tt.required), func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.role.Allows(tt.required))
+		})
+	}
+}
+
+func TestUsers_Create(t *testing.T) {
+	tests := []struct {
+		name     string
+		user     string
+		password string
+		role     Role
+		wantErr  error
+	}{
+		{name: "ok", user: "alice", password: "password", role: RoleOperator},
+		{name: "empty name", user: "", password: "password", role: RoleViewer, wantErr: ErrBadArguments},
+		{name: "bad name", user: "al ice", password: "password", role: RoleViewer, wantErr: ErrBadArguments},
+		{name: "long name", user: strings.Repeat("a", 65), password: "password", role: RoleViewer, wantErr: ErrBadArguments},
+		{name: "short password", user: "alice", password: "short", role: RoleViewer, wantErr: ErrBadArguments},
+		{name: "long password", user: "alice", password: strings.Repeat("p", 73), role: RoleViewer, wantErr: ErrBadArguments},
+		{name: "unknown role", user: "alice", password: "password", role: "root", wantErr: ErrBadArguments},
+		{name: "duplicate", user: "bob", password: "password", role: RoleViewer, wantErr: ErrAlreadyExists},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMockStore()
+			users := NewUsers(store, mockHasher{})
+			_, err := users.Create(context.Background(), "bob", "password", RoleViewer)
+			assert.NoError(t, err)
+
+			user, err := users.Create(context.Background(), tt.user, tt.password, tt.role)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.user, user.Name)
+			assert.Equal(t, tt.role, user.Role)
+			assert.Equal(t, "hash:"+tt.password, store.hashes[tt.user])
+		})
+	}
+}
+
+func TestUsers_Authenticate(t *testing.T) {
+	store := newMockStore()
+	hasher := &countingHasher{}
+	users := NewUsers(store, hasher)
+	registered, err := users.Register(context.Background(), "alice", "password")
+	assert.NoError(t, err)
+	assert.Equal(t, RoleViewer, registered.Role)
+
+	user, err := users.Authenticate(context.Background(), "alice", "password")
+	assert.NoError(t, err)
+	assert.Equal(t, registered, user)
+
+	_, err = users.Authenticate(context.Background(), "alice", "wrong")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	_, err = users.Authenticate(context.Background(), "bob", "password")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	// для неизвестного имени хеш тоже сравнивается
+	assert.Equal(t, 3, hasher.compared)
+
+	store.err = errors.New("db is down")
+	_, err = users.Authenticate(context.Background(), "alice", "password")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestUsers_SetRoleAndDelete(t *testing.T) {
+	store := newMockStore()
+	users := NewUsers(store, mockHasher{})
+	_, err := users.Register(context.Background(), "alice", "password")
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, users.SetRole(context.Background(), "alice", "root"), ErrBadArguments)
+	assert.NoError(t, users.SetRole(context.Background(), "alice", RoleAdmin))
+	assert.Equal(t, RoleAdmin, store.users["alice"].Role)
+	assert.ErrorIs(t, users.SetRole(context.Background(), "bob", RoleAdmin), ErrNotFound)
+
+	// последнего администратора нельзя понизить или удалить
+	assert.ErrorIs(t, users.SetRole(context.Background(), "alice", RoleViewer), ErrBadArguments)
+	assert.ErrorIs(t, users.Delete(context.Background(), "alice"), ErrBadArguments)
+	assert.Equal(t, RoleAdmin, store.users["alice"].Role)
+
+	_, err = users.Create(context.Background(), "bob", "password", RoleAdmin)
+	assert.NoError(t, err)
+	assert.NoError(t, users.SetRole(context.Background(), "alice", RoleViewer))
+	assert.ErrorIs(t, users.Delete(context.Background(), "bob"), ErrBadArguments)
+
+	assert.NoError(t, users.Delete(context.Background(), "alice"))
+	assert.ErrorIs(t, users.Delete(context.Background(), "alice"), ErrNotFound)
+}
+
+func TestUsers_EnsureAdmin(t *testing.T) {
+	store := newMockStore()
+	users := NewUsers(store, mockHasher{})
+
+	assert.NoError(t, users.EnsureAdmin(context.Background(), "admin", "password"))
+	assert.Equal(t, RoleAdmin, store.users["admin"].Role)
+
+	// существующий пользователь не перезаписывается
+	assert.NoError(t, users.EnsureAdmin(context.Background(), "admin", "other-password"))
+	assert.Equal(t, "hash:password", store.hashes["admin"])
+
+	assert.ErrorIs(t, users.EnsureAdmin(context.Background(), "", "password"), ErrBadArguments)
+}
+
+func TestUsers_Provision(t *testing.T) {
+	store := newMockStore()
+	users := NewUsers(store, mockHasher{})
+
+	const issuer = "https://sso.example.com"
+	alice, err := users.Provision(context.Background(), Identity{Issuer: issuer, Subject: "1", Name: "alice", Role: RoleViewer})
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(alice.Name, "oidc:"))
+	assert.Equal(t, "alice", alice.DisplayName)
+	assert.Equal(t, RoleViewer, alice.Role)
+
+	// роль и имя берутся из провайдера при каждом входе
+	user, err := users.Provision(context.Background(), Identity{Issuer: issuer, Subject: "1", Name: "alice.b", Role: RoleOperator})
+	assert.NoError(t, err)
+	assert.Equal(t, alice.Name, user.Name)
+	assert.Equal(t, RoleOperator, store.users[alice.Name].Role)
+	assert.Equal(t, "alice.b", store.users[alice.Name].DisplayName)
+
+	// чужой аккаунт с тем же именем не получает пользователя alice
+	user, err = users.Provision(context.Background(), Identity{Issuer: issuer, Subject: "2", Name: "alice.b", Role: RoleViewer})
+	assert.NoError(t, err)
+	assert.NotEqual(t, alice.Name, user.Name)
+	assert.Equal(t, RoleOperator, store.users[alice.Name].Role)
+
+	// тот же subject у другого провайдера — другой пользователь
+	user, err = users.Provision(context.Background(), Identity{Issuer: "https://other.example.com", Subject: "1", Role: RoleViewer})
+	assert.NoError(t, err)
+	assert.NotEqual(t, alice.Name, user.Name)
+	assert.Equal(t, "1", user.DisplayName)
+
+	// без пароля войти нельзя
+	_, err = users.Authenticate(context.Background(), alice.Name, "")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	_, err = users.Provision(context.Background(), Identity{Issuer: issuer, Name: "bob", Role: RoleViewer})
+	assert.ErrorIs(t, err, ErrBadArguments)
+	_, err = users.Provision(context.Background(), Identity{Subject: "3", Name: "bob", Role: RoleViewer})
+	assert.ErrorIs(t, err, ErrBadArguments)
+	_, err = users.Provision(context.Background(), Identity{Issuer: issuer, Subject: "3", Name: "bob", Role: "root"})
+	assert.ErrorIs(t, err, ErrBadArguments)
+}
@@ -5,12 +5,18 @@ import (
 	"errors"
 	"flag"
 	"log/slog"
+	"maps"
 	"net/http"
 	"os"
 	"os/signal"
+	"syscall"
+	"time"
 
 	"yadro.com/course/api/adapters/auth"
+	"yadro.com/course/api/adapters/cache"
+	"yadro.com/course/api/adapters/db"
 	"yadro.com/course/api/adapters/limiter"
+	"yadro.com/course/api/adapters/oidc"
 	"yadro.com/course/api/adapters/rest"
 	"yadro.com/course/api/adapters/search"
 	"yadro.com/course/api/adapters/update"
@@ -19,6 +25,9 @@ import (
 	"yadro.com/course/api/core"
 )
 
+// updateWatchPeriod is how often the update status is checked to invalidate the search cache
+const updateWatchPeriod = 10 * time.Second
+
 func main() {
 	var configPath string
 	flag.StringVar(&configPath, "config", "config.yaml", "server configuration file")
@@ -48,19 +57,129 @@ func main() {
 		os.Exit(1)
 	}
 
-	rateLimiter := limiter.NewRateLimiter(cfg.SearchRate)
-	concurrencyLimiter := limiter.NewConcurrencyLimiter(cfg.SearchConcurrency)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var searcher core.Searcher = searchClient
+	var updater core.Updater = updateClient
+	caches := map[string]core.CacheMonitor{}
+	if cfg.SearchCacheSize > 0 {
+		searchCache, err := cache.NewLRU(cfg.SearchCacheSize, cfg.SearchCacheTTL)
+		if err != nil {
+			log.Error("cannot init search cache", "error", err)
+			os.Exit(1)
+		}
+		cachedSearcher := core.NewCachedSearcher(log, searchClient, searchCache)
+		go cachedSearcher.WatchUpdates(ctx, updateClient, updateWatchPeriod)
+		searcher = cachedSearcher
+		updater = core.NewInvalidatingUpdater(updateClient, cachedSearcher)
+		caches["search"] = cachedSearcher
+	}
+	searchCacheHeaders := rest.WithETag(cfg.SearchCacheTTL, log)
 
-	jwtAuth := auth.NewJWTAuth(cfg.AuthConfig)
+	storage, err := db.New(log, cfg.DBAddress)
+	if err != nil {
+		log.Error("failed to connect to db", "error", err)
+		os.Exit(1)
+	}
+	if err := storage.Migrate(); err != nil {
+		log.Error("failed to migrate db", "error", err)
+		os.Exit(1)
+	}
+
+	users := core.NewUsers(storage, auth.NewBcrypt(cfg.AuthConfig.BcryptCost))
+	if err := users.EnsureAdmin(context.Background(), cfg.AuthConfig.AdminUser, cfg.AuthConfig.AdminPassword); err != nil {
+		log.Error("failed to create admin user", "error", err)
+		os.Exit(1)
+	}
+
+	apiKeys := core.NewAPIKeys(log, storage)
+
+	var signingKeys *auth.KeySet
+	if len(cfg.AuthConfig.SigningKeys) > 0 {
+		signingKeys, err = auth.LoadKeySet(cfg.AuthConfig.SigningKeys, cfg.AuthConfig.SigningKeyID)
+		if err != nil {
+			log.Error("failed to load signing keys", "error", err)
+			os.Exit(1)
+		}
+		// SIGHUP or the timer picks up rotated key files
+		reload := make(chan os.Signal, 1)
+		signal.Notify(reload, syscall.SIGHUP)
+		go signingKeys.Watch(ctx, log, cfg.AuthConfig.KeysReload, reload)
+	}
+	jwtAuth, err := auth.NewJWTAuth(cfg.AuthConfig, signingKeys, db.NewDenylist(storage))
+	if err != nil {
+		log.Error("cannot init token auth, set jwt_secret or signing_keys", "error", err)
+		os.Exit(1)
+	}
+	operator := rest.WithAuth(core.RoleOperator, log)
+	admin := rest.WithAuth(core.RoleAdmin, log)
+
+	trustedProxies, err := rest.ParseTrustedProxies(cfg.HTTPConfig.TrustedProxies)
+	if err != nil {
+		log.Error("failed to parse trusted proxies", "error", err)
+		os.Exit(1)
+	}
+	principal := rest.WithPrincipal(jwtAuth, apiKeys, trustedProxies)
+
+	rateLimits := config.RateLimits{
+		"isearch": {Rate: cfg.SearchRate, Burst: int(cfg.SearchRate)},
+		// every authorize request is stored until its callback, so anonymous clients are limited
+		"oidc": {Rate: 1, Burst: 10},
+	}
+	maps.Copy(rateLimits, cfg.RateLimits)
+	var shared bool
+	switch cfg.LimiterBackend {
+	case "local":
+	case "postgres":
+		shared = true
+	default:
+		log.Error("unknown limiter backend", "backend", cfg.LimiterBackend)
+		os.Exit(1)
+	}
+
+	// every route has its own quota
+	rateLimit := func(route string, handler http.Handler) http.Handler {
+		quota, ok := rateLimits[route]
+		if !ok || quota.Rate <= 0 {
+			return handler
+		}
+		var rateLimiter core.RateLimiter = limiter.NewTokenBuckets(quota.Rate, quota.Burst)
+		if shared {
+			rateLimiter = limiter.NewFallbackRateLimiter(log, route,
+				limiter.NewSharedRateLimiter(log, storage, route, quota.Rate, quota.Burst), rateLimiter)
+		}
+		return rest.WithRateLimit(rateLimiter, log)(handler)
+	}
+
+	var concurrencyLimiter core.ConcurrencyLimiter = limiter.NewConcurrencyLimiter(cfg.SearchConcurrency)
+	if shared {
+		concurrencyLimiter = limiter.NewFallbackConcurrencyLimiter(log, "search",
+			limiter.NewSharedConcurrencyLimiter(log, storage, "search", cfg.SearchConcurrency), concurrencyLimiter)
+	}
+	// slots of other replicas are freed without notice, the queue has to poll them
+	var queuePoll time.Duration
+	if shared {
+		queuePoll = 20 * time.Millisecond
+	}
+	searchQueue := limiter.NewQueue(concurrencyLimiter, cfg.SearchQueue, cfg.SearchMaxWait, queuePoll)
 
 	mux := http.NewServeMux()
 
-	mux.Handle("POST /api/db/update", rest.WithAuth(jwtAuth, log)(rest.NewUpdateHandler(log, updateClient)))
-	mux.Handle("DELETE /api/db", rest.WithAuth(jwtAuth, log)(rest.NewDropHandler(log, updateClient)))
+	mux.Handle("POST /api/db/update", operator(rest.NewUpdateHandler(log, updater)))
+	mux.Handle("POST /api/db/reindex", operator(rest.NewReindexHandler(log, updater)))
+	mux.Handle("POST /api/db/renormalize", operator(rest.NewRenormalizeHandler(log, updater)))
+	mux.Handle("DELETE /api/db", admin(rest.NewDropHandler(log, updater)))
 
-	mux.Handle("GET /api/search", rest.WithConcurrencyLimit(concurrencyLimiter, log)(rest.NewSearchHandler(log, searchClient)))
-	mux.Handle("GET /api/isearch", rest.WithRateLimit(rateLimiter, log)(rest.NewISearchHandler(log, searchClient)))
+	mux.Handle("GET /api/search", rateLimit("search", rest.WithConcurrencyLimit(searchQueue, log)(
+		searchCacheHeaders(rest.NewSearchHandler(log, searcher)))))
+	mux.Handle("GET /api/isearch", rateLimit("isearch", searchCacheHeaders(rest.NewISearchHandler(log, searcher))))
 
+	mux.Handle("GET /api/comics/{id}/image", rest.NewComicImageHandler(log, updateClient))
+
+	mux.Handle("GET /api/metrics", operator(rest.NewMetricsHandler(log, map[string]core.QueueMonitor{
+		"search": searchQueue,
+	}, caches)))
 	mux.Handle("GET /api/db/stats", rest.NewUpdateStatsHandler(log, updateClient))
 	mux.Handle("GET /api/db/status", rest.NewUpdateStatusHandler(log, updateClient))
 	mux.Handle("GET /api/ping", rest.NewPingHandler(log, map[string]core.Pinger{
@@ -69,17 +188,37 @@ func main() {
 		"search": searchClient,
 	}))
 
-	mux.Handle("POST /api/login", rest.NewLoginHandler(jwtAuth, log))
+	mux.Handle("GET /.well-known/jwks.json", rest.NewJWKSHandler(jwtAuth, log))
+	mux.Handle("POST /api/login", rateLimit("login", rest.NewLoginHandler(jwtAuth, users, log)))
+	mux.Handle("POST /api/token/refresh", rest.NewRefreshHandler(jwtAuth, users, log))
+	mux.Handle("POST /api/logout", rest.NewLogoutHandler(jwtAuth, log))
+	mux.Handle("POST /api/register", rateLimit("register", rest.NewRegisterHandler(log, users)))
+
+	if cfg.OIDCConfig.Issuer != "" {
+		provider, err := oidc.New(cfg.OIDCConfig, db.NewOIDCLogins(storage), log)
+		if err != nil {
+			log.Error("cannot init oidc provider", "error", err)
+			os.Exit(1)
+		}
+		mux.Handle("GET /api/oidc/authorize", rateLimit("oidc", rest.NewOIDCAuthorizeHandler(provider, log)))
+		mux.Handle("POST /api/oidc/callback", rateLimit("oidc", rest.NewOIDCCallbackHandler(provider, jwtAuth, users, log)))
+	}
+
+	mux.Handle("GET /api/users", admin(rest.NewListUsersHandler(log, users)))
+	mux.Handle("POST /api/users", admin(rest.NewCreateUserHandler(log, users)))
+	mux.Handle("PUT /api/users/{name}/role", admin(rest.NewUserRoleHandler(log, users)))
+	mux.Handle("DELETE /api/users/{name}", admin(rest.NewDeleteUserHandler(log, users)))
+
+	mux.Handle("GET /api/keys", admin(rest.NewListAPIKeysHandler(log, apiKeys)))
+	mux.Handle("POST /api/keys", admin(rest.NewCreateAPIKeyHandler(log, apiKeys)))
+	mux.Handle("DELETE /api/keys/{id}", admin(rest.NewRevokeAPIKeyHandler(log, apiKeys)))
 
 	server := http.Server{
 		Addr:        cfg.HTTPConfig.Address,
 		ReadTimeout: cfg.HTTPConfig.Timeout,
-		Handler:     mux,
+		Handler:     rest.WithRequestID(log)(principal(mux)),
 	}
 
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
-	defer stop()
-
 	go func() {
 		<-ctx.Done()
 		log.Debug("shutting down server")
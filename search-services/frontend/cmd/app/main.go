@@ -2,6 +2,7 @@ package main
 
 import (
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 
@@ -12,14 +13,17 @@ import (
 func main() {
 	templates.Init()
 
-	handler := handlers.NewHandler()
+	handler := handlers.NewHandler(slog.New(slog.NewTextHandler(os.Stderr, nil)))
 
 	fs := http.FileServer(http.Dir("static"))
 	http.Handle("/static/", http.StripPrefix("/static/", fs))
 
 	http.HandleFunc("/", handler.HomeHandler)
 	http.HandleFunc("/search", handler.SearchHandler)
+	http.HandleFunc("/images/", handler.ImageHandler)
 	http.HandleFunc("/login", handler.LoginHandler)
+	http.HandleFunc("/login/oidc", handler.OIDCLoginHandler)
+	http.HandleFunc("/login/oidc/callback", handler.OIDCCallbackHandler)
 	http.HandleFunc("/admin", handler.AdminHandler)
 	http.HandleFunc("/admin/update", handler.UpdateHandler)
 	http.HandleFunc("/admin/drop", handler.DropHandler)
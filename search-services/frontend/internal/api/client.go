@@ -3,16 +3,23 @@ package api
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
-	"io"
 	"net/http"
+	neturl "net/url"
 	"os"
 	"time"
 
 	"yadro.com/course/frontend/internal/models"
 )
 
+// ErrUnauthorized means the session is over and the user has to log in again
+var ErrUnauthorized = errors.New("сессия истекла")
+
+// ErrForbidden means the user is known but has no role in the service
+var ErrForbidden = errors.New("нет доступа")
+
 type Client struct {
 	httpClient *http.Client
 	apiAddress string
@@ -25,10 +32,17 @@ func NewClient() *Client {
 	}
 }
 
-func (c *Client) Search(query, limit string) ([]models.Comic, int, error) {
+// Search on behalf of the browser at clientAddr, the API limits searches per client
+func (c *Client) Search(query, limit, clientAddr string) ([]models.Comic, int, error) {
 	encodedQuery := template.URLQueryEscaper(query)
 
-	resp, err := c.httpClient.Get(fmt.Sprintf("%s/api/search?phrase=%s&limit=%s", c.apiAddress, encodedQuery, limit))
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/search?phrase=%s&limit=%s", c.apiAddress, encodedQuery, limit), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	forwardFor(req, clientAddr)
+
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -48,41 +62,134 @@ func (c *Client) Search(query, limit string) ([]models.Comic, int, error) {
 	}
 
 	for i := range result.Comics {
-		result.Comics[i].Title = fmt.Sprintf("XKCD #%d", result.Comics[i].ID)
-		result.Comics[i].Image = result.Comics[i].URL
-		result.Comics[i].PageURL = fmt.Sprintf("https://xkcd.com/%d/", result.Comics[i].ID)
+		comic := &result.Comics[i]
+		if comic.Source == "" || comic.Source == "xkcd" {
+			comic.Title = fmt.Sprintf("XKCD #%d", comic.ID)
+			comic.Image = fmt.Sprintf("/images/%d?size=thumb", comic.ID)
+			comic.Original = fmt.Sprintf("/images/%d", comic.ID)
+			comic.PageURL = fmt.Sprintf("https://xkcd.com/%d/", comic.ID)
+			continue
+		}
+		source := template.URLQueryEscaper(comic.Source)
+		comic.Title = fmt.Sprintf("%s #%d", comic.Source, comic.ID)
+		comic.Image = fmt.Sprintf("/images/%d?size=thumb&source=%s", comic.ID, source)
+		comic.Original = fmt.Sprintf("/images/%d?source=%s", comic.ID, source)
 	}
 
 	return result.Comics, result.Total, nil
 }
 
-func (c *Client) Login(username, password string) (string, error) {
+func (c *Client) Login(username, password, clientAddr string) (*models.Tokens, error) {
 	loginReq := models.LoginRequest{
 		Name:     username,
 		Password: password,
 	}
 
-	jsonData, err := json.Marshal(loginReq)
+	tokens, err := c.postTokens("/api/login", loginReq, clientAddr)
 	if err != nil {
-		return "", err
+		return nil, fmt.Errorf("ошибка аутентификации: %w", err)
 	}
+	return tokens, nil
+}
 
-	resp, err := c.httpClient.Post(fmt.Sprintf("%s/api/login", c.apiAddress), "application/json", bytes.NewBuffer(jsonData))
+func (c *Client) Refresh(refreshToken string) (*models.Tokens, error) {
+	tokens, err := c.postTokens("/api/token/refresh", models.RefreshRequest{RefreshToken: refreshToken}, "")
 	if err != nil {
-		return "", err
+		return nil, fmt.Errorf("ошибка обновления сессии: %w", err)
+	}
+	return tokens, nil
+}
+
+func (c *Client) Logout(refreshToken string) error {
+	jsonData, err := json.Marshal(models.RefreshRequest{RefreshToken: refreshToken})
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Post(c.apiAddress+"/api/logout", "application/json", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("ошибка аутентификации: код %d", resp.StatusCode)
+		return fmt.Errorf("ошибка выхода: код %d", resp.StatusCode)
 	}
+	return nil
+}
 
-	token, err := io.ReadAll(resp.Body)
+// OIDCAuthorize returns the identity provider page and the state to check on callback
+func (c *Client) OIDCAuthorize() (string, string, error) {
+	resp, err := c.httpClient.Get(c.apiAddress + "/api/oidc/authorize")
 	if err != nil {
-		return "", err
+		return "", "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("ошибка входа через SSO: код %d", resp.StatusCode)
+	}
+
+	var result struct {
+		URL   string `json:"url"`
+		State string `json:"state"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", "", err
 	}
+	return result.URL, result.State, nil
+}
 
-	return string(token), nil
+func (c *Client) OIDCLogin(code, state, clientAddr string) (*models.Tokens, error) {
+	tokens, err := c.postTokens("/api/oidc/callback", models.OIDCCallbackRequest{Code: code, State: state}, clientAddr)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка входа через SSO: %w", err)
+	}
+	return tokens, nil
+}
+
+func (c *Client) postTokens(path string, body any, clientAddr string) (*models.Tokens, error) {
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", c.apiAddress+path, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	forwardFor(req, clientAddr)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, ErrUnauthorized
+	}
+	if resp.StatusCode == http.StatusForbidden {
+		return nil, ErrForbidden
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("код %d", resp.StatusCode)
+	}
+
+	var tokens models.Tokens
+	if err := json.NewDecoder(resp.Body).Decode(&tokens); err != nil {
+		return nil, err
+	}
+	return &tokens, nil
+}
+
+// forwardFor passes the browser address, the API trusts it only from the frontend
+func forwardFor(req *http.Request, clientAddr string) {
+	if clientAddr != "" {
+		req.Header.Set("X-Forwarded-For", clientAddr)
+	}
 }
 
 func (c *Client) GetStats(token string) (*models.Stats, error) {
@@ -150,6 +257,9 @@ func (c *Client) UpdateDB(token string) error {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusUnauthorized {
+		return ErrUnauthorized
+	}
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
 		return fmt.Errorf("ошибка при обновлении базы данных: код %d", resp.StatusCode)
 	}
@@ -170,9 +280,36 @@ func (c *Client) DropDB(token string) error {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusUnauthorized {
+		return ErrUnauthorized
+	}
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("ошибка при очистке базы данных: код %d", resp.StatusCode)
 	}
 
 	return nil
 }
+
+func (c *Client) Image(id, source, size, etag string) (*http.Response, error) {
+	params := neturl.Values{}
+	if source != "" {
+		params.Set("source", source)
+	}
+	if size != "" {
+		params.Set("size", size)
+	}
+	url := fmt.Sprintf("%s/api/comics/%s/image", c.apiAddress, template.URLQueryEscaper(id))
+	if len(params) > 0 {
+		url += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	return c.httpClient.Do(req)
+}
@@ -1,21 +1,41 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"strconv"
+	"strings"
 
 	"yadro.com/course/frontend/internal/api"
 	"yadro.com/course/frontend/internal/models"
 	"yadro.com/course/frontend/internal/templates"
 )
 
+const (
+	tokenCookie   = "token"
+	refreshCookie = "refresh_token"
+	stateCookie   = "oidc_state"
+	// stateMaxAge matches how long the API waits for the callback
+	stateMaxAge = 600
+)
+
 type Handler struct {
+	log       *slog.Logger
 	apiClient *api.Client
+	oidc      bool
 }
 
-func NewHandler() *Handler {
+func NewHandler(log *slog.Logger) *Handler {
+	oidc, _ := strconv.ParseBool(os.Getenv("OIDC_ENABLED"))
 	return &Handler{
+		log:       log,
 		apiClient: api.NewClient(),
+		oidc:      oidc,
 	}
 }
 
@@ -53,7 +73,7 @@ func (h *Handler) SearchHandler(w http.ResponseWriter, r *http.Request) {
 		limit = "10"
 	}
 
-	comics, total, err := h.apiClient.Search(query, limit)
+	comics, total, err := h.apiClient.Search(query, limit, clientAddr(r))
 	if err != nil {
 		h.renderError(w, "Ошибка при выполнении поиска: "+err.Error(), query, limit)
 		return
@@ -77,71 +97,109 @@ func (h *Handler) SearchHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (h *Handler) ImageHandler(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/images/")
+	if id == "" || strings.Contains(id, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	resp, err := h.apiClient.Image(id, r.URL.Query().Get("source"), r.URL.Query().Get("size"), r.Header.Get("If-None-Match"))
+	if err != nil {
+		http.Error(w, "Ошибка при получении изображения", http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	for _, header := range []string{"Content-Type", "Content-Length", "ETag", "Cache-Control"} {
+		if value := resp.Header.Get(header); value != "" {
+			w.Header().Set(header, value)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+}
+
 func (h *Handler) LoginHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method == http.MethodPost {
 		username := r.FormValue("username")
 		password := r.FormValue("password")
 
-		token, err := h.apiClient.Login(username, password)
+		tokens, err := h.apiClient.Login(username, password, clientAddr(r))
 		if err != nil {
-			tmpl := templates.Get("login")
-			if tmpl == nil {
-				http.Error(w, "Шаблон не найден", http.StatusInternalServerError)
-				return
-			}
-
-			data := models.PageData{
-				Title: "Вход в систему",
-				Error: "Неверные учетные данные или ошибка сервера",
-			}
-			if err := tmpl.Execute(w, data); err != nil {
-				http.Error(w, "Ошибка при отображении шаблона: "+err.Error(), http.StatusInternalServerError)
-				return
-			}
+			h.renderLogin(w, "Неверные учетные данные или ошибка сервера")
 			return
 		}
 
-		http.SetCookie(w, &http.Cookie{
-			Name:     "token",
-			Value:    token,
-			Path:     "/",
-			HttpOnly: true,
-			Secure:   true,
-		})
-
+		setSession(w, tokens)
 		http.Redirect(w, r, "/admin", http.StatusSeeOther)
 		return
 	}
 
-	tmpl := templates.Get("login")
-	if tmpl == nil {
-		http.Error(w, "Шаблон не найден", http.StatusInternalServerError)
+	h.renderLogin(w, "")
+}
+
+// OIDCLoginHandler sends the user to the identity provider,
+// the state cookie ties the callback to this browser
+func (h *Handler) OIDCLoginHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.oidc {
+		http.NotFound(w, r)
 		return
 	}
 
-	data := models.PageData{
-		Title: "Вход в систему",
+	url, state, err := h.apiClient.OIDCAuthorize()
+	if err != nil {
+		h.renderLogin(w, err.Error())
+		return
 	}
-	if err := tmpl.Execute(w, data); err != nil {
-		http.Error(w, "Ошибка при отображении шаблона: "+err.Error(), http.StatusInternalServerError)
+
+	setCookie(w, stateCookie, state, stateMaxAge)
+	http.Redirect(w, r, url, http.StatusSeeOther)
+}
+
+func (h *Handler) OIDCCallbackHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.oidc {
+		http.NotFound(w, r)
+		return
+	}
+
+	query := r.URL.Query()
+	if query.Get("error") != "" {
+		h.renderLogin(w, "Вход через SSO отменён: "+query.Get("error"))
+		return
+	}
+
+	state, err := r.Cookie(stateCookie)
+	setCookie(w, stateCookie, "", -1)
+	if err != nil || state.Value == "" || state.Value != query.Get("state") {
+		h.renderLogin(w, "Вход через SSO устарел, попробуйте ещё раз")
+		return
+	}
+
+	tokens, err := h.apiClient.OIDCLogin(query.Get("code"), state.Value, clientAddr(r))
+	if err != nil {
+		h.renderLogin(w, err.Error())
 		return
 	}
+
+	setSession(w, tokens)
+	http.Redirect(w, r, "/admin", http.StatusSeeOther)
 }
 
 func (h *Handler) AdminHandler(w http.ResponseWriter, r *http.Request) {
-	token, err := r.Cookie("token")
-	if err != nil {
+	token, ok := h.session(w, r)
+	if !ok {
 		http.Redirect(w, r, "/login", http.StatusSeeOther)
 		return
 	}
 
-	stats, err := h.apiClient.GetStats(token.Value)
+	stats, err := h.apiClient.GetStats(token)
 	if err != nil {
 		h.renderError(w, "Ошибка при получении статистики: "+err.Error(), "", "")
 		return
 	}
 
-	status, err := h.apiClient.GetStatus(token.Value)
+	status, err := h.apiClient.GetStatus(token)
 	if err != nil {
 		h.renderError(w, "Ошибка при получении статуса: "+err.Error(), "", "")
 		return
@@ -172,20 +230,20 @@ func (h *Handler) UpdateHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := r.Cookie("token")
-	if err != nil {
+	token, ok := h.session(w, r)
+	if !ok {
 		http.Redirect(w, r, "/login", http.StatusSeeOther)
 		return
 	}
 
-	status, err := h.apiClient.GetStatus(token.Value)
+	status, err := h.apiClient.GetStatus(token)
 	if err != nil {
 		h.renderError(w, "Ошибка при получении статуса базы данных: "+err.Error(), "", "")
 		return
 	}
 
 	if status == "updating" || status == "running" {
-		stats, err := h.apiClient.GetStats(token.Value)
+		stats, err := h.apiClient.GetStats(token)
 		if err != nil {
 			h.renderError(w, "Ошибка при получении статистики: "+err.Error(), "", "")
 			return
@@ -211,7 +269,12 @@ func (h *Handler) UpdateHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.apiClient.UpdateDB(token.Value)
+	err = h.apiClient.UpdateDB(token)
+	if errors.Is(err, api.ErrUnauthorized) {
+		clearSession(w)
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
 	if err != nil {
 		h.renderError(w, "Ошибка при обновлении базы данных: "+err.Error(), "", "")
 		return
@@ -226,16 +289,21 @@ func (h *Handler) DropHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := r.Cookie("token")
-	if err != nil {
+	token, ok := h.session(w, r)
+	if !ok {
 		http.Redirect(w, r, "/login", http.StatusSeeOther)
 		return
 	}
 
-	err = h.apiClient.DropDB(token.Value)
+	err := h.apiClient.DropDB(token)
+	if errors.Is(err, api.ErrUnauthorized) {
+		clearSession(w)
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
 	if err != nil {
-		stats, statsErr := h.apiClient.GetStats(token.Value)
-		status, statusErr := h.apiClient.GetStatus(token.Value)
+		stats, statsErr := h.apiClient.GetStats(token)
+		status, statusErr := h.apiClient.GetStatus(token)
 
 		if statsErr != nil || statusErr != nil {
 			h.renderError(w, "Ошибка при очистке базы данных: "+err.Error(), "", "")
@@ -267,15 +335,72 @@ func (h *Handler) DropHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	if refresh, err := r.Cookie(refreshCookie); err == nil {
+		if err := h.apiClient.Logout(refresh.Value); err != nil {
+			h.log.Error("logout failed", "error", err)
+		}
+	}
+	clearSession(w)
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// session returns the access token, an expired one is refreshed.
+// Cookies live as long as their tokens, so a missing token cookie means it has expired.
+func (h *Handler) session(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if token, err := r.Cookie(tokenCookie); err == nil {
+		return token.Value, true
+	}
+
+	refresh, err := r.Cookie(refreshCookie)
+	if err != nil {
+		return "", false
+	}
+	tokens, err := h.apiClient.Refresh(refresh.Value)
+	if err != nil {
+		clearSession(w)
+		return "", false
+	}
+	setSession(w, tokens)
+	return tokens.AccessToken, true
+}
+
+func setSession(w http.ResponseWriter, tokens *models.Tokens) {
+	setCookie(w, tokenCookie, tokens.AccessToken, tokens.ExpiresIn)
+	setCookie(w, refreshCookie, tokens.RefreshToken, tokens.RefreshExpiresIn)
+}
+
+func clearSession(w http.ResponseWriter) {
+	setCookie(w, tokenCookie, "", -1)
+	setCookie(w, refreshCookie, "", -1)
+}
+
+func setCookie(w http.ResponseWriter, name, value string, maxAge int) {
 	http.SetCookie(w, &http.Cookie{
-		Name:     "token",
-		Value:    "",
+		Name:     name,
+		Value:    value,
 		Path:     "/",
 		HttpOnly: true,
 		Secure:   true,
-		MaxAge:   -1,
+		MaxAge:   maxAge,
 	})
-	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+func (h *Handler) renderLogin(w http.ResponseWriter, message string) {
+	tmpl := templates.Get("login")
+	if tmpl == nil {
+		http.Error(w, "Шаблон не найден", http.StatusInternalServerError)
+		return
+	}
+
+	data := models.PageData{
+		Title: "Вход в систему",
+		Error: message,
+		OIDC:  h.oidc,
+	}
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, "Ошибка при отображении шаблона: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
 }
 
 func (h *Handler) renderError(w http.ResponseWriter, message string, query string, limit string) {
@@ -296,3 +421,13 @@ func (h *Handler) renderError(w http.ResponseWriter, message string, query strin
 		return
 	}
 }
+
+// clientAddr is the browser address, the frontend faces clients directly
+// so forwarded headers from the request are not trusted
+func clientAddr(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
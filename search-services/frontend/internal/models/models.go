@@ -1,11 +1,13 @@
 package models
 
 type Comic struct {
-	ID      int64  `json:"id"`
-	URL     string `json:"url"`
-	Title   string
-	Image   string
-	PageURL string
+	ID       int64  `json:"id"`
+	Source   string `json:"source"`
+	URL      string `json:"url"`
+	Title    string
+	Image    string
+	Original string
+	PageURL  string
 }
 
 type PageData struct {
@@ -18,6 +20,7 @@ type PageData struct {
 	Status   string
 	Query    string
 	Limit    string
+	OIDC     bool
 }
 
 type Stats struct {
@@ -25,6 +28,23 @@ type Stats struct {
 	WordsUnique   int `json:"words_unique"`
 	ComicsFetched int `json:"comics_fetched"`
 	ComicsTotal   int `json:"comics_total"`
+	ComicsStale   int `json:"comics_stale"`
+}
+
+type Tokens struct {
+	AccessToken      string `json:"access_token"`
+	RefreshToken     string `json:"refresh_token"`
+	ExpiresIn        int    `json:"expires_in"`
+	RefreshExpiresIn int    `json:"refresh_expires_in"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type OIDCCallbackRequest struct {
+	Code  string `json:"code"`
+	State string `json:"state"`
 }
 
 type LoginRequest struct {
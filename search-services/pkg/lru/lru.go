@@ -0,0 +1,118 @@
+// Package lru is the least recently used cache shared by the services
+package lru
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Cache drops the least recently used entries over its size, entries older than ttl
+// are dropped on access. Zero ttl keeps entries until they are evicted.
+type Cache struct {
+	mu        sync.Mutex
+	size      int
+	ttl       time.Duration
+	items     map[string]*list.Element
+	order     *list.List
+	hits      int
+	misses    int
+	evictions int
+	now       func() time.Time
+}
+
+type Stats struct {
+	Hits      int
+	Misses    int
+	Evictions int
+	Size      int
+}
+
+type entry struct {
+	key     string
+	value   any
+	expires time.Time
+}
+
+func New(size int, ttl time.Duration) (*Cache, error) {
+	if size < 1 {
+		return nil, fmt.Errorf("wrong cache size specified: %d", size)
+	}
+	if ttl < 0 {
+		return nil, fmt.Errorf("wrong cache ttl specified: %v", ttl)
+	}
+	return &Cache{
+		size:  size,
+		ttl:   ttl,
+		items: make(map[string]*list.Element, size),
+		order: list.New(),
+		now:   time.Now,
+	}, nil
+}
+
+func (c *Cache) Get(key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.items[key]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	e := element.Value.(*entry)
+	if c.ttl > 0 && !c.now().Before(e.expires) {
+		c.remove(element)
+		c.evictions++
+		c.misses++
+		return nil, false
+	}
+
+	c.order.MoveToFront(element)
+	c.hits++
+	return e.value, true
+}
+
+func (c *Cache) Add(key string, value any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires := c.now().Add(c.ttl)
+	if element, ok := c.items[key]; ok {
+		e := element.Value.(*entry)
+		e.value, e.expires = value, expires
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&entry{key: key, value: value, expires: expires})
+	if c.order.Len() > c.size {
+		c.remove(c.order.Back())
+		c.evictions++
+	}
+}
+
+func (c *Cache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.items = make(map[string]*list.Element, c.size)
+	c.order.Init()
+}
+
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return Stats{
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+		Size:      c.order.Len(),
+	}
+}
+
+func (c *Cache) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.items, element.Value.(*entry).key)
+}
@@ -0,0 +1,77 @@
+package lru
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	_, err := New(0, time.Minute)
+	assert.Error(t, err)
+	_, err = New(1, -time.Minute)
+	assert.Error(t, err)
+	_, err = New(1, 0)
+	assert.NoError(t, err)
+}
+
+func TestLRU_Eviction(t *testing.T) {
+	c, err := New(2, 0)
+	require.NoError(t, err)
+
+	c.Add("a", 1)
+	c.Add("b", 2)
+	_, ok := c.Get("a")
+	assert.True(t, ok)
+
+	// b давно не использовался и вытесняется
+	c.Add("c", 3)
+	_, ok = c.Get("b")
+	assert.False(t, ok)
+
+	value, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+	value, ok = c.Get("c")
+	assert.True(t, ok)
+	assert.Equal(t, 3, value)
+
+	assert.Equal(t, Stats{Hits: 3, Misses: 1, Evictions: 1, Size: 2}, c.Stats())
+}
+
+func TestLRU_TTL(t *testing.T) {
+	c, err := New(10, time.Minute)
+	require.NoError(t, err)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+
+	c.Add("a", 1)
+	now = now.Add(30 * time.Second)
+	_, ok := c.Get("a")
+	assert.True(t, ok)
+
+	now = now.Add(30 * time.Second)
+	_, ok = c.Get("a")
+	assert.False(t, ok)
+
+	assert.Equal(t, Stats{Hits: 1, Misses: 1, Evictions: 1, Size: 0}, c.Stats())
+}
+
+func TestLRU_UpdateAndPurge(t *testing.T) {
+	c, err := New(10, 0)
+	require.NoError(t, err)
+
+	c.Add("a", 1)
+	c.Add("a", 2)
+	value, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 2, value)
+	assert.Equal(t, 1, c.Stats().Size)
+
+	c.Purge()
+	_, ok = c.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, c.Stats().Size)
+}
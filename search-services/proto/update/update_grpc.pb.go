@@ -21,11 +21,14 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	Update_Ping_FullMethodName   = "/update.Update/Ping"
-	Update_Status_FullMethodName = "/update.Update/Status"
-	Update_Update_FullMethodName = "/update.Update/Update"
-	Update_Stats_FullMethodName  = "/update.Update/Stats"
-	Update_Drop_FullMethodName   = "/update.Update/Drop"
+	Update_Ping_FullMethodName        = "/update.Update/Ping"
+	Update_Status_FullMethodName      = "/update.Update/Status"
+	Update_Update_FullMethodName      = "/update.Update/Update"
+	Update_Stats_FullMethodName       = "/update.Update/Stats"
+	Update_Drop_FullMethodName        = "/update.Update/Drop"
+	Update_Reindex_FullMethodName     = "/update.Update/Reindex"
+	Update_Renormalize_FullMethodName = "/update.Update/Renormalize"
+	Update_Image_FullMethodName       = "/update.Update/Image"
 )
 
 // UpdateClient is the client API for Update service.
@@ -37,6 +40,9 @@ type UpdateClient interface {
 	Update(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	Stats(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*StatsReply, error)
 	Drop(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	Reindex(ctx context.Context, in *ReindexRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	Renormalize(ctx context.Context, in *RenormalizeRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	Image(ctx context.Context, in *ImageRequest, opts ...grpc.CallOption) (*ImageReply, error)
 }
 
 type updateClient struct {
@@ -97,6 +103,36 @@ func (c *updateClient) Drop(ctx context.Context, in *emptypb.Empty, opts ...grpc
 	return out, nil
 }
 
+func (c *updateClient) Reindex(ctx context.Context, in *ReindexRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Update_Reindex_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *updateClient) Renormalize(ctx context.Context, in *RenormalizeRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Update_Renormalize_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *updateClient) Image(ctx context.Context, in *ImageRequest, opts ...grpc.CallOption) (*ImageReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ImageReply)
+	err := c.cc.Invoke(ctx, Update_Image_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // UpdateServer is the server API for Update service.
 // All implementations must embed UnimplementedUpdateServer
 // for forward compatibility.
@@ -106,6 +142,9 @@ type UpdateServer interface {
 	Update(context.Context, *emptypb.Empty) (*emptypb.Empty, error)
 	Stats(context.Context, *emptypb.Empty) (*StatsReply, error)
 	Drop(context.Context, *emptypb.Empty) (*emptypb.Empty, error)
+	Reindex(context.Context, *ReindexRequest) (*emptypb.Empty, error)
+	Renormalize(context.Context, *RenormalizeRequest) (*emptypb.Empty, error)
+	Image(context.Context, *ImageRequest) (*ImageReply, error)
 	mustEmbedUnimplementedUpdateServer()
 }
 
@@ -131,6 +170,15 @@ func (UnimplementedUpdateServer) Stats(context.Context, *emptypb.Empty) (*StatsR
 func (UnimplementedUpdateServer) Drop(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Drop not implemented")
 }
+func (UnimplementedUpdateServer) Reindex(context.Context, *ReindexRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Reindex not implemented")
+}
+func (UnimplementedUpdateServer) Renormalize(context.Context, *RenormalizeRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Renormalize not implemented")
+}
+func (UnimplementedUpdateServer) Image(context.Context, *ImageRequest) (*ImageReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Image not implemented")
+}
 func (UnimplementedUpdateServer) mustEmbedUnimplementedUpdateServer() {}
 func (UnimplementedUpdateServer) testEmbeddedByValue()                {}
 
@@ -242,6 +290,60 @@ func _Update_Drop_Handler(srv interface{}, ctx context.Context, dec func(interfa
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Update_Reindex_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReindexRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UpdateServer).Reindex(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Update_Reindex_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UpdateServer).Reindex(ctx, req.(*ReindexRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Update_Renormalize_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenormalizeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UpdateServer).Renormalize(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Update_Renormalize_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UpdateServer).Renormalize(ctx, req.(*RenormalizeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Update_Image_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ImageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UpdateServer).Image(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Update_Image_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UpdateServer).Image(ctx, req.(*ImageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Update_ServiceDesc is the grpc.ServiceDesc for Update service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -269,6 +371,18 @@ var Update_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Drop",
 			Handler:    _Update_Drop_Handler,
 		},
+		{
+			MethodName: "Reindex",
+			Handler:    _Update_Reindex_Handler,
+		},
+		{
+			MethodName: "Renormalize",
+			Handler:    _Update_Renormalize_Handler,
+		},
+		{
+			MethodName: "Image",
+			Handler:    _Update_Image_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/update/update.proto",
@@ -21,8 +21,14 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	Words_Ping_FullMethodName = "/words.Words/Ping"
-	Words_Norm_FullMethodName = "/words.Words/Norm"
+	Words_Ping_FullMethodName       = "/words.Words/Ping"
+	Words_Norm_FullMethodName       = "/words.Words/Norm"
+	Words_NormBatch_FullMethodName  = "/words.Words/NormBatch"
+	Words_NormStream_FullMethodName = "/words.Words/NormStream"
+	Words_NormQuery_FullMethodName  = "/words.Words/NormQuery"
+	Words_Tokenize_FullMethodName   = "/words.Words/Tokenize"
+	Words_Reload_FullMethodName     = "/words.Words/Reload"
+	Words_CacheStats_FullMethodName = "/words.Words/CacheStats"
 )
 
 // WordsClient is the client API for Words service.
@@ -31,6 +37,16 @@ const (
 type WordsClient interface {
 	Ping(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	Norm(ctx context.Context, in *WordsRequest, opts ...grpc.CallOption) (*WordsReply, error)
+	NormBatch(ctx context.Context, in *NormBatchRequest, opts ...grpc.CallOption) (*NormBatchReply, error)
+	NormStream(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[WordsRequest, NormBatchReply], error)
+	// normalizes a search query and expands it with weighted synonyms
+	NormQuery(ctx context.Context, in *WordsRequest, opts ...grpc.CallOption) (*QueryReply, error)
+	// ordered tokens with duplicates and stop words kept
+	Tokenize(ctx context.Context, in *WordsRequest, opts ...grpc.CallOption) (*TokenizeReply, error)
+	// rereads stop-word and protected-word files
+	Reload(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// normalization cache counters, the cache is purged on Reload
+	CacheStats(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*CacheStatsReply, error)
 }
 
 type wordsClient struct {
@@ -61,12 +77,85 @@ func (c *wordsClient) Norm(ctx context.Context, in *WordsRequest, opts ...grpc.C
 	return out, nil
 }
 
+func (c *wordsClient) NormBatch(ctx context.Context, in *NormBatchRequest, opts ...grpc.CallOption) (*NormBatchReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(NormBatchReply)
+	err := c.cc.Invoke(ctx, Words_NormBatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *wordsClient) NormStream(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[WordsRequest, NormBatchReply], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Words_ServiceDesc.Streams[0], Words_NormStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WordsRequest, NormBatchReply]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Words_NormStreamClient = grpc.ClientStreamingClient[WordsRequest, NormBatchReply]
+
+func (c *wordsClient) NormQuery(ctx context.Context, in *WordsRequest, opts ...grpc.CallOption) (*QueryReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(QueryReply)
+	err := c.cc.Invoke(ctx, Words_NormQuery_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *wordsClient) Tokenize(ctx context.Context, in *WordsRequest, opts ...grpc.CallOption) (*TokenizeReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TokenizeReply)
+	err := c.cc.Invoke(ctx, Words_Tokenize_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *wordsClient) Reload(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Words_Reload_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *wordsClient) CacheStats(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*CacheStatsReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CacheStatsReply)
+	err := c.cc.Invoke(ctx, Words_CacheStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // WordsServer is the server API for Words service.
 // All implementations must embed UnimplementedWordsServer
 // for forward compatibility.
 type WordsServer interface {
 	Ping(context.Context, *emptypb.Empty) (*emptypb.Empty, error)
 	Norm(context.Context, *WordsRequest) (*WordsReply, error)
+	NormBatch(context.Context, *NormBatchRequest) (*NormBatchReply, error)
+	NormStream(grpc.ClientStreamingServer[WordsRequest, NormBatchReply]) error
+	// normalizes a search query and expands it with weighted synonyms
+	NormQuery(context.Context, *WordsRequest) (*QueryReply, error)
+	// ordered tokens with duplicates and stop words kept
+	Tokenize(context.Context, *WordsRequest) (*TokenizeReply, error)
+	// rereads stop-word and protected-word files
+	Reload(context.Context, *emptypb.Empty) (*emptypb.Empty, error)
+	// normalization cache counters, the cache is purged on Reload
+	CacheStats(context.Context, *emptypb.Empty) (*CacheStatsReply, error)
 	mustEmbedUnimplementedWordsServer()
 }
 
@@ -83,6 +172,24 @@ func (UnimplementedWordsServer) Ping(context.Context, *emptypb.Empty) (*emptypb.
 func (UnimplementedWordsServer) Norm(context.Context, *WordsRequest) (*WordsReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Norm not implemented")
 }
+func (UnimplementedWordsServer) NormBatch(context.Context, *NormBatchRequest) (*NormBatchReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method NormBatch not implemented")
+}
+func (UnimplementedWordsServer) NormStream(grpc.ClientStreamingServer[WordsRequest, NormBatchReply]) error {
+	return status.Errorf(codes.Unimplemented, "method NormStream not implemented")
+}
+func (UnimplementedWordsServer) NormQuery(context.Context, *WordsRequest) (*QueryReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method NormQuery not implemented")
+}
+func (UnimplementedWordsServer) Tokenize(context.Context, *WordsRequest) (*TokenizeReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Tokenize not implemented")
+}
+func (UnimplementedWordsServer) Reload(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Reload not implemented")
+}
+func (UnimplementedWordsServer) CacheStats(context.Context, *emptypb.Empty) (*CacheStatsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CacheStats not implemented")
+}
 func (UnimplementedWordsServer) mustEmbedUnimplementedWordsServer() {}
 func (UnimplementedWordsServer) testEmbeddedByValue()               {}
 
@@ -140,6 +247,103 @@ func _Words_Norm_Handler(srv interface{}, ctx context.Context, dec func(interfac
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Words_NormBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NormBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WordsServer).NormBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Words_NormBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WordsServer).NormBatch(ctx, req.(*NormBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Words_NormStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(WordsServer).NormStream(&grpc.GenericServerStream[WordsRequest, NormBatchReply]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Words_NormStreamServer = grpc.ClientStreamingServer[WordsRequest, NormBatchReply]
+
+func _Words_NormQuery_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WordsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WordsServer).NormQuery(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Words_NormQuery_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WordsServer).NormQuery(ctx, req.(*WordsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Words_Tokenize_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WordsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WordsServer).Tokenize(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Words_Tokenize_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WordsServer).Tokenize(ctx, req.(*WordsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Words_Reload_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WordsServer).Reload(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Words_Reload_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WordsServer).Reload(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Words_CacheStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WordsServer).CacheStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Words_CacheStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WordsServer).CacheStats(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Words_ServiceDesc is the grpc.ServiceDesc for Words service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -155,7 +359,33 @@ var Words_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Norm",
 			Handler:    _Words_Norm_Handler,
 		},
+		{
+			MethodName: "NormBatch",
+			Handler:    _Words_NormBatch_Handler,
+		},
+		{
+			MethodName: "NormQuery",
+			Handler:    _Words_NormQuery_Handler,
+		},
+		{
+			MethodName: "Tokenize",
+			Handler:    _Words_Tokenize_Handler,
+		},
+		{
+			MethodName: "Reload",
+			Handler:    _Words_Reload_Handler,
+		},
+		{
+			MethodName: "CacheStats",
+			Handler:    _Words_CacheStats_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "NormStream",
+			Handler:       _Words_NormStream_Handler,
+			ClientStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/words/words.proto",
 }
@@ -27,26 +27,28 @@ func New(log *slog.Logger, address string) (*DB, error) {
 	}, nil
 }
 
-func (db *DB) Get(ctx context.Context, id int) (core.Comics, error) {
+func (db *DB) Get(ctx context.Context, key core.ComicKey) (core.Comics, error) {
 	var comic core.Comics
-	err := db.conn.GetContext(ctx, &comic, "SELECT comic_id as id, url, description FROM comics WHERE comic_id = $1", id)
+	err := db.conn.GetContext(ctx, &comic,
+		"SELECT comic_id as id, source, url, description FROM comics WHERE source = $1 AND comic_id = $2",
+		key.Source, key.ID)
 	if err != nil {
 		return core.Comics{}, core.ErrNotFound
 	}
 
-	db.log.Debug("comics", "id", id)
+	db.log.Debug("comics", "source", key.Source, "id", key.ID)
 
 	return comic, nil
 }
 
-func (db *DB) IDs(ctx context.Context) ([]int, error) {
-	var ids []int
-	err := db.conn.SelectContext(ctx, &ids, "SELECT comic_id FROM comics")
+func (db *DB) Keys(ctx context.Context) ([]core.ComicKey, error) {
+	var keys []core.ComicKey
+	err := db.conn.SelectContext(ctx, &keys, "SELECT source, comic_id as id FROM comics")
 	if err != nil {
 		return nil, core.ErrNotFound
 	}
 
-	db.log.Debug("comics ids", "ids", ids)
+	db.log.Debug("comics keys", "count", len(keys))
 
-	return ids, nil
+	return keys, nil
 }
@@ -2,8 +2,11 @@ package grpc
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
 	searchpb "yadro.com/course/proto/search"
 	"yadro.com/course/search/core"
@@ -24,16 +27,17 @@ func (s *Server) Ping(_ context.Context, _ *emptypb.Empty) (*emptypb.Empty, erro
 }
 
 func (s *Server) Search(ctx context.Context, req *searchpb.SearchRequest) (*searchpb.ComicsResponse, error) {
-	comics, total, err := s.service.Search(ctx, req.Query, int(req.Limit))
+	comics, total, err := s.service.Search(ctx, req.Query, int(req.Limit), req.Source)
 	if err != nil {
-		return nil, err
+		return nil, statusError(err)
 	}
 
 	pbComics := make([]*searchpb.Comics, len(comics))
 	for i, c := range comics {
 		pbComics[i] = &searchpb.Comics{
-			Id:  int64(c.ID),
-			Url: c.URL,
+			Id:     int64(c.ID),
+			Url:    c.URL,
+			Source: c.Source,
 		}
 	}
 
@@ -44,16 +48,17 @@ func (s *Server) Search(ctx context.Context, req *searchpb.SearchRequest) (*sear
 }
 
 func (s *Server) ISearch(ctx context.Context, req *searchpb.ISearchRequest) (*searchpb.ComicsResponse, error) {
-	comics, total, err := s.service.ISearch(ctx, req.Query, int(req.Limit))
+	comics, total, err := s.service.ISearch(ctx, req.Query, int(req.Limit), req.Source)
 	if err != nil {
-		return nil, err
+		return nil, statusError(err)
 	}
 
 	pbComics := make([]*searchpb.Comics, len(comics))
 	for i, c := range comics {
 		pbComics[i] = &searchpb.Comics{
-			Id:  int64(c.ID),
-			Url: c.URL,
+			Id:     int64(c.ID),
+			Url:    c.URL,
+			Source: c.Source,
 		}
 	}
 
@@ -62,3 +67,19 @@ func (s *Server) ISearch(ctx context.Context, req *searchpb.ISearchRequest) (*se
 		Total: int64(total),
 	}, nil
 }
+
+// statusError gives core errors their grpc codes, errors from the words service keep theirs
+func statusError(err error) error {
+	switch {
+	case errors.Is(err, core.ErrBadArguments):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, core.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	}
+	if st, ok := status.FromError(err); ok {
+		return status.Error(st.Code(), err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
@@ -3,25 +3,28 @@ package grpc
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
 	searchpb "yadro.com/course/proto/search"
 	"yadro.com/course/search/core"
 )
 
 type mockSearcher struct {
-	searchFunc  func(ctx context.Context, query string, limit int) ([]core.Comics, int, error)
-	isearchFunc func(ctx context.Context, query string, limit int) ([]core.Comics, int, error)
+	searchFunc  func(ctx context.Context, query string, limit int, source string) ([]core.Comics, int, error)
+	isearchFunc func(ctx context.Context, query string, limit int, source string) ([]core.Comics, int, error)
 }
 
-func (m *mockSearcher) Search(ctx context.Context, query string, limit int) ([]core.Comics, int, error) {
-	return m.searchFunc(ctx, query, limit)
+func (m *mockSearcher) Search(ctx context.Context, query string, limit int, source string) ([]core.Comics, int, error) {
+	return m.searchFunc(ctx, query, limit, source)
 }
 
-func (m *mockSearcher) ISearch(ctx context.Context, query string, limit int) ([]core.Comics, int, error) {
-	return m.isearchFunc(ctx, query, limit)
+func (m *mockSearcher) ISearch(ctx context.Context, query string, limit int, source string) ([]core.Comics, int, error) {
+	return m.isearchFunc(ctx, query, limit, source)
 }
 
 func TestServer_Ping(t *testing.T) {
@@ -38,7 +41,7 @@ func TestServer_Search(t *testing.T) {
 		name           string
 		query          string
 		limit          int64
-		searchFunc     func(ctx context.Context, query string, limit int) ([]core.Comics, int, error)
+		searchFunc     func(ctx context.Context, query string, limit int, source string) ([]core.Comics, int, error)
 		expectedComics []*searchpb.Comics
 		expectedTotal  int64
 		expectedErr    error
@@ -47,10 +50,12 @@ func TestServer_Search(t *testing.T) {
 			name:  "successful search",
 			query: "test query",
 			limit: 2,
-			searchFunc: func(ctx context.Context, query string, limit int) ([]core.Comics, int, error) {
+			searchFunc: func(ctx context.Context, query string, limit int, source string) ([]core.Comics, int, error) {
+				assert.Equal(t, "xkcd", source)
 				return []core.Comics{
 					{
 						ID:          1,
+						Source:      "xkcd",
 						URL:         "http://example.com/1",
 						Description: "test description 1",
 					},
@@ -63,8 +68,9 @@ func TestServer_Search(t *testing.T) {
 			},
 			expectedComics: []*searchpb.Comics{
 				{
-					Id:  1,
-					Url: "http://example.com/1",
+					Id:     1,
+					Url:    "http://example.com/1",
+					Source: "xkcd",
 				},
 				{
 					Id:  2,
@@ -78,7 +84,7 @@ func TestServer_Search(t *testing.T) {
 			name:  "search error",
 			query: "test query",
 			limit: 2,
-			searchFunc: func(ctx context.Context, query string, limit int) ([]core.Comics, int, error) {
+			searchFunc: func(ctx context.Context, query string, limit int, source string) ([]core.Comics, int, error) {
 				return nil, 0, errors.New("search error")
 			},
 			expectedComics: nil,
@@ -94,8 +100,9 @@ func TestServer_Search(t *testing.T) {
 			})
 
 			resp, err := server.Search(context.Background(), &searchpb.SearchRequest{
-				Query: tt.query,
-				Limit: tt.limit,
+				Query:  tt.query,
+				Limit:  tt.limit,
+				Source: "xkcd",
 			})
 
 			if tt.expectedErr != nil {
@@ -115,7 +122,7 @@ func TestServer_ISearch(t *testing.T) {
 		name           string
 		query          string
 		limit          int64
-		isearchFunc    func(ctx context.Context, query string, limit int) ([]core.Comics, int, error)
+		isearchFunc    func(ctx context.Context, query string, limit int, source string) ([]core.Comics, int, error)
 		expectedComics []*searchpb.Comics
 		expectedTotal  int64
 		expectedErr    error
@@ -124,7 +131,7 @@ func TestServer_ISearch(t *testing.T) {
 			name:  "successful isearch",
 			query: "test query",
 			limit: 2,
-			isearchFunc: func(ctx context.Context, query string, limit int) ([]core.Comics, int, error) {
+			isearchFunc: func(ctx context.Context, query string, limit int, source string) ([]core.Comics, int, error) {
 				return []core.Comics{
 					{
 						ID:          1,
@@ -155,7 +162,7 @@ func TestServer_ISearch(t *testing.T) {
 			name:  "isearch error",
 			query: "test query",
 			limit: 2,
-			isearchFunc: func(ctx context.Context, query string, limit int) ([]core.Comics, int, error) {
+			isearchFunc: func(ctx context.Context, query string, limit int, source string) ([]core.Comics, int, error) {
 				return nil, 0, errors.New("isearch error")
 			},
 			expectedComics: nil,
@@ -186,3 +193,36 @@ func TestServer_ISearch(t *testing.T) {
 		})
 	}
 }
+
+func TestStatusError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code codes.Code
+	}{
+		{name: "bad arguments", err: core.ErrBadArguments, code: codes.InvalidArgument},
+		{name: "not found", err: fmt.Errorf("failed to get comic: %w", core.ErrNotFound), code: codes.NotFound},
+		{name: "deadline", err: fmt.Errorf("failed to get ids: %w", context.DeadlineExceeded), code: codes.DeadlineExceeded},
+		{name: "canceled", err: context.Canceled, code: codes.Canceled},
+		{
+			name: "words unavailable",
+			err:  fmt.Errorf("failed to normalize words: %w", status.Error(codes.Unavailable, "no words")),
+			code: codes.Unavailable,
+		},
+		{name: "unknown", err: errors.New("db error"), code: codes.Internal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := NewServer(&mockSearcher{
+				searchFunc: func(ctx context.Context, query string, limit int, source string) ([]core.Comics, int, error) {
+					return nil, 0, tt.err
+				},
+			})
+
+			_, err := server.Search(context.Background(), &searchpb.SearchRequest{Query: "query"})
+			assert.Equal(t, tt.code, status.Code(err))
+			assert.Contains(t, err.Error(), tt.err.Error())
+		})
+	}
+}
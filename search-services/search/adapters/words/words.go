@@ -8,6 +8,7 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 	wordspb "yadro.com/course/proto/words"
+	"yadro.com/course/search/core"
 )
 
 type Client struct {
@@ -26,14 +27,19 @@ func NewClient(address string, log *slog.Logger) (*Client, error) {
 	}, nil
 }
 
-func (c Client) Norm(ctx context.Context, phrase string) ([]string, error) {
-	response, err := c.client.Norm(ctx, &wordspb.WordsRequest{Phrase: phrase})
+func (c Client) NormQuery(ctx context.Context, phrase string) ([]core.Term, error) {
+	response, err := c.client.NormQuery(ctx, &wordspb.WordsRequest{Phrase: phrase})
 	if err != nil {
 		c.log.Error("failed to normalize words", "error", err)
 		return nil, fmt.Errorf("failed to normalize words: %w", err)
 	}
 
-	c.log.Debug("words normalized", "words", response.Words)
+	terms := make([]core.Term, 0, len(response.Terms))
+	for _, term := range response.Terms {
+		terms = append(terms, core.Term{Word: term.Word, Weight: term.Weight})
+	}
+
+	c.log.Debug("query normalized", "terms", terms)
 
-	return response.Words, nil
+	return terms, nil
 }
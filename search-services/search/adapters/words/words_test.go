@@ -11,14 +11,15 @@ import (
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
 	wordspb "yadro.com/course/proto/words"
+	"yadro.com/course/search/core"
 )
 
 type mockWordsClient struct {
 	wordspb.WordsClient
-	normFunc func(ctx context.Context, req *wordspb.WordsRequest, opts ...grpc.CallOption) (*wordspb.WordsReply, error)
+	normFunc func(ctx context.Context, req *wordspb.WordsRequest, opts ...grpc.CallOption) (*wordspb.QueryReply, error)
 }
 
-func (m *mockWordsClient) Norm(ctx context.Context, req *wordspb.WordsRequest, opts ...grpc.CallOption) (*wordspb.WordsReply, error) {
+func (m *mockWordsClient) NormQuery(ctx context.Context, req *wordspb.WordsRequest, opts ...grpc.CallOption) (*wordspb.QueryReply, error) {
 	return m.normFunc(ctx, req, opts...)
 }
 
@@ -60,40 +61,40 @@ func TestNewClient(t *testing.T) {
 	}
 }
 
-func TestClient_Norm(t *testing.T) {
+func TestClient_NormQuery(t *testing.T) {
 	tests := []struct {
 		name        string
 		phrase      string
-		normFunc    func(ctx context.Context, req *wordspb.WordsRequest, opts ...grpc.CallOption) (*wordspb.WordsReply, error)
-		expected    []string
+		normFunc    func(ctx context.Context, req *wordspb.WordsRequest, opts ...grpc.CallOption) (*wordspb.QueryReply, error)
+		expected    []core.Term
 		expectedErr error
 	}{
 		{
 			name:   "successful normalization",
 			phrase: "test phrase",
-			normFunc: func(ctx context.Context, req *wordspb.WordsRequest, opts ...grpc.CallOption) (*wordspb.WordsReply, error) {
-				return &wordspb.WordsReply{
-					Words: []string{"test", "phrase"},
+			normFunc: func(ctx context.Context, req *wordspb.WordsRequest, opts ...grpc.CallOption) (*wordspb.QueryReply, error) {
+				return &wordspb.QueryReply{
+					Terms: []*wordspb.Term{{Word: "test", Weight: 1}, {Word: "exam", Weight: 0.5}},
 				}, nil
 			},
-			expected:    []string{"test", "phrase"},
+			expected:    []core.Term{{Word: "test", Weight: 1}, {Word: "exam", Weight: 0.5}},
 			expectedErr: nil,
 		},
 		{
 			name:   "empty response",
 			phrase: "test phrase",
-			normFunc: func(ctx context.Context, req *wordspb.WordsRequest, opts ...grpc.CallOption) (*wordspb.WordsReply, error) {
-				return &wordspb.WordsReply{
-					Words: []string{},
+			normFunc: func(ctx context.Context, req *wordspb.WordsRequest, opts ...grpc.CallOption) (*wordspb.QueryReply, error) {
+				return &wordspb.QueryReply{
+					Terms: []*wordspb.Term{},
 				}, nil
 			},
-			expected:    []string{},
+			expected:    []core.Term{},
 			expectedErr: nil,
 		},
 		{
 			name:   "grpc error",
 			phrase: "test phrase",
-			normFunc: func(ctx context.Context, req *wordspb.WordsRequest, opts ...grpc.CallOption) (*wordspb.WordsReply, error) {
+			normFunc: func(ctx context.Context, req *wordspb.WordsRequest, opts ...grpc.CallOption) (*wordspb.QueryReply, error) {
 				return nil, errors.New("grpc error")
 			},
 			expected:    nil,
@@ -110,7 +111,7 @@ func TestClient_Norm(t *testing.T) {
 				},
 			}
 
-			words, err := client.Norm(context.Background(), tt.phrase)
+			words, err := client.NormQuery(context.Background(), tt.phrase)
 
 			if tt.expectedErr != nil {
 				assert.Error(t, err)
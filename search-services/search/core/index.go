@@ -11,7 +11,7 @@ type Index struct {
 	log     *slog.Logger
 	db      DB
 	mu      *sync.RWMutex
-	entries map[string][]int
+	entries map[string][]ComicKey
 }
 
 func NewIndex(log *slog.Logger, db DB) *Index {
@@ -19,11 +19,11 @@ func NewIndex(log *slog.Logger, db DB) *Index {
 		log:     log,
 		db:      db,
 		mu:      &sync.RWMutex{},
-		entries: make(map[string][]int),
+		entries: make(map[string][]ComicKey),
 	}
 }
 
-func (i *Index) Search(word string) []int {
+func (i *Index) Search(word string) []ComicKey {
 	i.mu.RLock()
 	defer i.mu.RUnlock()
 	return i.entries[word]
@@ -32,26 +32,26 @@ func (i *Index) Search(word string) []int {
 func (i *Index) UpdateIndex(ctx context.Context) {
 	i.mu.Lock()
 	defer i.mu.Unlock()
-	i.entries = make(map[string][]int)
-	ids, err := i.db.IDs(ctx)
+	i.entries = make(map[string][]ComicKey)
+	keys, err := i.db.Keys(ctx)
 	if err != nil {
 		i.log.Error("failed to get ids", "error", err)
 		return
 	}
 
-	for _, id := range ids {
-		comics, err := i.db.Get(ctx, id)
+	for _, key := range keys {
+		comics, err := i.db.Get(ctx, key)
 		if err != nil {
 			i.log.Error("failed to get comics", "error", err)
 			continue
 		}
 		words := strings.Split(comics.Description, " ")
-		i.add(words, id)
+		i.add(words, key)
 	}
 }
 
-func (i *Index) add(phrase []string, comicsID int) {
+func (i *Index) add(phrase []string, key ComicKey) {
 	for _, word := range phrase {
-		i.entries[word] = append(i.entries[word], comicsID)
+		i.entries[word] = append(i.entries[word], key)
 	}
 }
@@ -2,6 +2,19 @@ package core
 
 type Comics struct {
 	ID          int
+	Source      string
 	URL         string
 	Description string
 }
+
+// Term is a normalized query word with its weight in scoring
+type Term struct {
+	Word   string
+	Weight float64
+}
+
+// ComicKey identifies a comic, ids are only unique within a source.
+type ComicKey struct {
+	Source string
+	ID     int
+}
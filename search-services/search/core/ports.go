@@ -3,17 +3,17 @@ package core
 import "context"
 
 type Searcher interface {
-	Search(ctx context.Context, query string, limit int) ([]Comics, int, error)
-	ISearch(ctx context.Context, query string, limit int) ([]Comics, int, error)
+	Search(ctx context.Context, query string, limit int, source string) ([]Comics, int, error)
+	ISearch(ctx context.Context, query string, limit int, source string) ([]Comics, int, error)
 }
 
 type Words interface {
-	Norm(ctx context.Context, phrase string) ([]string, error)
+	NormQuery(ctx context.Context, phrase string) ([]Term, error)
 }
 
 type DB interface {
-	Get(ctx context.Context, id int) (Comics, error)
-	IDs(context.Context) ([]int, error)
+	Get(ctx context.Context, key ComicKey) (Comics, error)
+	Keys(context.Context) ([]ComicKey, error)
 }
 
 type UpdateIndex interface {
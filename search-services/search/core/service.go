@@ -24,41 +24,44 @@ func NewService(log *slog.Logger, db DB, words Words, idx *Index) *Service {
 	}
 }
 
-func (s *Service) Search(ctx context.Context, query string, limit int) ([]Comics, int, error) {
+func (s *Service) Search(ctx context.Context, query string, limit int, source string) ([]Comics, int, error) {
 	if limit <= 0 {
 		return nil, 0, ErrBadArguments
 	}
 
-	normQuery, err := s.words.Norm(ctx, query)
+	terms, err := s.words.NormQuery(ctx, query)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to normalize words: %w", err)
 	}
 
-	comicIDToHits := make(map[int]int)
-	ids, err := s.db.IDs(ctx)
+	comicIDToHits := make(map[ComicKey]float64)
+	keys, err := s.db.Keys(ctx)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to get ids: %w", err)
 	}
 
-	for _, id := range ids {
-		comics, err := s.db.Get(ctx, id)
+	for _, key := range keys {
+		if source != "" && key.Source != source {
+			continue
+		}
+		comics, err := s.db.Get(ctx, key)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to get comic: %w", err)
 		}
-		for _, word := range normQuery {
-			if strings.Contains(comics.Description, word) {
-				comicIDToHits[id]++
+		for _, term := range terms {
+			if strings.Contains(comics.Description, term.Word) {
+				comicIDToHits[key] += term.Weight
 			}
 		}
 	}
 
 	type comicScore struct {
-		ID    int
-		Score int
+		Key   ComicKey
+		Score float64
 	}
 	var scoredComics []comicScore
-	for id, score := range comicIDToHits {
-		scoredComics = append(scoredComics, comicScore{id, score})
+	for key, score := range comicIDToHits {
+		scoredComics = append(scoredComics, comicScore{key, score})
 	}
 	sort.Slice(scoredComics, func(i, j int) bool {
 		return scoredComics[i].Score > scoredComics[j].Score
@@ -68,9 +71,10 @@ func (s *Service) Search(ctx context.Context, query string, limit int) ([]Comics
 
 	resultComics := make([]Comics, 0, limit)
 	for i := 0; i < len(scoredComics) && i < limit; i++ {
-		comics, err := s.db.Get(ctx, scoredComics[i].ID)
+		key := scoredComics[i].Key
+		comics, err := s.db.Get(ctx, key)
 		if err != nil {
-			return nil, 0, fmt.Errorf("failed to fetch comic %d: %v", scoredComics[i].ID, err)
+			return nil, 0, fmt.Errorf("failed to fetch comic %s/%d: %v", key.Source, key.ID, err)
 		}
 		resultComics = append(resultComics, comics)
 	}
@@ -80,33 +84,35 @@ func (s *Service) Search(ctx context.Context, query string, limit int) ([]Comics
 	return resultComics, len(resultComics), nil
 }
 
-func (s *Service) ISearch(ctx context.Context, query string, limit int) ([]Comics, int, error) {
+func (s *Service) ISearch(ctx context.Context, query string, limit int, source string) ([]Comics, int, error) {
 	if limit <= 0 {
 		return nil, 0, ErrBadArguments
 	}
 
-	uniqueWords, err := s.words.Norm(ctx, query)
+	terms, err := s.words.NormQuery(ctx, query)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to normalize words: %w", err)
 	}
 
-	comicIDToHits := make(map[int]int)
-	for _, word := range uniqueWords {
-		comicIDs := s.index.Search(word)
-		for _, id := range comicIDs {
-			comicIDToHits[id]++
+	comicIDToHits := make(map[ComicKey]float64)
+	for _, term := range terms {
+		for _, key := range s.index.Search(term.Word) {
+			if source != "" && key.Source != source {
+				continue
+			}
+			comicIDToHits[key] += term.Weight
 		}
 	}
 
 	s.log.Debug("comicIDToHits", "comicIDToHits", comicIDToHits)
 
 	type comicScore struct {
-		ID    int
-		Score int
+		Key   ComicKey
+		Score float64
 	}
 	var scoredComics []comicScore
-	for id, score := range comicIDToHits {
-		scoredComics = append(scoredComics, comicScore{id, score})
+	for key, score := range comicIDToHits {
+		scoredComics = append(scoredComics, comicScore{key, score})
 	}
 	sort.Slice(scoredComics, func(i, j int) bool {
 		return scoredComics[i].Score > scoredComics[j].Score
@@ -116,9 +122,10 @@ func (s *Service) ISearch(ctx context.Context, query string, limit int) ([]Comic
 
 	resultComics := make([]Comics, 0, limit)
 	for i := 0; i < len(scoredComics) && i < limit; i++ {
-		comics, err := s.db.Get(ctx, scoredComics[i].ID)
+		key := scoredComics[i].Key
+		comics, err := s.db.Get(ctx, key)
 		if err != nil {
-			return nil, 0, fmt.Errorf("failed to fetch comic %d: %v", scoredComics[i].ID, err)
+			return nil, 0, fmt.Errorf("failed to fetch comic %s/%d: %v", key.Source, key.ID, err)
 		}
 		resultComics = append(resultComics, comics)
 	}
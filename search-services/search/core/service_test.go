@@ -11,24 +11,37 @@ import (
 )
 
 type mockWords struct {
-	normFunc func(ctx context.Context, phrase string) ([]string, error)
+	normFunc  func(ctx context.Context, phrase string) ([]string, error)
+	termsFunc func(ctx context.Context, phrase string) ([]Term, error)
 }
 
-func (m *mockWords) Norm(ctx context.Context, phrase string) ([]string, error) {
-	return m.normFunc(ctx, phrase)
+// NormQuery gives every word from normFunc weight 1 unless termsFunc is set
+func (m *mockWords) NormQuery(ctx context.Context, phrase string) ([]Term, error) {
+	if m.termsFunc != nil {
+		return m.termsFunc(ctx, phrase)
+	}
+	words, err := m.normFunc(ctx, phrase)
+	if err != nil {
+		return nil, err
+	}
+	terms := make([]Term, 0, len(words))
+	for _, word := range words {
+		terms = append(terms, Term{Word: word, Weight: 1})
+	}
+	return terms, nil
 }
 
 type mockDB struct {
-	getFunc func(ctx context.Context, id int) (Comics, error)
-	idsFunc func(ctx context.Context) ([]int, error)
+	getFunc  func(ctx context.Context, key ComicKey) (Comics, error)
+	keysFunc func(ctx context.Context) ([]ComicKey, error)
 }
 
-func (m *mockDB) Get(ctx context.Context, id int) (Comics, error) {
-	return m.getFunc(ctx, id)
+func (m *mockDB) Get(ctx context.Context, key ComicKey) (Comics, error) {
+	return m.getFunc(ctx, key)
 }
 
-func (m *mockDB) IDs(ctx context.Context) ([]int, error) {
-	return m.idsFunc(ctx)
+func (m *mockDB) Keys(ctx context.Context) ([]ComicKey, error) {
+	return m.keysFunc(ctx)
 }
 
 func newTestLogger() *slog.Logger {
@@ -41,8 +54,8 @@ func TestService_Search(t *testing.T) {
 		query          string
 		limit          int
 		normFunc       func(ctx context.Context, phrase string) ([]string, error)
-		getFunc        func(ctx context.Context, id int) (Comics, error)
-		idsFunc        func(ctx context.Context) ([]int, error)
+		getFunc        func(ctx context.Context, key ComicKey) (Comics, error)
+		keysFunc       func(ctx context.Context) ([]ComicKey, error)
 		expectedComics []Comics
 		expectedCount  int
 		expectedErr    error
@@ -54,15 +67,15 @@ func TestService_Search(t *testing.T) {
 			normFunc: func(ctx context.Context, phrase string) ([]string, error) {
 				return []string{"test", "query"}, nil
 			},
-			getFunc: func(ctx context.Context, id int) (Comics, error) {
+			getFunc: func(ctx context.Context, key ComicKey) (Comics, error) {
 				return Comics{
-					ID:          id,
+					ID:          key.ID,
 					URL:         "http://example.com",
 					Description: "test query description",
 				}, nil
 			},
-			idsFunc: func(ctx context.Context) ([]int, error) {
-				return []int{2, 3}, nil
+			keysFunc: func(ctx context.Context) ([]ComicKey, error) {
+				return []ComicKey{{Source: "xkcd", ID: 2}, {Source: "xkcd", ID: 3}}, nil
 			},
 			expectedComics: []Comics{
 				{
@@ -86,11 +99,11 @@ func TestService_Search(t *testing.T) {
 			normFunc: func(ctx context.Context, phrase string) ([]string, error) {
 				return []string{"test", "query"}, nil
 			},
-			getFunc: func(ctx context.Context, id int) (Comics, error) {
+			getFunc: func(ctx context.Context, key ComicKey) (Comics, error) {
 				return Comics{}, nil
 			},
-			idsFunc: func(ctx context.Context) ([]int, error) {
-				return []int{}, nil
+			keysFunc: func(ctx context.Context) ([]ComicKey, error) {
+				return []ComicKey{}, nil
 			},
 			expectedComics: nil,
 			expectedCount:  0,
@@ -103,11 +116,11 @@ func TestService_Search(t *testing.T) {
 			normFunc: func(ctx context.Context, phrase string) ([]string, error) {
 				return nil, errors.New("normalization error")
 			},
-			getFunc: func(ctx context.Context, id int) (Comics, error) {
+			getFunc: func(ctx context.Context, key ComicKey) (Comics, error) {
 				return Comics{}, nil
 			},
-			idsFunc: func(ctx context.Context) ([]int, error) {
-				return []int{}, nil
+			keysFunc: func(ctx context.Context) ([]ComicKey, error) {
+				return []ComicKey{}, nil
 			},
 			expectedComics: nil,
 			expectedCount:  0,
@@ -120,10 +133,10 @@ func TestService_Search(t *testing.T) {
 			normFunc: func(ctx context.Context, phrase string) ([]string, error) {
 				return []string{"test", "query"}, nil
 			},
-			getFunc: func(ctx context.Context, id int) (Comics, error) {
+			getFunc: func(ctx context.Context, key ComicKey) (Comics, error) {
 				return Comics{}, nil
 			},
-			idsFunc: func(ctx context.Context) ([]int, error) {
+			keysFunc: func(ctx context.Context) ([]ComicKey, error) {
 				return nil, errors.New("get ids error")
 			},
 			expectedComics: nil,
@@ -135,8 +148,8 @@ func TestService_Search(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			db := &mockDB{
-				getFunc: tt.getFunc,
-				idsFunc: tt.idsFunc,
+				getFunc:  tt.getFunc,
+				keysFunc: tt.keysFunc,
 			}
 			index := NewIndex(newTestLogger(), db)
 			service := NewService(
@@ -148,7 +161,7 @@ func TestService_Search(t *testing.T) {
 				index,
 			)
 
-			comics, count, err := service.Search(context.Background(), tt.query, tt.limit)
+			comics, count, err := service.Search(context.Background(), tt.query, tt.limit, "")
 
 			if tt.expectedErr != nil {
 				assert.Error(t, err)
@@ -168,8 +181,8 @@ func TestService_ISearch(t *testing.T) {
 		query          string
 		limit          int
 		normFunc       func(ctx context.Context, phrase string) ([]string, error)
-		getFunc        func(ctx context.Context, id int) (Comics, error)
-		idsFunc        func(ctx context.Context) ([]int, error)
+		getFunc        func(ctx context.Context, key ComicKey) (Comics, error)
+		keysFunc       func(ctx context.Context) ([]ComicKey, error)
 		expectedComics []Comics
 		expectedCount  int
 		expectedErr    error
@@ -181,15 +194,15 @@ func TestService_ISearch(t *testing.T) {
 			normFunc: func(ctx context.Context, phrase string) ([]string, error) {
 				return []string{"test", "query"}, nil
 			},
-			getFunc: func(ctx context.Context, id int) (Comics, error) {
+			getFunc: func(ctx context.Context, key ComicKey) (Comics, error) {
 				return Comics{
-					ID:          id,
+					ID:          key.ID,
 					URL:         "http://example.com",
 					Description: "test query description",
 				}, nil
 			},
-			idsFunc: func(ctx context.Context) ([]int, error) {
-				return []int{2, 3}, nil
+			keysFunc: func(ctx context.Context) ([]ComicKey, error) {
+				return []ComicKey{{Source: "xkcd", ID: 2}, {Source: "xkcd", ID: 3}}, nil
 			},
 			expectedComics: []Comics{
 				{
@@ -213,11 +226,11 @@ func TestService_ISearch(t *testing.T) {
 			normFunc: func(ctx context.Context, phrase string) ([]string, error) {
 				return []string{"test", "query"}, nil
 			},
-			getFunc: func(ctx context.Context, id int) (Comics, error) {
+			getFunc: func(ctx context.Context, key ComicKey) (Comics, error) {
 				return Comics{}, nil
 			},
-			idsFunc: func(ctx context.Context) ([]int, error) {
-				return []int{}, nil
+			keysFunc: func(ctx context.Context) ([]ComicKey, error) {
+				return []ComicKey{}, nil
 			},
 			expectedComics: nil,
 			expectedCount:  0,
@@ -230,11 +243,11 @@ func TestService_ISearch(t *testing.T) {
 			normFunc: func(ctx context.Context, phrase string) ([]string, error) {
 				return nil, errors.New("normalization error")
 			},
-			getFunc: func(ctx context.Context, id int) (Comics, error) {
+			getFunc: func(ctx context.Context, key ComicKey) (Comics, error) {
 				return Comics{}, nil
 			},
-			idsFunc: func(ctx context.Context) ([]int, error) {
-				return []int{}, nil
+			keysFunc: func(ctx context.Context) ([]ComicKey, error) {
+				return []ComicKey{}, nil
 			},
 			expectedComics: nil,
 			expectedCount:  0,
@@ -245,8 +258,8 @@ func TestService_ISearch(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			db := &mockDB{
-				getFunc: tt.getFunc,
-				idsFunc: tt.idsFunc,
+				getFunc:  tt.getFunc,
+				keysFunc: tt.keysFunc,
 			}
 			index := NewIndex(newTestLogger(), db)
 			service := NewService(
@@ -260,7 +273,7 @@ func TestService_ISearch(t *testing.T) {
 
 			index.UpdateIndex(context.Background())
 
-			comics, count, err := service.ISearch(context.Background(), tt.query, tt.limit)
+			comics, count, err := service.ISearch(context.Background(), tt.query, tt.limit, "")
 
 			if tt.expectedErr != nil {
 				assert.Error(t, err)
@@ -273,3 +286,42 @@ func TestService_ISearch(t *testing.T) {
 		})
 	}
 }
+
+func TestService_ISearch_Weights(t *testing.T) {
+	descriptions := map[ComicKey]string{
+		{Source: "xkcd", ID: 2}: "car",
+		{Source: "xkcd", ID: 3}: "automobil red",
+		{Source: "xkcd", ID: 4}: "automobil",
+		{Source: "feed", ID: 1}: "car red",
+	}
+	db := &mockDB{
+		getFunc: func(ctx context.Context, key ComicKey) (Comics, error) {
+			return Comics{ID: key.ID, Source: key.Source, Description: descriptions[key]}, nil
+		},
+		keysFunc: func(ctx context.Context) ([]ComicKey, error) {
+			return []ComicKey{{"xkcd", 2}, {"xkcd", 3}, {"xkcd", 4}, {"feed", 1}}, nil
+		},
+	}
+	words := &mockWords{
+		termsFunc: func(ctx context.Context, phrase string) ([]Term, error) {
+			return []Term{{Word: "car", Weight: 1}, {Word: "red", Weight: 1}, {Word: "automobil", Weight: 0.5}}, nil
+		},
+	}
+	index := NewIndex(newTestLogger(), db)
+	index.UpdateIndex(context.Background())
+	service := NewService(newTestLogger(), db, words, index)
+
+	// синонимы весят меньше слов запроса, фильтр по источнику убирает feed
+	for _, search := range []func(context.Context, string, int, string) ([]Comics, int, error){
+		service.Search, service.ISearch,
+	} {
+		comics, count, err := search(context.Background(), "red car", 10, "xkcd")
+		assert.NoError(t, err)
+		assert.Equal(t, 3, count)
+		ids := make([]int, 0, len(comics))
+		for _, c := range comics {
+			ids = append(ids, c.ID)
+		}
+		assert.Equal(t, []int{3, 2, 4}, ids)
+	}
+}
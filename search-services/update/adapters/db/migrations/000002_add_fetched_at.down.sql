@@ -0,0 +1 @@
+ALTER TABLE comics DROP COLUMN IF EXISTS fetched_at;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE comics ADD COLUMN fetched_at TIMESTAMPTZ NOT NULL DEFAULT now();
\ No newline at end of file
@@ -0,0 +1,5 @@
+ALTER TABLE comics
+    DROP COLUMN IF EXISTS title,
+    DROP COLUMN IF EXISTS alt,
+    DROP COLUMN IF EXISTS transcript,
+    DROP COLUMN IF EXISTS normalizer_version;
\ No newline at end of file
@@ -0,0 +1,5 @@
+ALTER TABLE comics
+    ADD COLUMN title TEXT NOT NULL DEFAULT '',
+    ADD COLUMN alt TEXT NOT NULL DEFAULT '',
+    ADD COLUMN transcript TEXT NOT NULL DEFAULT '',
+    ADD COLUMN normalizer_version TEXT NOT NULL DEFAULT '';
\ No newline at end of file
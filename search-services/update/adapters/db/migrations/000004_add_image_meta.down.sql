@@ -0,0 +1,6 @@
+ALTER TABLE comics
+    DROP COLUMN IF EXISTS image_hash,
+    DROP COLUMN IF EXISTS image_size,
+    DROP COLUMN IF EXISTS image_width,
+    DROP COLUMN IF EXISTS image_height,
+    DROP COLUMN IF EXISTS image_type;
\ No newline at end of file
@@ -0,0 +1,6 @@
+ALTER TABLE comics
+    ADD COLUMN image_hash TEXT NOT NULL DEFAULT '',
+    ADD COLUMN image_size BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN image_width INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN image_height INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN image_type TEXT NOT NULL DEFAULT '';
\ No newline at end of file
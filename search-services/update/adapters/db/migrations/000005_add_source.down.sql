@@ -0,0 +1,4 @@
+DELETE FROM comics WHERE source <> 'xkcd';
+ALTER TABLE comics DROP CONSTRAINT comics_pkey;
+ALTER TABLE comics ADD PRIMARY KEY (comic_id);
+ALTER TABLE comics DROP COLUMN IF EXISTS source;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE comics ADD COLUMN source TEXT NOT NULL DEFAULT 'xkcd';
+ALTER TABLE comics DROP CONSTRAINT comics_pkey;
+ALTER TABLE comics ADD PRIMARY KEY (source, comic_id);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS feed_keys;
\ No newline at end of file
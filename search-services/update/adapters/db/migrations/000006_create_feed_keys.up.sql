@@ -0,0 +1,7 @@
+CREATE TABLE feed_keys (
+    source TEXT NOT NULL,
+    key_hash TEXT NOT NULL,
+    comic_id INTEGER NOT NULL,
+    PRIMARY KEY (source, comic_id),
+    UNIQUE (source, key_hash)
+);
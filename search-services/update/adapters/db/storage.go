@@ -2,6 +2,9 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"log/slog"
 	"strings"
 
@@ -30,17 +33,34 @@ func New(log *slog.Logger, address string) (*DB, error) {
 
 func (db *DB) Add(ctx context.Context, comic core.Comics) error {
 	query := `
-		INSERT INTO comics (comic_id, url, description)
-		VALUES ($1, $2, $3)
-		ON CONFLICT (comic_id) DO UPDATE
+		INSERT INTO comics (comic_id, url, description, fetched_at,
+			title, alt, transcript, normalizer_version,
+			image_hash, image_size, image_width, image_height, image_type, source)
+		VALUES ($1, $2, $3, now(), $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		ON CONFLICT (source, comic_id) DO UPDATE
 		SET url = EXCLUDED.url,
-			description = EXCLUDED.description
+			description = EXCLUDED.description,
+			fetched_at = EXCLUDED.fetched_at,
+			title = EXCLUDED.title,
+			alt = EXCLUDED.alt,
+			transcript = EXCLUDED.transcript,
+			normalizer_version = EXCLUDED.normalizer_version,
+			image_hash = CASE WHEN EXCLUDED.image_hash <> '' THEN EXCLUDED.image_hash ELSE comics.image_hash END,
+			image_size = CASE WHEN EXCLUDED.image_hash <> '' THEN EXCLUDED.image_size ELSE comics.image_size END,
+			image_width = CASE WHEN EXCLUDED.image_hash <> '' THEN EXCLUDED.image_width ELSE comics.image_width END,
+			image_height = CASE WHEN EXCLUDED.image_hash <> '' THEN EXCLUDED.image_height ELSE comics.image_height END,
+			image_type = CASE WHEN EXCLUDED.image_hash <> '' THEN EXCLUDED.image_type ELSE comics.image_type END
 	`
 
-	_, err := db.conn.ExecContext(ctx, query, comic.ID, comic.URL, strings.Join(comic.Words, " "))
+	_, err := db.conn.ExecContext(ctx, query,
+		comic.ID, comic.URL, strings.Join(comic.Words, " "),
+		comic.Title, comic.Alt, comic.Transcript, comic.NormalizerVersion,
+		comic.Image.Hash, comic.Image.Size, comic.Image.Width, comic.Image.Height, comic.Image.ContentType,
+		comic.Source)
 	if err != nil {
 		db.log.Error("failed to insert comic",
 			"error", err,
+			"source", comic.Source,
 			"comic_id", comic.ID,
 			"words_count", len(comic.Words),
 			"url", comic.URL)
@@ -48,6 +68,7 @@ func (db *DB) Add(ctx context.Context, comic core.Comics) error {
 	}
 
 	db.log.Debug("comic added",
+		"source", comic.Source,
 		"comic_id", comic.ID,
 		"words_count", len(comic.Words),
 		"url", comic.URL)
@@ -87,15 +108,144 @@ func (db *DB) Stats(ctx context.Context) (core.DBStats, error) {
 	return stats, nil
 }
 
-func (db *DB) IDs(ctx context.Context) ([]int, error) {
+func (db *DB) IDs(ctx context.Context, source string) ([]int, error) {
 	var ids []int
-	err := db.conn.SelectContext(ctx, &ids, "SELECT comic_id FROM comics")
+	err := db.conn.SelectContext(ctx, &ids, "SELECT comic_id FROM comics WHERE source = $1", source)
 	if err != nil {
 		return nil, err
 	}
 	return ids, nil
 }
 
+func (db *DB) FilteredIDs(ctx context.Context, source string, filter core.ReindexFilter) ([]int, error) {
+	query := "SELECT comic_id FROM comics WHERE source = $1"
+	args := []any{source}
+	if filter.FromID > 0 {
+		args = append(args, filter.FromID)
+		query += fmt.Sprintf(" AND comic_id >= $%d", len(args))
+	}
+	if filter.ToID > 0 {
+		args = append(args, filter.ToID)
+		query += fmt.Sprintf(" AND comic_id <= $%d", len(args))
+	}
+	if !filter.OlderThan.IsZero() {
+		args = append(args, filter.OlderThan)
+		query += fmt.Sprintf(" AND fetched_at < $%d", len(args))
+	}
+	query += " ORDER BY comic_id"
+
+	var ids []int
+	if err := db.conn.SelectContext(ctx, &ids, query, args...); err != nil {
+		db.log.Error("failed to select comics for reindex", "error", err)
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (db *DB) RawBatch(
+	ctx context.Context, afterSource string, afterID, limit int, staleFor string,
+) ([]core.Comics, error) {
+	query := `
+		SELECT source, comic_id, url, title, alt, transcript, normalizer_version
+		FROM comics
+		WHERE (source, comic_id) > ($1, $2)
+			AND ($4 = '' OR normalizer_version <> $4)
+		ORDER BY source, comic_id
+		LIMIT $3
+	`
+
+	rows, err := db.conn.QueryContext(ctx, query, afterSource, afterID, limit, staleFor)
+	if err != nil {
+		db.log.Error("failed to read raw comics", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comics []core.Comics
+	for rows.Next() {
+		var c core.Comics
+		if err := rows.Scan(&c.Source, &c.ID, &c.URL, &c.Title, &c.Alt, &c.Transcript, &c.NormalizerVersion); err != nil {
+			return nil, err
+		}
+		comics = append(comics, c)
+	}
+	return comics, rows.Err()
+}
+
+func (db *DB) UpdateWords(ctx context.Context, source string, id int, words []string, version string) error {
+	query := "UPDATE comics SET description = $3, normalizer_version = $4 WHERE source = $1 AND comic_id = $2"
+	if _, err := db.conn.ExecContext(ctx, query, source, id, strings.Join(words, " "), version); err != nil {
+		db.log.Error("failed to update comic words", "error", err, "source", source, "comic_id", id)
+		return err
+	}
+
+	db.log.Debug("comic renormalized", "source", source, "comic_id", id, "words_count", len(words))
+	return nil
+}
+
+func (db *DB) StaleCount(ctx context.Context, version string) (int, error) {
+	var count int
+	err := db.conn.GetContext(ctx, &count,
+		"SELECT COUNT(*) FROM comics WHERE normalizer_version <> $1", version)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// Key numbers feed items: an unseen key gets the next number of the source.
+func (db *DB) Key(ctx context.Context, source, key string) (int, error) {
+	query := `
+		INSERT INTO feed_keys (source, key_hash, comic_id)
+		SELECT $1, $2, COALESCE(MAX(comic_id), 0) + 1 FROM feed_keys WHERE source = $1
+		ON CONFLICT (source, key_hash) DO NOTHING
+	`
+	if _, err := db.conn.ExecContext(ctx, query, source, key); err != nil {
+		db.log.Error("failed to add feed key", "source", source, "error", err)
+		return 0, err
+	}
+
+	var id int
+	err := db.conn.GetContext(ctx, &id,
+		"SELECT comic_id FROM feed_keys WHERE source = $1 AND key_hash = $2", source, key)
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (db *DB) KeyCount(ctx context.Context, source string) (int, error) {
+	var count int
+	err := db.conn.GetContext(ctx, &count,
+		"SELECT COUNT(*) FROM feed_keys WHERE source = $1", source)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (db *DB) ImageMeta(ctx context.Context, source string, id int) (core.ImageMeta, error) {
+	query := `
+		SELECT image_hash, image_size, image_width, image_height, image_type
+		FROM comics
+		WHERE source = $1 AND comic_id = $2
+	`
+
+	var meta core.ImageMeta
+	err := db.conn.QueryRowContext(ctx, query, source, id).
+		Scan(&meta.Hash, &meta.Size, &meta.Width, &meta.Height, &meta.ContentType)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return core.ImageMeta{}, core.ErrNotFound
+		}
+		return core.ImageMeta{}, err
+	}
+	if meta.Hash == "" {
+		return core.ImageMeta{}, core.ErrNotFound
+	}
+	return meta, nil
+}
+
 func (db *DB) Drop(ctx context.Context) error {
 	if _, err := db.conn.ExecContext(ctx, "TRUNCATE TABLE comics"); err != nil {
 		db.log.Error("failed to truncate table", "error", err)
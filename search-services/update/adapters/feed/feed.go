@@ -0,0 +1,288 @@
+package feed
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"yadro.com/course/update/core"
+)
+
+const (
+	FormatJSON = "jsonfeed"
+	FormatRSS  = "rss"
+)
+
+var (
+	imgRe   = regexp.MustCompile(`(?i)<img\s[^>]*>`)
+	srcRe   = regexp.MustCompile(`(?i)\ssrc\s*=\s*"([^"]*)"`)
+	titleRe = regexp.MustCompile(`(?i)\stitle\s*=\s*"([^"]*)"`)
+	tagRe   = regexp.MustCompile(`<[^>]*>`)
+)
+
+// Client reads a webcomic from a JSON Feed or RSS 2.0 feed. Items are keyed by
+// their id (guid) or link and numbered in the order they first appear, the
+// numbers are kept by keys so they stay the same after items leave the feed.
+type Client struct {
+	id       string
+	log      *slog.Logger
+	client   http.Client
+	url      string
+	format   string
+	cacheTTL time.Duration
+	keys     core.Keys
+
+	mu        sync.Mutex
+	items     map[int]core.ComicInfo
+	lastID    int
+	fetchedAt time.Time
+}
+
+// entry is a parsed feed item with the key it is numbered by
+type entry struct {
+	key  string
+	info core.ComicInfo
+}
+
+func New(
+	id, url, format string, timeout, cacheTTL time.Duration, keys core.Keys, log *slog.Logger,
+) (*Client, error) {
+	if url == "" {
+		return nil, fmt.Errorf("empty feed url specified")
+	}
+	if format != FormatJSON && format != FormatRSS {
+		return nil, fmt.Errorf("unknown feed format: %q", format)
+	}
+	return &Client{
+		id:       id,
+		log:      log,
+		client:   http.Client{Timeout: timeout},
+		url:      url,
+		format:   format,
+		cacheTTL: cacheTTL,
+		keys:     keys,
+	}, nil
+}
+
+func (c *Client) ID() string {
+	return c.id
+}
+
+func (c *Client) Get(ctx context.Context, id int) (core.ComicInfo, error) {
+	items, _, err := c.load(ctx)
+	if err != nil {
+		return core.ComicInfo{}, err
+	}
+
+	info, ok := items[id]
+	if !ok {
+		c.log.Debug("comic is missing in feed", "source", c.id, "id", id)
+		return core.ComicInfo{}, core.ErrNotFound
+	}
+	return info, nil
+}
+
+func (c *Client) LastID(ctx context.Context) (int, error) {
+	_, lastID, err := c.load(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if lastID == 0 {
+		return 0, core.ErrNotFound
+	}
+	return lastID, nil
+}
+
+// Count returns how many items the feed has ever had, including the ones it no longer lists
+func (c *Client) Count(ctx context.Context) (int, error) {
+	if _, _, err := c.load(ctx); err != nil {
+		return 0, err
+	}
+	return c.keys.KeyCount(ctx, c.id)
+}
+
+// load returns the parsed feed, downloading it again once the cache expires.
+func (c *Client) load(ctx context.Context) (map[int]core.ComicInfo, int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.items != nil && time.Since(c.fetchedAt) < c.cacheTTL {
+		return c.items, c.lastID, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %w", core.ErrTransport, err)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		c.log.Error("failed to get feed", "source", c.id, "error", err)
+		return nil, 0, fmt.Errorf("%w: %w", core.ErrTransport, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, 0, fmt.Errorf("%w: unexpected status code: %d", core.ErrNotFound, resp.StatusCode)
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return nil, 0, fmt.Errorf("%w: unexpected status code: %d", core.ErrRateLimited, resp.StatusCode)
+	default:
+		return nil, 0, fmt.Errorf("%w: unexpected status code: %d", core.ErrTransport, resp.StatusCode)
+	}
+
+	var entries []entry
+	if c.format == FormatJSON {
+		entries, err = parseJSONFeed(resp.Body)
+	} else {
+		entries, err = parseRSS(resp.Body)
+	}
+	if err != nil {
+		c.log.Error("failed to decode feed", "source", c.id, "error", err)
+		return nil, 0, fmt.Errorf("%w: %w", core.ErrDecode, err)
+	}
+
+	// feeds list the newest items first, so number them from the end
+	items := make(map[int]core.ComicInfo, len(entries))
+	lastID := 0
+	for i := len(entries) - 1; i >= 0; i-- {
+		id, err := c.keys.Key(ctx, c.id, hashKey(entries[i].key))
+		if err != nil {
+			c.log.Error("failed to number feed item", "source", c.id, "key", entries[i].key, "error", err)
+			return nil, 0, fmt.Errorf("failed to number feed item: %w", err)
+		}
+		info := entries[i].info
+		info.ID = id
+		items[id] = info
+		lastID = max(lastID, id)
+	}
+	c.items = items
+	c.lastID = lastID
+	c.fetchedAt = time.Now()
+
+	c.log.Debug("feed loaded", "source", c.id, "items", len(c.items), "last_id", c.lastID)
+	return c.items, c.lastID, nil
+}
+
+func parseJSONFeed(r io.Reader) ([]entry, error) {
+	var feed struct {
+		Items []struct {
+			ID          string `json:"id"`
+			URL         string `json:"url"`
+			Title       string `json:"title"`
+			Summary     string `json:"summary"`
+			ContentText string `json:"content_text"`
+			ContentHTML string `json:"content_html"`
+			Image       string `json:"image"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(r).Decode(&feed); err != nil {
+		return nil, err
+	}
+
+	var entries []entry
+	for _, item := range feed.Items {
+		key := firstNonEmpty(item.ID, item.URL)
+		if key == "" {
+			continue
+		}
+		image, alt := imageFromHTML(item.ContentHTML)
+		if item.Image != "" {
+			image = item.Image
+		}
+		text := item.ContentText
+		if text == "" {
+			text = stripHTML(item.ContentHTML)
+		}
+		entries = append(entries, entry{key: key, info: core.ComicInfo{
+			URL:        image,
+			Title:      item.Title,
+			Alt:        strings.TrimSpace(item.Summary + " " + alt),
+			Transcript: text,
+		}})
+	}
+	return entries, nil
+}
+
+func parseRSS(r io.Reader) ([]entry, error) {
+	var feed struct {
+		Items []struct {
+			Title       string `xml:"title"`
+			Link        string `xml:"link"`
+			GUID        string `xml:"guid"`
+			Description string `xml:"description"`
+			Enclosure   struct {
+				URL  string `xml:"url,attr"`
+				Type string `xml:"type,attr"`
+			} `xml:"enclosure"`
+		} `xml:"channel>item"`
+	}
+	if err := xml.NewDecoder(r).Decode(&feed); err != nil {
+		return nil, err
+	}
+
+	var entries []entry
+	for _, item := range feed.Items {
+		key := firstNonEmpty(item.GUID, item.Link)
+		if key == "" {
+			continue
+		}
+		image, alt := imageFromHTML(item.Description)
+		if strings.HasPrefix(item.Enclosure.Type, "image/") {
+			image = item.Enclosure.URL
+		}
+		entries = append(entries, entry{key: key, info: core.ComicInfo{
+			URL:        image,
+			Title:      item.Title,
+			Alt:        alt,
+			Transcript: stripHTML(item.Description),
+		}})
+	}
+	return entries, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// hashKey keeps keys of any length in a fixed size column
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// imageFromHTML returns src and title of the first <img>, webcomics keep hover text in title.
+func imageFromHTML(s string) (string, string) {
+	img := imgRe.FindString(s)
+	if img == "" {
+		return "", ""
+	}
+	var src, title string
+	if m := srcRe.FindStringSubmatch(img); m != nil {
+		src = html.UnescapeString(m[1])
+	}
+	if m := titleRe.FindStringSubmatch(img); m != nil {
+		title = html.UnescapeString(m[1])
+	}
+	return src, title
+}
+
+func stripHTML(s string) string {
+	return strings.Join(strings.Fields(html.UnescapeString(tagRe.ReplaceAllString(s, " "))), " ")
+}
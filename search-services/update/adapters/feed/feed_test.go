@@ -0,0 +1,290 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"yadro.com/course/update/core"
+)
+
+const jsonFeed = `{
+	"version": "https://jsonfeed.org/version/1.1",
+	"items": [
+		{
+			"id": "https://example.com/comic/12",
+			"url": "https://example.com/comic/12",
+			"title": "Twelve",
+			"content_html": "<p>Cats &amp; dogs</p><img src=\"https://example.com/12.png\" title=\"hover text\">"
+		},
+		{
+			"id": "https://example.com/comic/13",
+			"title": "Thirteen",
+			"content_text": "plain text",
+			"image": "https://example.com/13.png"
+		},
+		{
+			"title": "No id"
+		}
+	]
+}`
+
+const rssFeed = `<?xml version="1.0"?>
+<rss version="2.0">
+<channel>
+	<title>Example</title>
+	<item>
+		<title>First</title>
+		<link>https://example.com/?p=7</link>
+		<guid>https://example.com/?p=7</guid>
+		<description>&lt;img src="https://example.com/7.png" title="mouse over"&gt; Some &lt;b&gt;words&lt;/b&gt;</description>
+	</item>
+	<item>
+		<title>Second</title>
+		<link>https://example.com/?p=9</link>
+		<description>Other words</description>
+		<enclosure url="https://example.com/9.jpg" type="image/jpeg" length="100"/>
+	</item>
+</channel>
+</rss>`
+
+func newTestLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// memoryKeys реализует интерфейс Keys для тестов
+type memoryKeys struct {
+	mu   sync.Mutex
+	keys map[string]map[string]int
+}
+
+func newMemoryKeys() *memoryKeys {
+	return &memoryKeys{keys: make(map[string]map[string]int)}
+}
+
+func (m *memoryKeys) Key(_ context.Context, source, key string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.keys[source] == nil {
+		m.keys[source] = make(map[string]int)
+	}
+	id, ok := m.keys[source][key]
+	if !ok {
+		id = len(m.keys[source]) + 1
+		m.keys[source][key] = id
+	}
+	return id, nil
+}
+
+func (m *memoryKeys) KeyCount(_ context.Context, source string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.keys[source]), nil
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		format  string
+		wantErr bool
+	}{
+		{name: "json feed", url: "http://example.com", format: FormatJSON},
+		{name: "rss", url: "http://example.com", format: FormatRSS},
+		{name: "empty url", url: "", format: FormatRSS, wantErr: true},
+		{name: "unknown format", url: "http://example.com", format: "atom", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := New("comic", tt.url, tt.format, time.Second, time.Minute, newMemoryKeys(), newTestLogger())
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, client)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "comic", client.ID())
+		})
+	}
+}
+
+func TestClient_Get(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		body     string
+		lastID   int
+		expected map[int]core.ComicInfo
+	}{
+		{
+			name:   "json feed",
+			format: FormatJSON,
+			body:   jsonFeed,
+			lastID: 2,
+			expected: map[int]core.ComicInfo{
+				2: {
+					ID:         2,
+					URL:        "https://example.com/12.png",
+					Title:      "Twelve",
+					Alt:        "hover text",
+					Transcript: "Cats & dogs",
+				},
+				1: {
+					ID:         1,
+					URL:        "https://example.com/13.png",
+					Title:      "Thirteen",
+					Transcript: "plain text",
+				},
+			},
+		},
+		{
+			name:   "rss",
+			format: FormatRSS,
+			body:   rssFeed,
+			lastID: 2,
+			expected: map[int]core.ComicInfo{
+				2: {
+					ID:         2,
+					URL:        "https://example.com/7.png",
+					Title:      "First",
+					Alt:        "mouse over",
+					Transcript: "Some words",
+				},
+				1: {
+					ID:         1,
+					URL:        "https://example.com/9.jpg",
+					Title:      "Second",
+					Transcript: "Other words",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, err := io.WriteString(w, tt.body)
+				assert.NoError(t, err)
+			}))
+			defer server.Close()
+
+			client, err := New("comic", server.URL, tt.format, time.Second, time.Minute, newMemoryKeys(), newTestLogger())
+			require.NoError(t, err)
+
+			lastID, err := client.LastID(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.lastID, lastID)
+
+			for id, expected := range tt.expected {
+				info, err := client.Get(context.Background(), id)
+				require.NoError(t, err)
+				assert.Equal(t, expected, info)
+			}
+
+			_, err = client.Get(context.Background(), 3)
+			assert.ErrorIs(t, err, core.ErrNotFound)
+		})
+	}
+}
+
+func TestClient_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		expectedErr error
+	}{
+		{name: "not found", status: http.StatusNotFound, expectedErr: core.ErrNotFound},
+		{name: "rate limited", status: http.StatusTooManyRequests, expectedErr: core.ErrRateLimited},
+		{name: "server error", status: http.StatusBadGateway, expectedErr: core.ErrTransport},
+		{name: "bad json", status: http.StatusOK, body: "{", expectedErr: core.ErrDecode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, err := io.WriteString(w, tt.body)
+				assert.NoError(t, err)
+			}))
+			defer server.Close()
+
+			client, err := New("comic", server.URL, FormatJSON, time.Second, time.Minute, newMemoryKeys(), newTestLogger())
+			require.NoError(t, err)
+
+			_, err = client.Get(context.Background(), 1)
+			assert.ErrorIs(t, err, tt.expectedErr)
+		})
+	}
+}
+
+func TestClient_Cache(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		_, err := io.WriteString(w, jsonFeed)
+		assert.NoError(t, err)
+	}))
+	defer server.Close()
+
+	client, err := New("comic", server.URL, FormatJSON, time.Second, time.Minute, newMemoryKeys(), newTestLogger())
+	require.NoError(t, err)
+
+	// весь фид скачивается один раз, а не на каждый комикс
+	for id := 1; id <= 13; id++ {
+		_, _ = client.Get(context.Background(), id)
+	}
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+func TestClient_Keys(t *testing.T) {
+	const item = `{"id": "https://example.com/2024/05/%s", "title": "%s"}`
+	body := `{"items": [` + fmt.Sprintf(item, "b", "B") + `, ` + fmt.Sprintf(item, "a", "A") + `]}`
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		_, err := io.WriteString(w, body)
+		assert.NoError(t, err)
+	}))
+	defer server.Close()
+
+	client, err := New("comic", server.URL, FormatJSON, time.Second, 0, newMemoryKeys(), newTestLogger())
+	require.NoError(t, err)
+
+	// адреса с датой не склеиваются в один номер, старые записи нумеруются первыми
+	lastID, err := client.LastID(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, lastID)
+	info, err := client.Get(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, "A", info.Title)
+
+	// новая запись получает следующий номер, ушедшие из фида сохраняют свои и учитываются в Count
+	mu.Lock()
+	body = `{"items": [` + fmt.Sprintf(item, "c", "C") + `, ` + fmt.Sprintf(item, "b", "B") + `]}`
+	mu.Unlock()
+
+	info, err = client.Get(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, "B", info.Title)
+	info, err = client.Get(context.Background(), 3)
+	require.NoError(t, err)
+	assert.Equal(t, "C", info.Title)
+	_, err = client.Get(context.Background(), 1)
+	assert.ErrorIs(t, err, core.ErrNotFound)
+
+	count, err := client.Count(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+}
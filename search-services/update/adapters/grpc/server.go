@@ -2,6 +2,7 @@ package grpc
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 
 	"google.golang.org/grpc/codes"
@@ -41,8 +42,15 @@ func (s *Server) Status(ctx context.Context, _ *emptypb.Empty) (*updatepb.Status
 func (s *Server) Update(ctx context.Context, _ *emptypb.Empty) (*emptypb.Empty, error) {
 	err := s.service.Update(ctx)
 	if err != nil {
-		if err == core.ErrAlreadyExists {
+		switch {
+		case errors.Is(err, core.ErrAlreadyExists):
 			return nil, status.Error(codes.AlreadyExists, "update already in progress")
+		case errors.Is(err, core.ErrRateLimited):
+			return nil, status.Error(codes.Unavailable, "comics source rate limit exceeded")
+		case errors.Is(err, core.ErrTransport):
+			return nil, status.Error(codes.Unavailable, "comics source is unavailable")
+		case errors.Is(err, core.ErrNormalize):
+			return nil, status.Error(codes.Unavailable, "failed to normalize comics")
 		}
 		return nil, status.Error(codes.Internal, "failed to update")
 	}
@@ -60,6 +68,72 @@ func (s *Server) Stats(ctx context.Context, _ *emptypb.Empty) (*updatepb.StatsRe
 		WordsUnique:   int64(stats.WordsUnique),
 		ComicsTotal:   int64(stats.ComicsTotal),
 		ComicsFetched: int64(stats.ComicsFetched),
+		ComicsStale:   int64(stats.ComicsStale),
+	}, nil
+}
+
+func (s *Server) Reindex(ctx context.Context, req *updatepb.ReindexRequest) (*emptypb.Empty, error) {
+	filter := core.ReindexFilter{
+		Source: req.GetSource(),
+		FromID: int(req.GetFromId()),
+		ToID:   int(req.GetToId()),
+	}
+	if req.GetOlderThan() != nil {
+		filter.OlderThan = req.GetOlderThan().AsTime()
+	}
+
+	err := s.service.Reindex(ctx, filter)
+	if err != nil {
+		switch {
+		case errors.Is(err, core.ErrAlreadyExists):
+			return nil, status.Error(codes.AlreadyExists, "update already in progress")
+		case errors.Is(err, core.ErrBadArguments):
+			return nil, status.Error(codes.InvalidArgument, "bad reindex range")
+		case errors.Is(err, core.ErrRateLimited):
+			return nil, status.Error(codes.Unavailable, "comics source rate limit exceeded")
+		case errors.Is(err, core.ErrTransport):
+			return nil, status.Error(codes.Unavailable, "comics source is unavailable")
+		case errors.Is(err, core.ErrNormalize):
+			return nil, status.Error(codes.Unavailable, "failed to normalize comics")
+		}
+		return nil, status.Error(codes.Internal, "failed to reindex")
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) Renormalize(ctx context.Context, req *updatepb.RenormalizeRequest) (*emptypb.Empty, error) {
+	err := s.service.Renormalize(ctx, req.GetOnlyStale())
+	if err != nil {
+		switch {
+		case errors.Is(err, core.ErrAlreadyExists):
+			return nil, status.Error(codes.AlreadyExists, "update already in progress")
+		case errors.Is(err, core.ErrRateLimited):
+			return nil, status.Error(codes.Unavailable, "comics source rate limit exceeded")
+		case errors.Is(err, core.ErrTransport):
+			return nil, status.Error(codes.Unavailable, "comics source is unavailable")
+		case errors.Is(err, core.ErrNormalize):
+			return nil, status.Error(codes.Unavailable, "failed to normalize comics")
+		}
+		return nil, status.Error(codes.Internal, "failed to renormalize")
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) Image(ctx context.Context, req *updatepb.ImageRequest) (*updatepb.ImageReply, error) {
+	image, err := s.service.Image(ctx, req.GetSource(), int(req.GetId()), req.GetThumbnail())
+	if err != nil {
+		if errors.Is(err, core.ErrNotFound) {
+			return nil, status.Error(codes.NotFound, "image not found")
+		}
+		return nil, status.Error(codes.Internal, "failed to get image")
+	}
+
+	return &updatepb.ImageReply{
+		Data:        image.Data,
+		ContentType: image.ContentType,
+		Hash:        image.Hash,
+		Width:       int64(image.Width),
+		Height:      int64(image.Height),
 	}, nil
 }
 
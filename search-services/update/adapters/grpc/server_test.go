@@ -3,21 +3,28 @@ package grpc
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	updatepb "yadro.com/course/proto/update"
 	"yadro.com/course/update/core"
 )
 
 type mockUpdater struct {
-	status core.ServiceStatus
-	stats  core.ServiceStats
-	err    error
+	status    core.ServiceStatus
+	stats     core.ServiceStats
+	err       error
+	filter    core.ReindexFilter
+	onlyStale bool
+	image     core.Image
+	source    string
 }
 
 func (m *mockUpdater) Update(ctx context.Context) error {
@@ -36,6 +43,21 @@ func (m *mockUpdater) Drop(ctx context.Context) error {
 	return m.err
 }
 
+func (m *mockUpdater) Image(ctx context.Context, source string, id int, thumbnail bool) (core.Image, error) {
+	m.source = source
+	return m.image, m.err
+}
+
+func (m *mockUpdater) Renormalize(ctx context.Context, onlyStale bool) error {
+	m.onlyStale = onlyStale
+	return m.err
+}
+
+func (m *mockUpdater) Reindex(ctx context.Context, filter core.ReindexFilter) error {
+	m.filter = filter
+	return m.err
+}
+
 func TestServer_Ping(t *testing.T) {
 	server := NewServer(&mockUpdater{})
 	resp, err := server.Ping(context.Background(), &emptypb.Empty{})
@@ -103,6 +125,16 @@ func TestServer_Update(t *testing.T) {
 			serviceError:  core.ErrAlreadyExists,
 			expectedError: status.Error(codes.AlreadyExists, "update already in progress"),
 		},
+		{
+			name:          "rate limited",
+			serviceError:  fmt.Errorf("%w: status 429", core.ErrRateLimited),
+			expectedError: status.Error(codes.Unavailable, "comics source rate limit exceeded"),
+		},
+		{
+			name:          "transport error",
+			serviceError:  fmt.Errorf("%w: connection refused", core.ErrTransport),
+			expectedError: status.Error(codes.Unavailable, "comics source is unavailable"),
+		},
 		{
 			name:          "internal error",
 			serviceError:  errors.New("some error"),
@@ -143,6 +175,7 @@ func TestServer_Stats(t *testing.T) {
 					ComicsFetched: 5,
 				},
 				ComicsTotal: 10,
+				ComicsStale: 2,
 			},
 			serviceError:  nil,
 			expectedError: nil,
@@ -173,6 +206,7 @@ func TestServer_Stats(t *testing.T) {
 			assert.Equal(t, tt.stats.WordsUnique, int(resp.WordsUnique))
 			assert.Equal(t, tt.stats.ComicsTotal, int(resp.ComicsTotal))
 			assert.Equal(t, tt.stats.ComicsFetched, int(resp.ComicsFetched))
+			assert.Equal(t, tt.stats.ComicsStale, int(resp.ComicsStale))
 		})
 	}
 }
@@ -211,3 +245,160 @@ func TestServer_Drop(t *testing.T) {
 		})
 	}
 }
+
+func TestServer_Reindex(t *testing.T) {
+	olderThan := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		request        *updatepb.ReindexRequest
+		serviceError   error
+		expectedFilter core.ReindexFilter
+		expectedError  error
+	}{
+		{
+			name:           "reindex all",
+			request:        &updatepb.ReindexRequest{},
+			expectedFilter: core.ReindexFilter{},
+		},
+		{
+			name: "range and date",
+			request: &updatepb.ReindexRequest{
+				FromId:    10,
+				ToId:      20,
+				OlderThan: timestamppb.New(olderThan),
+				Source:    "xkcd",
+			},
+			expectedFilter: core.ReindexFilter{Source: "xkcd", FromID: 10, ToID: 20, OlderThan: olderThan},
+		},
+		{
+			name:          "already running",
+			request:       &updatepb.ReindexRequest{},
+			serviceError:  core.ErrAlreadyExists,
+			expectedError: status.Error(codes.AlreadyExists, "update already in progress"),
+		},
+		{
+			name:          "bad arguments",
+			request:       &updatepb.ReindexRequest{FromId: 5, ToId: 1},
+			serviceError:  core.ErrBadArguments,
+			expectedError: status.Error(codes.InvalidArgument, "bad reindex range"),
+		},
+		{
+			name:          "internal error",
+			request:       &updatepb.ReindexRequest{},
+			serviceError:  errors.New("some error"),
+			expectedError: status.Error(codes.Internal, "failed to reindex"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updater := &mockUpdater{err: tt.serviceError}
+			server := NewServer(updater)
+			resp, err := server.Reindex(context.Background(), tt.request)
+
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.NotNil(t, resp)
+			assert.True(t, tt.expectedFilter.OlderThan.Equal(updater.filter.OlderThan))
+			assert.Equal(t, tt.expectedFilter.FromID, updater.filter.FromID)
+			assert.Equal(t, tt.expectedFilter.ToID, updater.filter.ToID)
+		})
+	}
+}
+
+func TestServer_Renormalize(t *testing.T) {
+	tests := []struct {
+		name          string
+		onlyStale     bool
+		serviceError  error
+		expectedError error
+	}{
+		{
+			name:      "renormalize stale",
+			onlyStale: true,
+		},
+		{
+			name:          "already running",
+			serviceError:  core.ErrAlreadyExists,
+			expectedError: status.Error(codes.AlreadyExists, "update already in progress"),
+		},
+		{
+			name:          "internal error",
+			serviceError:  errors.New("some error"),
+			expectedError: status.Error(codes.Internal, "failed to renormalize"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updater := &mockUpdater{err: tt.serviceError}
+			server := NewServer(updater)
+			resp, err := server.Renormalize(context.Background(), &updatepb.RenormalizeRequest{OnlyStale: tt.onlyStale})
+
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.NotNil(t, resp)
+			assert.Equal(t, tt.onlyStale, updater.onlyStale)
+		})
+	}
+}
+
+func TestServer_Image(t *testing.T) {
+	image := core.Image{
+		ImageMeta: core.ImageMeta{Hash: "abcd", Width: 20, Height: 10, ContentType: "image/png"},
+		Data:      []byte("png"),
+	}
+
+	tests := []struct {
+		name          string
+		serviceError  error
+		expectedError error
+	}{
+		{
+			name: "successful image",
+		},
+		{
+			name:          "not found",
+			serviceError:  core.ErrNotFound,
+			expectedError: status.Error(codes.NotFound, "image not found"),
+		},
+		{
+			name:          "internal error",
+			serviceError:  errors.New("some error"),
+			expectedError: status.Error(codes.Internal, "failed to get image"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updater := &mockUpdater{image: image, err: tt.serviceError}
+			server := NewServer(updater)
+			resp, err := server.Image(context.Background(), &updatepb.ImageRequest{Id: 1, Thumbnail: true, Source: "feed"})
+			assert.Equal(t, "feed", updater.source)
+
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, image.Data, resp.Data)
+			assert.Equal(t, image.ContentType, resp.ContentType)
+			assert.Equal(t, image.Hash, resp.Hash)
+			assert.Equal(t, int64(image.Width), resp.Width)
+			assert.Equal(t, int64(image.Height), resp.Height)
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package images
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"yadro.com/course/update/core"
+)
+
+// Store keeps images by content hash. maxPixels bounds the memory a decode takes:
+// a small file can declare huge dimensions, and thumbnails are made on request.
+type Store struct {
+	log       *slog.Logger
+	client    http.Client
+	dir       string
+	maxSize   int64
+	maxPixels int64
+	thumbSize int
+}
+
+func NewStore(
+	dir string, maxSize, maxPixels int64, thumbSize int, timeout time.Duration, log *slog.Logger,
+) (*Store, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("empty images directory specified")
+	}
+	if maxSize <= 0 || maxPixels <= 0 || thumbSize <= 0 {
+		return nil, fmt.Errorf("wrong image limits specified: size %d, pixels %d, thumbnail %d",
+			maxSize, maxPixels, thumbSize)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create images directory: %w", err)
+	}
+	return &Store{
+		log:       log,
+		client:    http.Client{Timeout: timeout},
+		dir:       dir,
+		maxSize:   maxSize,
+		maxPixels: maxPixels,
+		thumbSize: thumbSize,
+	}, nil
+}
+
+func (s *Store) Save(ctx context.Context, url string) (core.ImageMeta, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return core.ImageMeta{}, fmt.Errorf("failed to create image request: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return core.ImageMeta{}, fmt.Errorf("failed to download image: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return core.ImageMeta{}, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, s.maxSize+1))
+	if err != nil {
+		return core.ImageMeta{}, fmt.Errorf("failed to read image: %w", err)
+	}
+	if int64(len(data)) > s.maxSize {
+		return core.ImageMeta{}, fmt.Errorf("image is larger than %d bytes", s.maxSize)
+	}
+
+	config, format, err := s.decodeConfig(data)
+	if err != nil {
+		return core.ImageMeta{}, err
+	}
+
+	sum := sha256.Sum256(data)
+	meta := core.ImageMeta{
+		Hash:        hex.EncodeToString(sum[:]),
+		Size:        int64(len(data)),
+		Width:       config.Width,
+		Height:      config.Height,
+		ContentType: "image/" + format,
+	}
+
+	if err := s.write(s.path(meta.Hash, false), data); err != nil {
+		return core.ImageMeta{}, err
+	}
+
+	s.log.Debug("image saved", "url", url, "hash", meta.Hash, "size", meta.Size)
+	return meta, nil
+}
+
+func (s *Store) Load(_ context.Context, meta core.ImageMeta, thumbnail bool) (core.Image, error) {
+	data, err := os.ReadFile(s.path(meta.Hash, false))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return core.Image{}, core.ErrNotFound
+		}
+		return core.Image{}, fmt.Errorf("failed to read image: %w", err)
+	}
+	if !thumbnail {
+		return core.Image{ImageMeta: meta, Data: data}, nil
+	}
+
+	thumbPath := s.path(meta.Hash, true)
+	thumb, err := os.ReadFile(thumbPath)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return core.Image{}, fmt.Errorf("failed to read thumbnail: %w", err)
+		}
+		if thumb, err = s.makeThumbnail(data); err != nil {
+			return core.Image{}, err
+		}
+		if err := s.write(thumbPath, thumb); err != nil {
+			return core.Image{}, err
+		}
+	}
+
+	config, err := png.DecodeConfig(bytes.NewReader(thumb))
+	if err != nil {
+		return core.Image{}, fmt.Errorf("failed to decode thumbnail: %w", err)
+	}
+
+	return core.Image{
+		ImageMeta: core.ImageMeta{
+			Hash:        meta.Hash,
+			Size:        int64(len(thumb)),
+			Width:       config.Width,
+			Height:      config.Height,
+			ContentType: "image/png",
+		},
+		Data: thumb,
+	}, nil
+}
+
+// decodeConfig reads the dimensions without decoding pixels and rejects too large images
+func (s *Store) decodeConfig(data []byte) (image.Config, string, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return image.Config{}, "", fmt.Errorf("failed to decode image: %w", err)
+	}
+	if int64(config.Width)*int64(config.Height) > s.maxPixels {
+		return image.Config{}, "", fmt.Errorf("image %dx%d is larger than %d pixels",
+			config.Width, config.Height, s.maxPixels)
+	}
+	return config, format, nil
+}
+
+func (s *Store) makeThumbnail(data []byte) ([]byte, error) {
+	// images saved before the limit are checked again before the decode
+	if _, _, err := s.decodeConfig(data); err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, resize(img, s.thumbSize)); err != nil {
+		return nil, fmt.Errorf("failed to encode thumbnail: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *Store) path(hash string, thumbnail bool) string {
+	name := hash
+	if thumbnail {
+		name += ".thumb.png"
+	}
+	return filepath.Join(s.dir, hash[:2], name)
+}
+
+func (s *Store) write(path string, data []byte) error {
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create image directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create image file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write image: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write image: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to store image: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,295 @@
+package images
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"yadro.com/course/update/core"
+)
+
+func newTestLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 100, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestNewStore(t *testing.T) {
+	tests := []struct {
+		name      string
+		dir       string
+		maxSize   int64
+		thumbSize int
+		wantErr   bool
+	}{
+		{
+			name:      "valid store",
+			dir:       t.TempDir(),
+			maxSize:   1024,
+			thumbSize: 100,
+		},
+		{
+			name:      "empty dir",
+			dir:       "",
+			maxSize:   1024,
+			thumbSize: 100,
+			wantErr:   true,
+		},
+		{
+			name:      "zero max size",
+			dir:       t.TempDir(),
+			maxSize:   0,
+			thumbSize: 100,
+			wantErr:   true,
+		},
+		{
+			name:      "zero thumbnail size",
+			dir:       t.TempDir(),
+			maxSize:   1024,
+			thumbSize: 0,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := NewStore(tt.dir, tt.maxSize, 1<<20, tt.thumbSize, time.Second, newTestLogger())
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, store)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, store)
+		})
+	}
+}
+
+func TestStore_Save(t *testing.T) {
+	picture := encodePNG(t, 40, 20)
+
+	tests := []struct {
+		name      string
+		handler   http.HandlerFunc
+		maxSize   int64
+		maxPixels int64
+		expected  core.ImageMeta
+		wantErr   bool
+	}{
+		{
+			name: "successful save",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, err := w.Write(picture)
+				require.NoError(t, err)
+			},
+			maxSize: 1 << 20,
+			expected: core.ImageMeta{
+				Size:        int64(len(picture)),
+				Width:       40,
+				Height:      20,
+				ContentType: "image/png",
+			},
+		},
+		{
+			name: "not found",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			},
+			maxSize: 1 << 20,
+			wantErr: true,
+		},
+		{
+			name: "too large",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, err := w.Write(picture)
+				require.NoError(t, err)
+			},
+			maxSize: 10,
+			wantErr: true,
+		},
+		{
+			name: "too many pixels",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, err := w.Write(picture)
+				require.NoError(t, err)
+			},
+			maxSize:   1 << 20,
+			maxPixels: 40*20 - 1,
+			wantErr:   true,
+		},
+		{
+			name: "not an image",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, err := io.WriteString(w, "<html></html>")
+				require.NoError(t, err)
+			},
+			maxSize: 1 << 20,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			dir := t.TempDir()
+			maxPixels := tt.maxPixels
+			if maxPixels == 0 {
+				maxPixels = 1 << 20
+			}
+			store, err := NewStore(dir, tt.maxSize, maxPixels, 10, time.Second, newTestLogger())
+			require.NoError(t, err)
+
+			meta, err := store.Save(context.Background(), server.URL+"/comic.png")
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Len(t, meta.Hash, 64)
+			tt.expected.Hash = meta.Hash
+			assert.Equal(t, tt.expected, meta)
+
+			stored, err := os.ReadFile(filepath.Join(dir, meta.Hash[:2], meta.Hash))
+			require.NoError(t, err)
+			assert.Equal(t, picture, stored)
+		})
+	}
+}
+
+func TestStore_Load(t *testing.T) {
+	picture := encodePNG(t, 40, 20)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write(picture)
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	store, err := NewStore(dir, 1<<20, 1<<20, 10, time.Second, newTestLogger())
+	require.NoError(t, err)
+
+	meta, err := store.Save(context.Background(), server.URL)
+	require.NoError(t, err)
+
+	original, err := store.Load(context.Background(), meta, false)
+	require.NoError(t, err)
+	assert.Equal(t, picture, original.Data)
+	assert.Equal(t, meta, original.ImageMeta)
+
+	thumb, err := store.Load(context.Background(), meta, true)
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", thumb.ContentType)
+	assert.Equal(t, 10, thumb.Width)
+	assert.Equal(t, 5, thumb.Height)
+	assert.FileExists(t, filepath.Join(dir, meta.Hash[:2], meta.Hash+".thumb.png"))
+
+	cached, err := store.Load(context.Background(), meta, true)
+	require.NoError(t, err)
+	assert.Equal(t, thumb.Data, cached.Data)
+
+	_, err = store.Load(context.Background(), core.ImageMeta{Hash: "ffff"}, false)
+	assert.ErrorIs(t, err, core.ErrNotFound)
+}
+
+func TestResize(t *testing.T) {
+	tests := []struct {
+		name       string
+		width      int
+		height     int
+		maxSide    int
+		wantWidth  int
+		wantHeight int
+	}{
+		{
+			name:       "landscape",
+			width:      400,
+			height:     200,
+			maxSide:    100,
+			wantWidth:  100,
+			wantHeight: 50,
+		},
+		{
+			name:       "portrait",
+			width:      150,
+			height:     600,
+			maxSide:    200,
+			wantWidth:  50,
+			wantHeight: 200,
+		},
+		{
+			name:       "already small",
+			width:      50,
+			height:     30,
+			maxSide:    200,
+			wantWidth:  50,
+			wantHeight: 30,
+		},
+		{
+			name:       "very thin",
+			width:      1000,
+			height:     2,
+			maxSide:    100,
+			wantWidth:  100,
+			wantHeight: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := image.NewRGBA(image.Rect(0, 0, tt.width, tt.height))
+			resized := resize(img, tt.maxSide)
+			assert.Equal(t, tt.wantWidth, resized.Bounds().Dx())
+			assert.Equal(t, tt.wantHeight, resized.Bounds().Dy())
+		})
+	}
+}
+
+func TestStore_Load_TooManyPixels(t *testing.T) {
+	picture := encodePNG(t, 40, 20)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write(picture)
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	store, err := NewStore(dir, 1<<20, 1<<20, 10, time.Second, newTestLogger())
+	require.NoError(t, err)
+	meta, err := store.Save(context.Background(), server.URL)
+	require.NoError(t, err)
+
+	// сохранённое до уменьшения лимита изображение отдаётся, но превью из него не делается
+	strict, err := NewStore(dir, 1<<20, 100, 10, time.Second, newTestLogger())
+	require.NoError(t, err)
+	_, err = strict.Load(context.Background(), meta, false)
+	assert.NoError(t, err)
+	_, err = strict.Load(context.Background(), meta, true)
+	assert.Error(t, err)
+	assert.NoFileExists(t, filepath.Join(dir, meta.Hash[:2], meta.Hash+".thumb.png"))
+}
@@ -0,0 +1,157 @@
+package mirror
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"yadro.com/course/update/core"
+)
+
+const maxLineSize = 1 << 20
+
+// xkcdComic is a comic in the xkcd JSON format, the mirror serves it back as is
+type xkcdComic struct {
+	Num        int    `json:"num"`
+	Img        string `json:"img"`
+	Title      string `json:"title"`
+	SafeTitle  string `json:"safe_title"`
+	Alt        string `json:"alt"`
+	Transcript string `json:"transcript"`
+	Link       string `json:"link"`
+	News       string `json:"news"`
+	Year       string `json:"year"`
+	Month      string `json:"month"`
+	Day        string `json:"day"`
+}
+
+type Mirror struct {
+	id     string
+	log    *slog.Logger
+	comics map[int]xkcdComic
+	lastID int
+}
+
+func New(id, path string, log *slog.Logger) (*Mirror, error) {
+	if path == "" {
+		return nil, fmt.Errorf("empty mirror path specified")
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mirror: %w", err)
+	}
+
+	m := &Mirror{
+		id:     id,
+		log:    log,
+		comics: make(map[int]xkcdComic),
+	}
+	if info.IsDir() {
+		err = m.loadDir(path)
+	} else {
+		err = m.loadJSONLines(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info("mirror loaded", "path", path, "comics", len(m.comics), "last_id", m.lastID)
+	return m, nil
+}
+
+func (m *Mirror) loadDir(root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		var comic xkcdComic
+		if err := json.Unmarshal(data, &comic); err != nil {
+			return fmt.Errorf("failed to decode %s: %w", path, err)
+		}
+		return m.add(comic)
+	})
+}
+
+func (m *Mirror) loadJSONLines(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var comic xkcdComic
+		if err := json.Unmarshal([]byte(text), &comic); err != nil {
+			return fmt.Errorf("failed to decode %s:%d: %w", path, line, err)
+		}
+		if err := m.add(comic); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+	}
+	return scanner.Err()
+}
+
+func (m *Mirror) add(comic xkcdComic) error {
+	if comic.Num <= 0 {
+		return fmt.Errorf("comic without number")
+	}
+	m.comics[comic.Num] = comic
+	m.lastID = max(m.lastID, comic.Num)
+	return nil
+}
+
+func (m *Mirror) ID() string {
+	return m.id
+}
+
+func (m *Mirror) Get(_ context.Context, id int) (core.ComicInfo, error) {
+	comic, ok := m.comics[id]
+	if !ok {
+		m.log.Debug("comic is missing in mirror", "id", id)
+		return core.ComicInfo{}, core.ErrNotFound
+	}
+
+	return core.ComicInfo{
+		ID:         comic.Num,
+		URL:        comic.Img,
+		Title:      comic.Title,
+		Alt:        comic.Alt,
+		Transcript: comic.Transcript,
+	}, nil
+}
+
+func (m *Mirror) LastID(_ context.Context) (int, error) {
+	if m.lastID == 0 {
+		return 0, core.ErrNotFound
+	}
+	return m.lastID, nil
+}
+
+func (m *Mirror) Count(_ context.Context) (int, error) {
+	return len(m.comics), nil
+}
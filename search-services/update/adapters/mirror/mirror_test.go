@@ -0,0 +1,173 @@
+package mirror
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"yadro.com/course/update/adapters/xkcd"
+	"yadro.com/course/update/core"
+)
+
+func newTestLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name       string
+		setup      func(t *testing.T, dir string) string
+		wantLastID int
+		wantCount  int
+		wantErr    bool
+	}{
+		{
+			name: "directory in xkcd layout",
+			setup: func(t *testing.T, dir string) string {
+				writeFile(t, filepath.Join(dir, "1", "info.0.json"), `{"num": 1, "title": "Barrel"}`)
+				writeFile(t, filepath.Join(dir, "2", "info.0.json"), `{"num": 2, "title": "Petit Trees"}`)
+				return dir
+			},
+			wantLastID: 2,
+			wantCount:  2,
+		},
+		{
+			name: "flat directory",
+			setup: func(t *testing.T, dir string) string {
+				writeFile(t, filepath.Join(dir, "5.json"), `{"num": 5, "title": "Blown apart"}`)
+				writeFile(t, filepath.Join(dir, "README.txt"), `not a comic`)
+				return dir
+			},
+			wantLastID: 5,
+			wantCount:  1,
+		},
+		{
+			name: "json lines",
+			setup: func(t *testing.T, dir string) string {
+				path := filepath.Join(dir, "comics.jsonl")
+				writeFile(t, path, "{\"num\": 3, \"title\": \"Island\"}\n\n{\"num\": 7, \"title\": \"Girl sleeping\"}\n")
+				return path
+			},
+			wantLastID: 7,
+			wantCount:  2,
+		},
+		{
+			name: "broken json lines",
+			setup: func(t *testing.T, dir string) string {
+				path := filepath.Join(dir, "comics.jsonl")
+				writeFile(t, path, "{\"num\": 3}\nnot json\n")
+				return path
+			},
+			wantErr: true,
+		},
+		{
+			name: "comic without number",
+			setup: func(t *testing.T, dir string) string {
+				path := filepath.Join(dir, "comics.jsonl")
+				writeFile(t, path, "{\"title\": \"nameless\"}\n")
+				return path
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing path",
+			setup: func(t *testing.T, dir string) string {
+				return filepath.Join(dir, "missing")
+			},
+			wantErr: true,
+		},
+		{
+			name: "empty path",
+			setup: func(t *testing.T, dir string) string {
+				return ""
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := tt.setup(t, t.TempDir())
+
+			m, err := New("xkcd", path, newTestLogger())
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, m)
+				return
+			}
+
+			require.NoError(t, err)
+			lastID, err := m.LastID(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantLastID, lastID)
+
+			count, err := m.Count(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantCount, count)
+		})
+	}
+}
+
+func TestMirror_Get(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "comics.jsonl")
+	writeFile(t, path, `{"num": 1, "img": "https://imgs.xkcd.com/comics/barrel_cropped_(1).jpg", "title": "Barrel - Part 1", "alt": "Don't we all.", "transcript": "[[A boy sits in a barrel]]"}`)
+
+	m, err := New("xkcd", path, newTestLogger())
+	require.NoError(t, err)
+
+	info, err := m.Get(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, core.ComicInfo{
+		ID:         1,
+		URL:        "https://imgs.xkcd.com/comics/barrel_cropped_(1).jpg",
+		Title:      "Barrel - Part 1",
+		Alt:        "Don't we all.",
+		Transcript: "[[A boy sits in a barrel]]",
+	}, info)
+
+	_, err = m.Get(context.Background(), 2)
+	assert.ErrorIs(t, err, core.ErrNotFound)
+}
+
+func TestMirror_Handler(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "comics.jsonl")
+	writeFile(t, path, "{\"num\": 1, \"title\": \"Barrel\", \"alt\": \"Alt\", \"img\": \"http://example.com/1.png\"}\n{\"num\": 2, \"title\": \"Petit Trees\"}\n")
+
+	m, err := New("xkcd", path, newTestLogger())
+	require.NoError(t, err)
+
+	server := httptest.NewServer(m.Handler())
+	defer server.Close()
+
+	client, err := xkcd.NewClient("xkcd", server.URL, time.Second, 0, 0, 0, newTestLogger())
+	require.NoError(t, err)
+
+	lastID, err := client.LastID(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, lastID)
+
+	info, err := client.Get(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Barrel", info.Title)
+	assert.Equal(t, "Alt", info.Alt)
+	assert.Equal(t, "http://example.com/1.png", info.URL)
+
+	resp, err := http.Get(server.URL + "/3/info.0.json")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,39 @@
+package mirror
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+func (m *Mirror) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /info.0.json", func(w http.ResponseWriter, r *http.Request) {
+		m.writeComic(w, m.lastID)
+	})
+
+	mux.HandleFunc("GET /{id}/info.0.json", func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		m.writeComic(w, id)
+	})
+
+	return mux
+}
+
+func (m *Mirror) writeComic(w http.ResponseWriter, id int) {
+	comic, ok := m.comics[id]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(comic); err != nil {
+		m.log.Error("failed to encode comic", "id", id, "error", err)
+	}
+}
@@ -2,11 +2,13 @@ package words
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 	wordspb "yadro.com/course/proto/words"
+	"yadro.com/course/update/core"
 )
 
 type Client struct {
@@ -25,16 +27,30 @@ func NewClient(address string, log *slog.Logger) (*Client, error) {
 	}, nil
 }
 
-func (c Client) Norm(ctx context.Context, phrase string) ([]string, error) {
-	response, err := c.client.Norm(ctx, &wordspb.WordsRequest{Phrase: phrase})
+func (c Client) NormBatch(ctx context.Context, phrases []string) ([]core.NormResult, error) {
+	items := make([]*wordspb.WordsRequest, 0, len(phrases))
+	for _, phrase := range phrases {
+		items = append(items, &wordspb.WordsRequest{Phrase: phrase})
+	}
+
+	response, err := c.client.NormBatch(ctx, &wordspb.NormBatchRequest{Items: items})
 	if err != nil {
-		c.log.Error("failed to normalize words", "error", err)
+		c.log.Error("failed to normalize words", "count", len(phrases), "error", err)
 		return nil, err
 	}
 
-	c.log.Debug("words normalized", "words", response.Words)
+	results := make([]core.NormResult, 0, len(response.Results))
+	for _, result := range response.Results {
+		if result.Error != "" {
+			results = append(results, core.NormResult{Err: errors.New(result.Error)})
+			continue
+		}
+		results = append(results, core.NormResult{Words: result.Words})
+	}
+
+	c.log.Debug("words normalized", "count", len(results))
 
-	return response.Words, nil
+	return results, nil
 }
 
 func (c Client) Ping(ctx context.Context) error {
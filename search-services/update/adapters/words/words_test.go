@@ -12,6 +12,7 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/protobuf/types/known/emptypb"
 	wordspb "yadro.com/course/proto/words"
+	"yadro.com/course/update/core"
 )
 
 // Моки для protobuf типов
@@ -28,13 +29,13 @@ type Empty struct{}
 
 type mockWordsClient struct {
 	wordspb.WordsClient
-	normFunc func(ctx context.Context, in *wordspb.WordsRequest, opts ...grpc.CallOption) (*wordspb.WordsReply, error)
-	pingFunc func(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	normBatchFunc func(ctx context.Context, in *wordspb.NormBatchRequest, opts ...grpc.CallOption) (*wordspb.NormBatchReply, error)
+	pingFunc      func(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error)
 }
 
-func (m *mockWordsClient) Norm(ctx context.Context, in *wordspb.WordsRequest, opts ...grpc.CallOption) (*wordspb.WordsReply, error) {
-	if m.normFunc != nil {
-		return m.normFunc(ctx, in, opts...)
+func (m *mockWordsClient) NormBatch(ctx context.Context, in *wordspb.NormBatchRequest, opts ...grpc.CallOption) (*wordspb.NormBatchReply, error) {
+	if m.normBatchFunc != nil {
+		return m.normBatchFunc(ctx, in, opts...)
 	}
 	return nil, nil
 }
@@ -46,29 +47,42 @@ func (m *mockWordsClient) Ping(ctx context.Context, in *emptypb.Empty, opts ...g
 	return nil, nil
 }
 
-func TestClient_Norm(t *testing.T) {
+func TestClient_NormBatch(t *testing.T) {
 	tests := []struct {
 		name      string
-		phrase    string
-		words     []string
+		phrases   []string
+		results   []*wordspb.NormResult
 		normError error
+		want      []core.NormResult
 		wantError bool
 	}{
 		{
-			name:      "successful normalization",
-			phrase:    "test phrase",
-			words:     []string{"test", "phrase"},
-			wantError: false,
+			name:    "successful normalization",
+			phrases: []string{"test phrase", "other"},
+			results: []*wordspb.NormResult{
+				{Words: []string{"test", "phrase"}},
+				{Words: []string{"other"}},
+			},
+			want: []core.NormResult{
+				{Words: []string{"test", "phrase"}},
+				{Words: []string{"other"}},
+			},
 		},
 		{
-			name:      "empty phrase",
-			phrase:    "",
-			words:     []string{},
-			wantError: false,
+			name:    "item error",
+			phrases: []string{"test phrase", "too long"},
+			results: []*wordspb.NormResult{
+				{Words: []string{"test", "phrase"}},
+				{Error: "phrase is large than 20000"},
+			},
+			want: []core.NormResult{
+				{Words: []string{"test", "phrase"}},
+				{Err: errors.New("phrase is large than 20000")},
+			},
 		},
 		{
 			name:      "normalization error",
-			phrase:    "test phrase",
+			phrases:   []string{"test phrase"},
 			normError: errors.New("normalization error"),
 			wantError: true,
 		},
@@ -78,11 +92,15 @@ func TestClient_Norm(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			logger := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelDebug}))
 			mockClient := &mockWordsClient{
-				normFunc: func(ctx context.Context, in *wordspb.WordsRequest, opts ...grpc.CallOption) (*wordspb.WordsReply, error) {
+				normBatchFunc: func(ctx context.Context, in *wordspb.NormBatchRequest, opts ...grpc.CallOption) (*wordspb.NormBatchReply, error) {
 					if tt.normError != nil {
 						return nil, tt.normError
 					}
-					return &wordspb.WordsReply{Words: tt.words}, nil
+					assert.Len(t, in.Items, len(tt.phrases))
+					for i, item := range in.Items {
+						assert.Equal(t, tt.phrases[i], item.Phrase)
+					}
+					return &wordspb.NormBatchReply{Results: tt.results}, nil
 				},
 			}
 
@@ -91,15 +109,15 @@ func TestClient_Norm(t *testing.T) {
 				client: mockClient,
 			}
 
-			words, err := client.Norm(context.Background(), tt.phrase)
+			results, err := client.NormBatch(context.Background(), tt.phrases)
 			if tt.wantError {
 				assert.Error(t, err)
-				assert.Nil(t, words)
+				assert.Nil(t, results)
 				return
 			}
 
 			assert.NoError(t, err)
-			assert.Equal(t, tt.words, words)
+			assert.Equal(t, tt.want, results)
 		})
 	}
 }
@@ -0,0 +1,57 @@
+package xkcd
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// pacer spaces requests evenly, waiting for a turn stops with the context
+type pacer struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// newPacer allows rate requests a second, zero rate is unlimited
+func newPacer(rate int) *pacer {
+	p := &pacer{}
+	if rate > 0 {
+		p.interval = time.Second / time.Duration(rate)
+	}
+	return p
+}
+
+func (p *pacer) Wait(ctx context.Context) error {
+	if p.interval == 0 {
+		return ctx.Err()
+	}
+
+	p.mu.Lock()
+	now := time.Now()
+	at := p.next
+	if at.Before(now) {
+		at = now
+	}
+	p.next = at.Add(p.interval)
+	p.mu.Unlock()
+
+	delay := at.Sub(now)
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		// give the turn back unless a later one was already taken
+		p.mu.Lock()
+		if p.next.Equal(at.Add(p.interval)) {
+			p.next = at
+		}
+		p.mu.Unlock()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
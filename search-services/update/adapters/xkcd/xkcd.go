@@ -6,47 +6,83 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"sync"
 	"time"
 
 	"yadro.com/course/update/core"
 )
 
+const (
+	defaultRetryDelay = time.Second
+	maxRetryDelay     = time.Minute
+)
+
+type lastInfo struct {
+	id           int
+	etag         string
+	lastModified string
+	fetchedAt    time.Time
+}
+
 type Client struct {
-	log    *slog.Logger
-	client http.Client
-	url    string
+	id         string
+	log        *slog.Logger
+	client     http.Client
+	url        string
+	limiter    *pacer
+	cacheTTL   time.Duration
+	maxRetries int
+
+	// mu guards only the cached last id, requests are sent without it
+	mu   sync.Mutex
+	last lastInfo
 }
 
-func NewClient(url string, timeout time.Duration, log *slog.Logger) (*Client, error) {
+func NewClient(
+	id, url string, timeout time.Duration, rate int, cacheTTL time.Duration, maxRetries int, log *slog.Logger,
+) (*Client, error) {
 	if url == "" {
 		return nil, fmt.Errorf("empty base url specified")
 	}
+	if maxRetries < 0 {
+		return nil, fmt.Errorf("wrong max retries specified")
+	}
+
 	return &Client{
-		client: http.Client{Timeout: timeout},
-		log:    log,
-		url:    url,
+		id:         id,
+		client:     http.Client{Timeout: timeout},
+		log:        log,
+		url:        url,
+		limiter:    newPacer(rate),
+		cacheTTL:   cacheTTL,
+		maxRetries: maxRetries,
 	}, nil
 }
 
-func (c Client) Get(ctx context.Context, id int) (core.XKCDInfo, error) {
+func (c *Client) ID() string {
+	return c.id
+}
+
+func (c *Client) Get(ctx context.Context, id int) (core.ComicInfo, error) {
 	if id == 404 {
 		c.log.Debug("skipping special comic 404")
-		return core.XKCDInfo{
-			ID:          id,
-			Description: "Not found",
-			Title:       "404",
+		return core.ComicInfo{
+			ID:    id,
+			Alt:   "Not found",
+			Title: "404",
 		}, nil
 	}
-	resp, err := c.client.Get(c.url + fmt.Sprintf("/%d/info.0.json", id))
+	resp, err := c.do(ctx, c.url+fmt.Sprintf("/%d/info.0.json", id), nil)
 	if err != nil {
 		c.log.Error("failed to get comic", "id", id, "error", err)
-		return core.XKCDInfo{}, core.ErrNotFound
+		return core.ComicInfo{}, fmt.Errorf("%w: %w", core.ErrTransport, err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		c.log.Error("unexpected status code", "status", resp.StatusCode)
-		return core.XKCDInfo{}, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	if err := statusError(resp.StatusCode); err != nil {
+		c.log.Error("unexpected status code", "id", id, "status", resp.StatusCode)
+		return core.ComicInfo{}, err
 	}
 
 	info := struct {
@@ -54,31 +90,70 @@ func (c Client) Get(ctx context.Context, id int) (core.XKCDInfo, error) {
 		URL        string `json:"img"`
 		Title      string `json:"title"`
 		Alt        string `json:"alt"`
-		SafeTitle  string `json:"safe_title"`
 		Transcript string `json:"transcript"`
 	}{}
 	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
-		c.log.Error("failed to decode comic", "error", err)
-		return core.XKCDInfo{}, fmt.Errorf("failed to decode comic: %w", err)
+		c.log.Error("failed to decode comic", "id", id, "error", err)
+		return core.ComicInfo{}, fmt.Errorf("%w: comic %d: %w", core.ErrDecode, id, err)
 	}
 
 	c.log.Debug("got comic", "id", id, "title", info.Title)
-	return core.XKCDInfo{
-		ID:          info.ID,
-		URL:         info.URL,
-		Title:       info.Title,
-		Description: info.Transcript + " " + info.Alt + " " + info.SafeTitle,
+	return core.ComicInfo{
+		ID:         info.ID,
+		URL:        info.URL,
+		Title:      info.Title,
+		Alt:        info.Alt,
+		Transcript: info.Transcript,
 	}, nil
 }
 
-func (c Client) LastID(ctx context.Context) (int, error) {
-	resp, err := c.client.Get(c.url + "/info.0.json")
+// Count is the last id, xkcd numbers comics one after another
+func (c *Client) Count(ctx context.Context) (int, error) {
+	return c.LastID(ctx)
+}
+
+func (c *Client) LastID(ctx context.Context) (int, error) {
+	c.mu.Lock()
+	last := c.last
+	c.mu.Unlock()
+
+	if last.id > 0 && time.Since(last.fetchedAt) < c.cacheTTL {
+		c.log.Debug("last id from cache", "id", last.id)
+		return last.id, nil
+	}
+
+	header := http.Header{}
+	if last.id > 0 {
+		if last.etag != "" {
+			header.Set("If-None-Match", last.etag)
+		}
+		if last.lastModified != "" {
+			header.Set("If-Modified-Since", last.lastModified)
+		}
+	}
+
+	resp, err := c.do(ctx, c.url+"/info.0.json", header)
 	if err != nil {
 		c.log.Error("failed to get last id", "error", err)
-		return 0, fmt.Errorf("failed to get last id: %w", err)
+		return 0, fmt.Errorf("%w: %w", core.ErrTransport, err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified && last.id > 0 {
+		c.mu.Lock()
+		if c.last.id == last.id {
+			c.last.fetchedAt = time.Now()
+		}
+		c.mu.Unlock()
+		c.log.Debug("last id not modified", "id", last.id)
+		return last.id, nil
+	}
+
+	if err := statusError(resp.StatusCode); err != nil {
+		c.log.Error("unexpected status code", "status", resp.StatusCode)
+		return 0, err
+	}
+
 	var info struct {
 		Num int `json:"num"`
 	}
@@ -86,10 +161,81 @@ func (c Client) LastID(ctx context.Context) (int, error) {
 	err = json.NewDecoder(resp.Body).Decode(&info)
 	if err != nil {
 		c.log.Error("failed to decode last id", "error", err)
-		return 0, fmt.Errorf("failed to decode last id: %w", err)
+		return 0, fmt.Errorf("%w: last id: %w", core.ErrDecode, err)
 	}
 
+	c.mu.Lock()
+	c.last = lastInfo{
+		id:           info.Num,
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+		fetchedAt:    time.Now(),
+	}
+	c.mu.Unlock()
 	c.log.Debug("last id", "id", info.Num)
 
 	return info.Num, nil
 }
+
+// do sends a rate limited GET and retries it while xkcd.com answers 429 or 503.
+func (c *Client) do(ctx context.Context, url string, header http.Header) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		for key, values := range header {
+			req.Header[key] = values
+		}
+
+		if err := c.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable ||
+			attempt >= c.maxRetries {
+			return resp, nil
+		}
+
+		delay := retryAfter(resp.Header.Get("Retry-After"))
+		resp.Body.Close()
+		c.log.Warn("xkcd asked to retry later", "url", url, "status", resp.StatusCode, "delay", delay)
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// statusError stops the update only when the source is in trouble (5xx),
+// other client errors are about the comic itself and it is skipped
+func statusError(code int) error {
+	switch {
+	case code == http.StatusOK:
+		return nil
+	case code == http.StatusTooManyRequests, code == http.StatusServiceUnavailable:
+		return fmt.Errorf("%w: unexpected status code: %d", core.ErrRateLimited, code)
+	case code >= 400 && code < 500:
+		return fmt.Errorf("%w: unexpected status code: %d", core.ErrNotFound, code)
+	}
+	return fmt.Errorf("%w: unexpected status code: %d", core.ErrTransport, code)
+}
+
+func retryAfter(value string) time.Duration {
+	if value == "" {
+		return defaultRetryDelay
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return min(time.Duration(seconds)*time.Second, maxRetryDelay)
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return min(max(time.Until(date), 0), maxRetryDelay)
+	}
+	return defaultRetryDelay
+}
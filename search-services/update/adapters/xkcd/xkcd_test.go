@@ -2,15 +2,16 @@ package xkcd
 
 import (
 	"context"
-	"errors"
 	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"yadro.com/course/update/core"
 )
 
@@ -20,9 +21,10 @@ func newTestLogger() *slog.Logger {
 
 func TestNewClient(t *testing.T) {
 	tests := []struct {
-		name    string
-		url     string
-		wantErr bool
+		name       string
+		url        string
+		maxRetries int
+		wantErr    bool
 	}{
 		{
 			name:    "valid url",
@@ -34,11 +36,17 @@ func TestNewClient(t *testing.T) {
 			url:     "",
 			wantErr: true,
 		},
+		{
+			name:       "negative retries",
+			url:        "http://example.com",
+			maxRetries: -1,
+			wantErr:    true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			client, err := NewClient(tt.url, time.Second, newTestLogger())
+			client, err := NewClient("xkcd", tt.url, time.Second, 0, 0, tt.maxRetries, newTestLogger())
 			if tt.wantErr {
 				assert.Error(t, err)
 				assert.Nil(t, client)
@@ -56,7 +64,7 @@ func TestClient_Get(t *testing.T) {
 		name        string
 		id          int
 		handler     http.HandlerFunc
-		expected    core.XKCDInfo
+		expected    core.ComicInfo
 		expectedErr error
 	}{
 		{
@@ -76,11 +84,12 @@ func TestClient_Get(t *testing.T) {
 					t.Fatal(err)
 				}
 			},
-			expected: core.XKCDInfo{
-				ID:          1,
-				URL:         "http://example.com/1.png",
-				Title:       "Test Comic",
-				Description: "Transcript Alt text Safe Title",
+			expected: core.ComicInfo{
+				ID:         1,
+				URL:        "http://example.com/1.png",
+				Title:      "Test Comic",
+				Alt:        "Alt text",
+				Transcript: "Transcript",
 			},
 			expectedErr: nil,
 		},
@@ -90,10 +99,10 @@ func TestClient_Get(t *testing.T) {
 			handler: func(w http.ResponseWriter, r *http.Request) {
 				w.WriteHeader(http.StatusNotFound)
 			},
-			expected: core.XKCDInfo{
-				ID:          404,
-				Description: "Not found",
-				Title:       "404",
+			expected: core.ComicInfo{
+				ID:    404,
+				Alt:   "Not found",
+				Title: "404",
 			},
 			expectedErr: nil,
 		},
@@ -103,8 +112,23 @@ func TestClient_Get(t *testing.T) {
 			handler: func(w http.ResponseWriter, r *http.Request) {
 				w.WriteHeader(http.StatusNotFound)
 			},
-			expected:    core.XKCDInfo{},
-			expectedErr: errors.New("unexpected status code: 404"),
+			expectedErr: core.ErrNotFound,
+		},
+		{
+			name: "forbidden comic is skipped",
+			id:   7,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusForbidden)
+			},
+			expectedErr: core.ErrNotFound,
+		},
+		{
+			name: "gone comic is skipped",
+			id:   7,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusGone)
+			},
+			expectedErr: core.ErrNotFound,
 		},
 		{
 			name: "invalid json",
@@ -116,8 +140,39 @@ func TestClient_Get(t *testing.T) {
 					t.Fatal(err)
 				}
 			},
-			expected:    core.XKCDInfo{},
-			expectedErr: errors.New("failed to decode comic"),
+			expectedErr: core.ErrDecode,
+		},
+		{
+			name: "rate limited",
+			id:   1,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusTooManyRequests)
+			},
+			expectedErr: core.ErrRateLimited,
+		},
+		{
+			name: "service unavailable",
+			id:   1,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+			expectedErr: core.ErrRateLimited,
+		},
+		{
+			name: "server error",
+			id:   1,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+			expectedErr: core.ErrTransport,
+		},
+		{
+			name: "bad gateway",
+			id:   1,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadGateway)
+			},
+			expectedErr: core.ErrTransport,
 		},
 	}
 
@@ -126,14 +181,13 @@ func TestClient_Get(t *testing.T) {
 			server := httptest.NewServer(tt.handler)
 			defer server.Close()
 
-			client, err := NewClient(server.URL, time.Second, newTestLogger())
+			client, err := NewClient("xkcd", server.URL, time.Second, 0, 0, 0, newTestLogger())
 			assert.NoError(t, err)
 
 			info, err := client.Get(context.Background(), tt.id)
 
 			if tt.expectedErr != nil {
-				assert.Error(t, err)
-				assert.Contains(t, err.Error(), tt.expectedErr.Error())
+				assert.ErrorIs(t, err, tt.expectedErr)
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, tt.expected, info)
@@ -142,6 +196,46 @@ func TestClient_Get(t *testing.T) {
 	}
 }
 
+func TestClient_Get_Transport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := server.URL
+	server.Close()
+
+	client, err := NewClient("xkcd", url, time.Second, 0, 0, 0, newTestLogger())
+	require.NoError(t, err)
+
+	_, err = client.Get(context.Background(), 1)
+	assert.ErrorIs(t, err, core.ErrTransport)
+
+	_, err = client.LastID(context.Background())
+	assert.ErrorIs(t, err, core.ErrTransport)
+}
+
+func TestClient_Get_Canceled(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client, err := NewClient("xkcd", server.URL, 10*time.Second, 0, 0, 0, newTestLogger())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// запрос должен прерваться по дедлайну контекста, а не по таймауту клиента
+	start := time.Now()
+	_, err = client.Get(ctx, 1)
+	assert.ErrorIs(t, err, core.ErrTransport)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
 func TestClient_LastID(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -170,16 +264,21 @@ func TestClient_LastID(t *testing.T) {
 					t.Fatal(err)
 				}
 			},
-			expected:    0,
-			expectedErr: errors.New("failed to decode last id"),
+			expectedErr: core.ErrDecode,
 		},
 		{
 			name: "server error",
 			handler: func(w http.ResponseWriter, r *http.Request) {
 				w.WriteHeader(http.StatusInternalServerError)
 			},
-			expected:    0,
-			expectedErr: errors.New("failed to decode last id: EOF"),
+			expectedErr: core.ErrTransport,
+		},
+		{
+			name: "rate limited",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusTooManyRequests)
+			},
+			expectedErr: core.ErrRateLimited,
 		},
 	}
 
@@ -188,14 +287,13 @@ func TestClient_LastID(t *testing.T) {
 			server := httptest.NewServer(tt.handler)
 			defer server.Close()
 
-			client, err := NewClient(server.URL, time.Second, newTestLogger())
+			client, err := NewClient("xkcd", server.URL, time.Second, 0, 0, 0, newTestLogger())
 			assert.NoError(t, err)
 
 			id, err := client.LastID(context.Background())
 
 			if tt.expectedErr != nil {
-				assert.Error(t, err)
-				assert.Contains(t, err.Error(), tt.expectedErr.Error())
+				assert.ErrorIs(t, err, tt.expectedErr)
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, tt.expected, id)
@@ -203,3 +301,179 @@ func TestClient_LastID(t *testing.T) {
 		})
 	}
 }
+
+func TestClient_LastID_Cache(t *testing.T) {
+	var requests, notModified atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			assert.Equal(t, "Mon, 02 Jan 2006 15:04:05 GMT", r.Header.Get("If-Modified-Since"))
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		_, err := io.WriteString(w, `{"num": 100}`)
+		assert.NoError(t, err)
+	}))
+	defer server.Close()
+
+	client, err := NewClient("xkcd", server.URL, time.Second, 0, 50*time.Millisecond, 0, newTestLogger())
+	require.NoError(t, err)
+
+	for range 3 {
+		id, err := client.LastID(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 100, id)
+	}
+	// в пределах TTL запрос уходит только один раз
+	assert.Equal(t, int32(1), requests.Load())
+
+	time.Sleep(60 * time.Millisecond)
+
+	id, err := client.LastID(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 100, id)
+	assert.Equal(t, int32(2), requests.Load())
+	assert.Equal(t, int32(1), notModified.Load())
+}
+
+func TestClient_RetryAfter(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		failures   int32
+		maxRetries int
+		wantErr    bool
+	}{
+		{
+			name:       "retry after 429",
+			status:     http.StatusTooManyRequests,
+			failures:   2,
+			maxRetries: 3,
+		},
+		{
+			name:       "retry after 503",
+			status:     http.StatusServiceUnavailable,
+			failures:   1,
+			maxRetries: 1,
+		},
+		{
+			name:       "retries exhausted",
+			status:     http.StatusTooManyRequests,
+			failures:   3,
+			maxRetries: 2,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if requests.Add(1) <= tt.failures {
+					w.Header().Set("Retry-After", "0")
+					w.WriteHeader(tt.status)
+					return
+				}
+				_, err := io.WriteString(w, `{"num": 7, "title": "Retried"}`)
+				assert.NoError(t, err)
+			}))
+			defer server.Close()
+
+			client, err := NewClient("xkcd", server.URL, time.Second, 0, 0, tt.maxRetries, newTestLogger())
+			require.NoError(t, err)
+
+			info, err := client.Get(context.Background(), 7)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, core.ErrRateLimited)
+				assert.Equal(t, int32(tt.maxRetries+1), requests.Load())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "Retried", info.Title)
+			assert.Equal(t, tt.failures+1, requests.Load())
+		})
+	}
+}
+
+func TestClient_RetryAfter_Canceled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client, err := NewClient("xkcd", server.URL, time.Second, 0, 0, 3, newTestLogger())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = client.LastID(ctx)
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected time.Duration
+	}{
+		{name: "empty", value: "", expected: defaultRetryDelay},
+		{name: "seconds", value: "5", expected: 5 * time.Second},
+		{name: "too long", value: "86400", expected: maxRetryDelay},
+		{name: "past date", value: "Mon, 02 Jan 2006 15:04:05 GMT", expected: 0},
+		{name: "garbage", value: "soon", expected: defaultRetryDelay},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, retryAfter(tt.value))
+		})
+	}
+}
+
+func TestClient_Rate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := io.WriteString(w, `{"num": 1}`)
+		assert.NoError(t, err)
+	}))
+	defer server.Close()
+
+	client, err := NewClient("xkcd", server.URL, time.Second, 20, 0, 0, newTestLogger())
+	require.NoError(t, err)
+
+	start := time.Now()
+	for range 5 {
+		_, err := client.Get(context.Background(), 1)
+		require.NoError(t, err)
+	}
+	// 20 запросов в секунду — между запросами не меньше 50мс
+	assert.GreaterOrEqual(t, time.Since(start), 180*time.Millisecond)
+}
+
+func TestClient_Rate_Canceled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := io.WriteString(w, `{"num": 1}`)
+		assert.NoError(t, err)
+	}))
+	defer server.Close()
+
+	client, err := NewClient("xkcd", server.URL, time.Second, 1, 0, 0, newTestLogger())
+	require.NoError(t, err)
+
+	_, err = client.Get(context.Background(), 1)
+	require.NoError(t, err)
+
+	// следующий запрос ждёт секунду, но отмена прерывает ожидание
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = client.Get(ctx, 1)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
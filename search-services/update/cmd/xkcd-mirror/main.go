@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+
+	"yadro.com/course/update/adapters/mirror"
+)
+
+func main() {
+	var dumpPath, address string
+	flag.StringVar(&dumpPath, "dump", "comics.jsonl", "directory or JSON-lines file with XKCD comics")
+	flag.StringVar(&address, "address", ":8080", "address to serve XKCD JSON API on")
+	flag.Parse()
+
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+	comics, err := mirror.New("xkcd", dumpPath, log)
+	if err != nil {
+		log.Error("failed to load mirror", "error", err)
+		os.Exit(1)
+	}
+
+	server := http.Server{
+		Addr:    address,
+		Handler: comics.Handler(),
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+		if err := server.Shutdown(context.Background()); err != nil {
+			log.Error("erroneous shutdown", "error", err)
+		}
+	}()
+
+	log.Info("serving XKCD mirror", "address", address)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Error("server closed unexpectedly", "error", err)
+		os.Exit(1)
+	}
+}
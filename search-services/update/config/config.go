@@ -8,18 +8,44 @@ import (
 )
 
 type XKCD struct {
+	Source      string        `yaml:"source" env:"XKCD_SOURCE" env-default:"http"`
+	MirrorPath  string        `yaml:"mirror_path" env:"XKCD_MIRROR_PATH"`
 	URL         string        `yaml:"url" env:"XKCD_URL" env-default:"xkcd.com"`
 	Concurrency int           `yaml:"concurrency" env:"XKCD_CONCURRENCY" env-default:"1"`
 	Timeout     time.Duration `yaml:"timeout" env:"XKCD_TIMEOUT" env-default:"10s"`
 	CheckPeriod time.Duration `yaml:"check_period" env:"XKCD_CHECK_PERIOD" env-default:"1h"`
+	Rate        int           `yaml:"rate" env:"XKCD_RATE" env-default:"10"`
+	CacheTTL    time.Duration `yaml:"cache_ttl" env:"XKCD_CACHE_TTL" env-default:"1m"`
+	MaxRetries  int           `yaml:"max_retries" env:"XKCD_MAX_RETRIES" env-default:"3"`
+}
+
+// Source is an entry of the sources list, type is one of http (xkcd.com API),
+// mirror, jsonfeed or rss. Empty timeout falls back to xkcd.timeout.
+type Source struct {
+	ID      string        `yaml:"id"`
+	Type    string        `yaml:"type"`
+	URL     string        `yaml:"url"`
+	Path    string        `yaml:"path"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+type Images struct {
+	Enabled       bool   `yaml:"enabled" env:"IMAGES_ENABLED" env-default:"false"`
+	Dir           string `yaml:"dir" env:"IMAGES_DIR" env-default:"images"`
+	MaxSize       int64  `yaml:"max_size" env:"IMAGES_MAX_SIZE" env-default:"10485760"`
+	MaxPixels     int64  `yaml:"max_pixels" env:"IMAGES_MAX_PIXELS" env-default:"25000000"`
+	ThumbnailSize int    `yaml:"thumbnail_size" env:"IMAGES_THUMBNAIL_SIZE" env-default:"200"`
 }
 
 type Config struct {
-	LogLevel     string `yaml:"log_level" env:"LOG_LEVEL" env-default:"DEBUG"`
-	Address      string `yaml:"update_address" env:"UPDATE_ADDRESS" env-default:"localhost:80"`
-	XKCD         XKCD   `yaml:"xkcd"`
-	DBAddress    string `yaml:"db_address" env:"DB_ADDRESS" env-default:"localhost:82"`
-	WordsAddress string `yaml:"words_address" env:"WORDS_ADDRESS" env-default:"localhost:81"`
+	LogLevel          string   `yaml:"log_level" env:"LOG_LEVEL" env-default:"DEBUG"`
+	Address           string   `yaml:"update_address" env:"UPDATE_ADDRESS" env-default:"localhost:80"`
+	XKCD              XKCD     `yaml:"xkcd"`
+	Sources           []Source `yaml:"sources"`
+	Images            Images   `yaml:"images"`
+	DBAddress         string   `yaml:"db_address" env:"DB_ADDRESS" env-default:"localhost:82"`
+	WordsAddress      string   `yaml:"words_address" env:"WORDS_ADDRESS" env-default:"localhost:81"`
+	NormalizerVersion string   `yaml:"normalizer_version" env:"NORMALIZER_VERSION" env-default:"5"`
 }
 
 func MustLoad(configPath string) Config {
@@ -29,5 +55,13 @@ func MustLoad(configPath string) Config {
 			log.Fatalf("cannot read config %q: %s", configPath, err)
 		}
 	}
+	if len(cfg.Sources) == 0 {
+		cfg.Sources = []Source{{
+			ID:   "xkcd",
+			Type: cfg.XKCD.Source,
+			URL:  cfg.XKCD.URL,
+			Path: cfg.XKCD.MirrorPath,
+		}}
+	}
 	return cfg
 }
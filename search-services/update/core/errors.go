@@ -5,9 +5,16 @@ import "errors"
 var ErrBadArguments = errors.New("arguments are not acceptable")
 var ErrAlreadyExists = errors.New("resource or task already exists")
 var ErrNotFound = errors.New("resource is not found")
+var ErrDecode = errors.New("failed to decode response")
+var ErrTransport = errors.New("failed to reach comics source")
+var ErrRateLimited = errors.New("comics source rate limit exceeded")
 
 var ErrComicsCount = errors.New("failed to get total count comics")
 var ErrGetDBStats = errors.New("failed to get db stats")
 var ErrGetDownloadedComics = errors.New("failed to get downloaded comics")
 var ErrReadComic = errors.New("failed to read comic")
 var ErrTruncateTable = errors.New("failed to truncate table")
+var ErrReadRawText = errors.New("failed to read stored comic text")
+var ErrReadImage = errors.New("failed to read comic image")
+var ErrNormalize = errors.New("failed to normalize comics")
+var ErrUpdateWords = errors.New("failed to update comic words")
@@ -1,5 +1,7 @@
 package core
 
+import "time"
+
 type ServiceStatus string
 
 const (
@@ -16,17 +18,55 @@ type DBStats struct {
 type ServiceStats struct {
 	DBStats
 	ComicsTotal int
+	ComicsStale int
 }
 
 type Comics struct {
-	ID    int
-	URL   string
-	Words []string
+	ID                int
+	Source            string
+	URL               string
+	Title             string
+	Alt               string
+	Transcript        string
+	Words             []string
+	NormalizerVersion string
+	Image             ImageMeta
+}
+
+func (c Comics) Text() string {
+	return c.Title + " " + c.Transcript + " " + c.Alt
 }
 
-type XKCDInfo struct {
-	ID          int
-	URL         string
-	Title       string
-	Description string
+type ComicInfo struct {
+	ID         int
+	URL        string
+	Title      string
+	Alt        string
+	Transcript string
+}
+
+type ReindexFilter struct {
+	Source    string
+	FromID    int
+	ToID      int
+	OlderThan time.Time
+}
+
+type ImageMeta struct {
+	Hash        string
+	Size        int64
+	Width       int
+	Height      int
+	ContentType string
+}
+
+type Image struct {
+	ImageMeta
+	Data []byte
+}
+
+// NormResult is the normalization of a single phrase from a batch
+type NormResult struct {
+	Words []string
+	Err   error
 }
@@ -9,20 +9,45 @@ type Updater interface {
 	Stats(context.Context) (ServiceStats, error)
 	Status(context.Context) ServiceStatus
 	Drop(context.Context) error
+	Reindex(context.Context, ReindexFilter) error
+	Renormalize(ctx context.Context, onlyStale bool) error
+	Image(ctx context.Context, source string, id int, thumbnail bool) (Image, error)
 }
 
 type DB interface {
 	Add(context.Context, Comics) error
 	Stats(context.Context) (DBStats, error)
 	Drop(context.Context) error
-	IDs(context.Context) ([]int, error)
+	IDs(ctx context.Context, source string) ([]int, error)
+	FilteredIDs(ctx context.Context, source string, filter ReindexFilter) ([]int, error)
+	RawBatch(ctx context.Context, afterSource string, afterID, limit int, staleFor string) ([]Comics, error)
+	UpdateWords(ctx context.Context, source string, id int, words []string, version string) error
+	StaleCount(ctx context.Context, version string) (int, error)
+	ImageMeta(ctx context.Context, source string, id int) (ImageMeta, error)
 }
 
-type XKCD interface {
-	Get(context.Context, int) (XKCDInfo, error)
+// Source is a webcomic with sequentially numbered comics, e.g. xkcd.com or a feed.
+type Source interface {
+	ID() string
+	Get(context.Context, int) (ComicInfo, error)
 	LastID(context.Context) (int, error)
+	// Count returns how many comics the source has, numbers may have gaps
+	Count(context.Context) (int, error)
+}
+
+// Keys numbers comics of sources without sequential ids, such as feed items keyed by guid
+type Keys interface {
+	// Key returns the number of the key, an unseen key gets the next number of the source
+	Key(ctx context.Context, source, key string) (int, error)
+	KeyCount(ctx context.Context, source string) (int, error)
 }
 
 type Words interface {
-	Norm(ctx context.Context, phrase string) ([]string, error)
+	// NormBatch returns a result per phrase in the same order
+	NormBatch(ctx context.Context, phrases []string) ([]NormResult, error)
+}
+
+type Images interface {
+	Save(ctx context.Context, url string) (ImageMeta, error)
+	Load(ctx context.Context, meta ImageMeta, thumbnail bool) (Image, error)
 }
@@ -2,69 +2,163 @@ package core
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"sync"
 )
 
+const (
+	renormalizeBatchSize = 100
+	normBatchSize        = 50
+)
+
 type Service struct {
-	log         *slog.Logger
-	db          DB
-	xkcd        XKCD
-	words       Words
-	concurrency int
-	mutex       sync.Mutex
+	log               *slog.Logger
+	db                DB
+	sources           []Source
+	words             Words
+	images            Images
+	concurrency       int
+	normalizerVersion string
+	mutex             sync.Mutex
 }
 
 func NewService(
-	log *slog.Logger, db DB, xkcd XKCD, words Words, concurrency int,
+	log *slog.Logger, db DB, sources []Source, words Words, images Images, concurrency int, normalizerVersion string,
 ) (*Service, error) {
 	if concurrency < 1 {
 		return nil, fmt.Errorf("wrong concurrency specified: %d", concurrency)
 	}
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no comic sources specified")
+	}
+	seen := make(map[string]struct{}, len(sources))
+	for _, src := range sources {
+		if src.ID() == "" {
+			return nil, fmt.Errorf("empty source id specified")
+		}
+		if _, ok := seen[src.ID()]; ok {
+			return nil, fmt.Errorf("duplicate source id: %s", src.ID())
+		}
+		seen[src.ID()] = struct{}{}
+	}
+	if normalizerVersion == "" {
+		return nil, fmt.Errorf("empty normalizer version specified")
+	}
 	return &Service{
-		log:         log,
-		db:          db,
-		xkcd:        xkcd,
-		words:       words,
-		concurrency: concurrency,
-		mutex:       sync.Mutex{},
+		log:               log,
+		db:                db,
+		sources:           sources,
+		words:             words,
+		images:            images,
+		concurrency:       concurrency,
+		normalizerVersion: normalizerVersion,
+		mutex:             sync.Mutex{},
 	}, nil
 }
 
-func (s *Service) processComic(ctx context.Context, i int, semaphore chan struct{}, wg *sync.WaitGroup) {
-	semaphore <- struct{}{}
-	defer func() {
-		<-semaphore
-		wg.Done()
-	}()
-
-	s.log.Debug("downloading comic", "id", i)
-	comicsInfo, err := s.xkcd.Get(ctx, i)
+// processComic downloads a comic with its image and passes it on to be stored
+func (s *Service) processComic(ctx context.Context, src Source, i int, out chan<- Comics) error {
+	s.log.Debug("downloading comic", "source", src.ID(), "id", i)
+	comicsInfo, err := src.Get(ctx, i)
 	if err != nil {
-		s.log.Error("failed to get comic", "error", err)
-		return
+		switch {
+		case errors.Is(err, ErrNotFound):
+			s.log.Debug("comic not found, skipping", "source", src.ID(), "id", i)
+			return nil
+		case errors.Is(err, ErrRateLimited), errors.Is(err, ErrTransport):
+			return err
+		}
+		s.log.Error("failed to get comic", "source", src.ID(), "id", i, "error", err)
+		return nil
 	}
 
-	description := comicsInfo.Title + " " + comicsInfo.Description
+	comic := Comics{
+		ID:                i,
+		Source:            src.ID(),
+		URL:               comicsInfo.URL,
+		Title:             comicsInfo.Title,
+		Alt:               comicsInfo.Alt,
+		Transcript:        comicsInfo.Transcript,
+		NormalizerVersion: s.normalizerVersion,
+	}
 
-	normedDescription, err := s.words.Norm(ctx, description)
-	if err != nil {
-		s.log.Error("failed to normalize words", "error", err)
-		return
+	if s.images != nil && comic.URL != "" {
+		comic.Image, err = s.images.Save(ctx, comic.URL)
+		if err != nil {
+			s.log.Error("failed to save comic image", "id", i, "url", comic.URL, "error", err)
+		}
 	}
 
-	err = s.db.Add(ctx, Comics{
-		ID:    i,
-		URL:   comicsInfo.URL,
-		Words: normedDescription,
-	})
+	out <- comic
+	return nil
+}
+
+// store normalizes the downloaded comics in batches and saves them.
+// When a batch can't be normalized the download is aborted and the rest
+// of the comics are drained, they would not be normalized either.
+func (s *Service) store(ctx context.Context, comics <-chan Comics, abort func()) error {
+	var storeErr error
+	batch := make([]Comics, 0, normBatchSize)
+	flush := func() {
+		if storeErr == nil {
+			if storeErr = s.storeBatch(ctx, batch); storeErr != nil {
+				abort()
+			}
+		}
+		batch = batch[:0]
+	}
+	for comic := range comics {
+		batch = append(batch, comic)
+		if len(batch) == normBatchSize {
+			flush()
+		}
+	}
+	if len(batch) > 0 {
+		flush()
+	}
+	return storeErr
+}
+
+func (s *Service) storeBatch(ctx context.Context, batch []Comics) error {
+	results, err := s.normBatch(ctx, batch)
 	if err != nil {
-		s.log.Error("failed to add comic", "error", err)
-		return
+		return err
 	}
 
-	s.log.Debug("added comic", "id", i)
+	for i, comic := range batch {
+		if results[i].Err != nil {
+			s.log.Error("failed to normalize words", "source", comic.Source, "id", comic.ID, "error", results[i].Err)
+			continue
+		}
+		comic.Words = results[i].Words
+
+		if err := s.db.Add(ctx, comic); err != nil {
+			s.log.Error("failed to add comic", "source", comic.Source, "id", comic.ID, "error", err)
+			continue
+		}
+		s.log.Debug("added comic", "source", comic.Source, "id", comic.ID)
+	}
+	return nil
+}
+
+// normBatch normalizes texts of the comics in a single words service call
+func (s *Service) normBatch(ctx context.Context, batch []Comics) ([]NormResult, error) {
+	texts := make([]string, 0, len(batch))
+	for _, comic := range batch {
+		texts = append(texts, comic.Text())
+	}
+
+	results, err := s.words.NormBatch(ctx, texts)
+	if err == nil && len(results) != len(batch) {
+		err = fmt.Errorf("got %d normalization results for %d comics", len(results), len(batch))
+	}
+	if err != nil {
+		s.log.Error("failed to normalize words", "count", len(batch), "error", err)
+		return nil, fmt.Errorf("%w: %v", ErrNormalize, err)
+	}
+	return results, nil
 }
 
 func (s *Service) Update(ctx context.Context) (err error) {
@@ -76,15 +170,31 @@ func (s *Service) Update(ctx context.Context) (err error) {
 
 	s.log.Debug("update started")
 
-	comicsTotal, err := s.xkcd.LastID(ctx)
+	// a failing source must not block the others, so remember its error and go on
+	var firstErr error
+	for _, src := range s.sources {
+		if err := s.updateSource(ctx, src); err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+func (s *Service) updateSource(ctx context.Context, src Source) error {
+	comicsTotal, err := src.LastID(ctx)
 	if err != nil {
-		s.log.Error("failed to get comics total", "error", err)
+		s.log.Error("failed to get comics total", "source", src.ID(), "error", err)
 		return ErrComicsCount
 	}
 
-	downloadedComics, err := s.db.IDs(ctx)
+	downloadedComics, err := s.db.IDs(ctx, src.ID())
 	if err != nil {
-		s.log.Error("failed to get downloaded comics", "error", err)
+		s.log.Error("failed to get downloaded comics", "source", src.ID(), "error", err)
 		return ErrGetDownloadedComics
 	}
 
@@ -93,28 +203,217 @@ func (s *Service) Update(ctx context.Context) (err error) {
 		downloadedComicsMap[id] = struct{}{}
 	}
 
-	s.log.Debug("downloaded comics", "count :", len(downloadedComicsMap))
-	s.log.Debug("downloading comics", "count :", comicsTotal-len(downloadedComicsMap))
+	s.log.Debug("downloaded comics", "source", src.ID(), "count", len(downloadedComicsMap))
 
-	semaphore := make(chan struct{}, s.concurrency)
-	defer close(semaphore)
-	wg := sync.WaitGroup{}
+	ids := make([]int, 0, max(comicsTotal-len(downloadedComicsMap), 0))
 	for i := 1; i <= comicsTotal; i++ {
 		if _, ok := downloadedComicsMap[i]; ok {
 			continue
 		}
+		ids = append(ids, i)
+	}
+
+	s.log.Debug("downloading comics", "source", src.ID(), "count", len(ids))
+	return s.fetch(ctx, src, ids)
+}
+
+func (s *Service) Reindex(ctx context.Context, filter ReindexFilter) error {
+	if filter.FromID < 0 || filter.ToID < 0 || (filter.ToID > 0 && filter.FromID > filter.ToID) {
+		return ErrBadArguments
+	}
+	if _, ok := s.source(filter.Source); filter.Source != "" && !ok {
+		return ErrBadArguments
+	}
+
+	if !s.mutex.TryLock() {
+		s.log.Debug("update already in progress")
+		return ErrAlreadyExists
+	}
+	defer s.mutex.Unlock()
+
+	sources := s.sources
+	if filter.Source != "" {
+		src, ok := s.source(filter.Source)
+		if !ok {
+			return ErrBadArguments
+		}
+		sources = []Source{src}
+	}
+
+	s.log.Debug("reindex started", "source", filter.Source,
+		"from", filter.FromID, "to", filter.ToID, "older_than", filter.OlderThan)
+
+	var firstErr error
+	for _, src := range sources {
+		ids, err := s.db.FilteredIDs(ctx, src.ID(), filter)
+		if err != nil {
+			s.log.Error("failed to get comics for reindex", "source", src.ID(), "error", err)
+			return ErrGetDownloadedComics
+		}
+
+		s.log.Debug("reindexing comics", "source", src.ID(), "count", len(ids))
+		if err := s.fetch(ctx, src, ids); err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+func (s *Service) Renormalize(ctx context.Context, onlyStale bool) error {
+	if !s.mutex.TryLock() {
+		s.log.Debug("update already in progress")
+		return ErrAlreadyExists
+	}
+	defer s.mutex.Unlock()
+
+	staleFor := ""
+	if onlyStale {
+		staleFor = s.normalizerVersion
+	}
+
+	s.log.Debug("renormalize started", "version", s.normalizerVersion, "only_stale", onlyStale)
+
+	afterSource, afterID, total := "", 0, 0
+	for {
+		batch, err := s.db.RawBatch(ctx, afterSource, afterID, renormalizeBatchSize, staleFor)
+		if err != nil {
+			s.log.Error("failed to read stored comics", "error", err)
+			return ErrReadRawText
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		if err := s.renormalizeBatch(ctx, batch); err != nil {
+			return err
+		}
+		total += len(batch)
+		afterSource, afterID = batch[len(batch)-1].Source, batch[len(batch)-1].ID
+
+		if len(batch) < renormalizeBatchSize {
+			break
+		}
+	}
+
+	s.log.Debug("renormalize finished", "count", total)
+	return nil
+}
+
+// renormalizeBatch stops on a failed batch or write, otherwise
+// the rest of the comics would silently stay on the old normalizer
+func (s *Service) renormalizeBatch(ctx context.Context, batch []Comics) error {
+	// comics stored before the raw text was kept have nothing to normalize, they are downloaded again
+	legacy := make(map[string][]int)
+	stored := make([]Comics, 0, len(batch))
+	for _, comic := range batch {
+		if comic.NormalizerVersion == "" {
+			legacy[comic.Source] = append(legacy[comic.Source], comic.ID)
+			continue
+		}
+		stored = append(stored, comic)
+	}
+	for source, ids := range legacy {
+		src, ok := s.source(source)
+		if !ok {
+			s.log.Warn("no source to download legacy comics from", "source", source, "count", len(ids))
+			continue
+		}
+		s.log.Debug("downloading legacy comics", "source", source, "count", len(ids))
+		if err := s.fetch(ctx, src, ids); err != nil {
+			return err
+		}
+	}
+	if len(stored) == 0 {
+		return nil
+	}
+
+	results, err := s.normBatch(ctx, stored)
+	if err != nil {
+		return err
+	}
+
+	for i, comic := range stored {
+		if results[i].Err != nil {
+			s.log.Error("failed to normalize words", "source", comic.Source, "id", comic.ID, "error", results[i].Err)
+			continue
+		}
+		if err := s.db.UpdateWords(ctx, comic.Source, comic.ID, results[i].Words, s.normalizerVersion); err != nil {
+			s.log.Error("failed to update comic words", "source", comic.Source, "id", comic.ID, "error", err)
+			return ErrUpdateWords
+		}
+	}
+	return nil
+}
+
+// fetch downloads comics concurrently and stops early when the source is
+// unreachable or rate limited, since the remaining requests would fail too,
+// or when the downloaded comics can't be stored.
+func (s *Service) fetch(ctx context.Context, src Source, ids []int) error {
+	storeCtx := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	comics := make(chan Comics)
+	stored := make(chan error, 1)
+	// the parent context, comics downloaded before an abort are still stored
+	go func() {
+		stored <- s.store(storeCtx, comics, cancel)
+	}()
+
+	var abortErr error
+	var once sync.Once
+	semaphore := make(chan struct{}, s.concurrency)
+	wg := sync.WaitGroup{}
+	for _, id := range ids {
+		select {
+		case semaphore <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
 		wg.Add(1)
-		go s.processComic(ctx, i, semaphore, &wg)
+		go func() {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+			if err := s.processComic(ctx, src, id, comics); err != nil {
+				once.Do(func() {
+					s.log.Error("aborting comics download", "source", src.ID(), "id", id, "error", err)
+					abortErr = err
+					cancel()
+				})
+			}
+		}()
 	}
 	wg.Wait()
-	return nil
+	close(comics)
+
+	if err := <-stored; err != nil {
+		return err
+	}
+	if abortErr != nil {
+		return abortErr
+	}
+	return ctx.Err()
 }
 
 func (s *Service) Stats(ctx context.Context) (ServiceStats, error) {
-	comicsTotal, err := s.xkcd.LastID(ctx)
-	if err != nil {
-		s.log.Error("failed to get comics total", "error", err)
-		return ServiceStats{}, ErrComicsCount
+	comicsTotal := 0
+	for _, src := range s.sources {
+		total, err := src.Count(ctx)
+		if err != nil {
+			s.log.Error("failed to get comics total", "source", src.ID(), "error", err)
+			return ServiceStats{}, ErrComicsCount
+		}
+		comicsTotal += total
 	}
 
 	dbStats, err := s.db.Stats(ctx)
@@ -123,9 +422,16 @@ func (s *Service) Stats(ctx context.Context) (ServiceStats, error) {
 		return ServiceStats{}, ErrGetDBStats
 	}
 
+	stale, err := s.db.StaleCount(ctx, s.normalizerVersion)
+	if err != nil {
+		s.log.Error("failed to count stale comics", "error", err)
+		return ServiceStats{}, ErrGetDBStats
+	}
+
 	return ServiceStats{
 		DBStats:     dbStats,
 		ComicsTotal: comicsTotal,
+		ComicsStale: stale,
 	}, nil
 }
 
@@ -139,7 +445,44 @@ func (s *Service) Status(ctx context.Context) ServiceStatus {
 	return StatusIdle
 }
 
+func (s *Service) Image(ctx context.Context, source string, id int, thumbnail bool) (Image, error) {
+	if s.images == nil {
+		return Image{}, ErrNotFound
+	}
+	if source == "" {
+		source = s.sources[0].ID()
+	}
+
+	meta, err := s.db.ImageMeta(ctx, source, id)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return Image{}, ErrNotFound
+		}
+		s.log.Error("failed to get image metadata", "source", source, "id", id, "error", err)
+		return Image{}, ErrReadImage
+	}
+
+	image, err := s.images.Load(ctx, meta, thumbnail)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return Image{}, ErrNotFound
+		}
+		s.log.Error("failed to load image", "source", source, "id", id, "hash", meta.Hash, "error", err)
+		return Image{}, ErrReadImage
+	}
+	return image, nil
+}
+
 func (s *Service) Drop(ctx context.Context) error {
 	s.log.Debug("dropping db")
 	return s.db.Drop(ctx)
 }
+
+func (s *Service) source(id string) (Source, bool) {
+	for _, src := range s.sources {
+		if src.ID() == id {
+			return src, true
+		}
+	}
+	return nil, false
+}
@@ -3,6 +3,8 @@ package core
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"os"
 	"sync"
 	"testing"
@@ -19,6 +21,11 @@ type MockDB struct {
 	idsFunc   func(ctx context.Context) ([]int, error)
 	statsFunc func(ctx context.Context) (DBStats, error)
 	dropFunc  func(ctx context.Context) error
+	filtFunc  func(ctx context.Context, filter ReindexFilter) ([]int, error)
+	rawFunc   func(ctx context.Context, afterID, limit int, staleFor string) ([]Comics, error)
+	wordsFunc func(ctx context.Context, id int, words []string, version string) error
+	staleFunc func(ctx context.Context, version string) (int, error)
+	imageFunc func(ctx context.Context, id int) (ImageMeta, error)
 }
 
 func (m MockDB) Add(ctx context.Context, comics Comics) error {
@@ -28,13 +35,50 @@ func (m MockDB) Add(ctx context.Context, comics Comics) error {
 	return nil
 }
 
-func (m MockDB) IDs(ctx context.Context) ([]int, error) {
+func (m MockDB) IDs(ctx context.Context, source string) ([]int, error) {
 	if m.idsFunc != nil {
 		return m.idsFunc(ctx)
 	}
 	return []int{}, nil
 }
 
+func (m MockDB) FilteredIDs(ctx context.Context, source string, filter ReindexFilter) ([]int, error) {
+	if m.filtFunc != nil {
+		return m.filtFunc(ctx, filter)
+	}
+	return []int{}, nil
+}
+
+func (m MockDB) RawBatch(
+	ctx context.Context, afterSource string, afterID, limit int, staleFor string,
+) ([]Comics, error) {
+	if m.rawFunc != nil {
+		return m.rawFunc(ctx, afterID, limit, staleFor)
+	}
+	return nil, nil
+}
+
+func (m MockDB) UpdateWords(ctx context.Context, source string, id int, words []string, version string) error {
+	if m.wordsFunc != nil {
+		return m.wordsFunc(ctx, id, words, version)
+	}
+	return nil
+}
+
+func (m MockDB) StaleCount(ctx context.Context, version string) (int, error) {
+	if m.staleFunc != nil {
+		return m.staleFunc(ctx, version)
+	}
+	return 0, nil
+}
+
+func (m MockDB) ImageMeta(ctx context.Context, source string, id int) (ImageMeta, error) {
+	if m.imageFunc != nil {
+		return m.imageFunc(ctx, id)
+	}
+	return ImageMeta{}, ErrNotFound
+}
+
 func (m MockDB) Stats(ctx context.Context) (DBStats, error) {
 	if m.statsFunc != nil {
 		return m.statsFunc(ctx)
@@ -49,57 +93,113 @@ func (m MockDB) Drop(ctx context.Context) error {
 	return nil
 }
 
-// MockXKCD реализует интерфейс XKCD для тестов
-type MockXKCD struct {
-	getFunc    func(ctx context.Context, id int) (XKCDInfo, error)
+// MockSource реализует интерфейс Source для тестов
+type MockSource struct {
+	id         string
+	getFunc    func(ctx context.Context, id int) (ComicInfo, error)
 	lastIDFunc func(ctx context.Context) (int, error)
+	countFunc  func(ctx context.Context) (int, error)
 }
 
-func (m MockXKCD) Get(ctx context.Context, id int) (XKCDInfo, error) {
+func (m MockSource) ID() string {
+	if m.id != "" {
+		return m.id
+	}
+	return "xkcd"
+}
+
+func (m MockSource) Get(ctx context.Context, id int) (ComicInfo, error) {
 	if m.getFunc != nil {
 		return m.getFunc(ctx, id)
 	}
-	return XKCDInfo{}, nil
+	return ComicInfo{}, nil
 }
 
-func (m MockXKCD) LastID(ctx context.Context) (int, error) {
+func (m MockSource) LastID(ctx context.Context) (int, error) {
 	if m.lastIDFunc != nil {
 		return m.lastIDFunc(ctx)
 	}
 	return 0, nil
 }
 
+func (m MockSource) Count(ctx context.Context) (int, error) {
+	if m.countFunc != nil {
+		return m.countFunc(ctx)
+	}
+	return 0, nil
+}
+
 // MockWords реализует интерфейс Words для тестов
 type MockWords struct {
 	normFunc func(ctx context.Context, phrase string) ([]string, error)
+	batchErr error
+}
+
+// NormBatch normalizes every phrase with normFunc, per-phrase errors go to the results
+func (m MockWords) NormBatch(ctx context.Context, phrases []string) ([]NormResult, error) {
+	if m.batchErr != nil {
+		return nil, m.batchErr
+	}
+	results := make([]NormResult, len(phrases))
+	for i, phrase := range phrases {
+		if m.normFunc == nil {
+			results[i].Words = []string{}
+			continue
+		}
+		results[i].Words, results[i].Err = m.normFunc(ctx, phrase)
+	}
+	return results, nil
 }
 
-func (m MockWords) Norm(ctx context.Context, phrase string) ([]string, error) {
-	if m.normFunc != nil {
-		return m.normFunc(ctx, phrase)
+// MockImages реализует интерфейс Images для тестов
+type MockImages struct {
+	saveFunc func(ctx context.Context, url string) (ImageMeta, error)
+	loadFunc func(ctx context.Context, meta ImageMeta, thumbnail bool) (Image, error)
+}
+
+func (m MockImages) Save(ctx context.Context, url string) (ImageMeta, error) {
+	if m.saveFunc != nil {
+		return m.saveFunc(ctx, url)
+	}
+	return ImageMeta{}, nil
+}
+
+func (m MockImages) Load(ctx context.Context, meta ImageMeta, thumbnail bool) (Image, error) {
+	if m.loadFunc != nil {
+		return m.loadFunc(ctx, meta, thumbnail)
 	}
-	return []string{}, nil
+	return Image{}, nil
 }
 
 func TestNewService(t *testing.T) {
 	tests := []struct {
 		name        string
 		concurrency int
+		version     string
 		wantError   bool
 	}{
 		{
 			name:        "valid concurrency",
 			concurrency: 1,
+			version:     "1",
 			wantError:   false,
 		},
 		{
 			name:        "zero concurrency",
 			concurrency: 0,
+			version:     "1",
 			wantError:   true,
 		},
 		{
 			name:        "negative concurrency",
 			concurrency: -1,
+			version:     "1",
+			wantError:   true,
+		},
+		{
+			name:        "empty normalizer version",
+			concurrency: 1,
+			version:     "",
 			wantError:   true,
 		},
 	}
@@ -108,10 +208,10 @@ func TestNewService(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			log := slog.New(slog.NewTextHandler(os.Stdout, nil))
 			db := MockDB{}
-			xkcd := MockXKCD{}
+			xkcd := MockSource{}
 			words := MockWords{}
 
-			service, err := NewService(log, db, xkcd, words, tt.concurrency)
+			service, err := NewService(log, db, []Source{xkcd}, words, nil, tt.concurrency, tt.version)
 
 			if tt.wantError {
 				assert.Error(t, err)
@@ -126,6 +226,43 @@ func TestNewService(t *testing.T) {
 	}
 }
 
+func TestNewService_Sources(t *testing.T) {
+	tests := []struct {
+		name      string
+		sources   []Source
+		wantError bool
+	}{
+		{
+			name:    "several sources",
+			sources: []Source{MockSource{id: "xkcd"}, MockSource{id: "feed"}},
+		},
+		{
+			name:      "no sources",
+			sources:   nil,
+			wantError: true,
+		},
+		{
+			name:      "duplicate source",
+			sources:   []Source{MockSource{id: "xkcd"}, MockSource{id: "xkcd"}},
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := slog.New(slog.NewTextHandler(io.Discard, nil))
+			service, err := NewService(log, MockDB{}, tt.sources, MockWords{}, nil, 1, "1")
+			if tt.wantError {
+				assert.Error(t, err)
+				assert.Nil(t, service)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, service)
+		})
+	}
+}
+
 func TestService_Update(t *testing.T) {
 	tests := []struct {
 		name              string
@@ -244,19 +381,20 @@ func TestService_Update(t *testing.T) {
 				},
 			}
 
-			xkcd := MockXKCD{
+			xkcd := MockSource{
 				lastIDFunc: func(ctx context.Context) (int, error) {
 					return tt.lastID, tt.lastIDError
 				},
-				getFunc: func(ctx context.Context, id int) (XKCDInfo, error) {
+				getFunc: func(ctx context.Context, id int) (ComicInfo, error) {
 					if tt.processComicError {
-						return XKCDInfo{}, errors.New("xkcd get error")
+						return ComicInfo{}, errors.New("xkcd get error")
 					}
-					return XKCDInfo{
-						ID:          id,
-						Title:       "Test Title",
-						Description: "Test Description",
-						URL:         "http://test.com",
+					return ComicInfo{
+						ID:         id,
+						Title:      "Test Title",
+						Transcript: "Test Transcript",
+						Alt:        "Test Alt",
+						URL:        "http://test.com",
 					}, nil
 				},
 			}
@@ -270,7 +408,7 @@ func TestService_Update(t *testing.T) {
 				},
 			}
 
-			service, err := NewService(log, db, xkcd, words, 2)
+			service, err := NewService(log, db, []Source{xkcd}, words, nil, 2, "1")
 			require.NoError(t, err)
 
 			err = service.Update(context.Background())
@@ -290,9 +428,181 @@ func TestService_Update(t *testing.T) {
 	}
 }
 
+func TestService_Update_SourceErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		getError      func(id int) error
+		expectedError error
+		maxAdded      int
+		minAdded      int
+	}{
+		{
+			name: "not found comics are skipped",
+			getError: func(id int) error {
+				if id%2 == 0 {
+					return fmt.Errorf("%w: status 404", ErrNotFound)
+				}
+				return nil
+			},
+			minAdded: 5,
+			maxAdded: 5,
+		},
+		{
+			name: "decode error skips comic",
+			getError: func(id int) error {
+				if id == 3 {
+					return fmt.Errorf("%w: bad json", ErrDecode)
+				}
+				return nil
+			},
+			minAdded: 9,
+			maxAdded: 9,
+		},
+		{
+			name: "rate limit aborts update",
+			getError: func(id int) error {
+				if id >= 3 {
+					return fmt.Errorf("%w: status 429", ErrRateLimited)
+				}
+				return nil
+			},
+			expectedError: ErrRateLimited,
+			minAdded:      2,
+			maxAdded:      2,
+		},
+		{
+			name: "transport error aborts update",
+			getError: func(id int) error {
+				if id >= 5 {
+					return fmt.Errorf("%w: connection refused", ErrTransport)
+				}
+				return nil
+			},
+			expectedError: ErrTransport,
+			minAdded:      4,
+			maxAdded:      4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := slog.New(slog.NewTextHandler(io.Discard, nil))
+			var mu sync.Mutex
+			added := 0
+			requested := 0
+
+			db := MockDB{
+				addFunc: func(ctx context.Context, comics Comics) error {
+					mu.Lock()
+					defer mu.Unlock()
+					added++
+					return nil
+				},
+			}
+			xkcd := MockSource{
+				lastIDFunc: func(ctx context.Context) (int, error) {
+					return 10, nil
+				},
+				getFunc: func(ctx context.Context, id int) (ComicInfo, error) {
+					mu.Lock()
+					requested++
+					mu.Unlock()
+					if err := tt.getError(id); err != nil {
+						return ComicInfo{}, err
+					}
+					return ComicInfo{ID: id}, nil
+				},
+			}
+
+			// одна горутина — порядок загрузки детерминирован
+			service, err := NewService(log, db, []Source{xkcd}, MockWords{}, nil, 1, "1")
+			require.NoError(t, err)
+
+			err = service.Update(context.Background())
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				// после ошибки источника оставшиеся комиксы не запрашиваются
+				assert.Equal(t, tt.maxAdded+1, requested)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, 10, requested)
+			}
+			assert.GreaterOrEqual(t, added, tt.minAdded)
+			assert.LessOrEqual(t, added, tt.maxAdded)
+		})
+	}
+}
+
+func TestService_Update_Canceled(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var mu sync.Mutex
+	requested := 0
+	xkcd := MockSource{
+		lastIDFunc: func(ctx context.Context) (int, error) {
+			return 100, nil
+		},
+		getFunc: func(ctx context.Context, id int) (ComicInfo, error) {
+			mu.Lock()
+			requested++
+			mu.Unlock()
+			if id == 3 {
+				cancel()
+			}
+			return ComicInfo{ID: id}, nil
+		},
+	}
+
+	service, err := NewService(log, MockDB{}, []Source{xkcd}, MockWords{}, nil, 1, "1")
+	require.NoError(t, err)
+
+	err = service.Update(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, requested, 100)
+}
+
+func TestService_Update_Sources(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	var mu sync.Mutex
+	added := make(map[string][]int)
+
+	db := MockDB{
+		addFunc: func(ctx context.Context, comics Comics) error {
+			mu.Lock()
+			defer mu.Unlock()
+			added[comics.Source] = append(added[comics.Source], comics.ID)
+			return nil
+		},
+	}
+	xkcd := MockSource{
+		id: "xkcd",
+		lastIDFunc: func(ctx context.Context) (int, error) {
+			return 0, fmt.Errorf("%w: connection refused", ErrTransport)
+		},
+	}
+	feed := MockSource{
+		id: "feed",
+		lastIDFunc: func(ctx context.Context) (int, error) {
+			return 3, nil
+		},
+		getFunc: func(ctx context.Context, id int) (ComicInfo, error) {
+			return ComicInfo{ID: id}, nil
+		},
+	}
+
+	service, err := NewService(log, db, []Source{xkcd, feed}, MockWords{}, nil, 1, "1")
+	require.NoError(t, err)
+
+	// недоступный источник не мешает загрузке остальных
+	err = service.Update(context.Background())
+	assert.ErrorIs(t, err, ErrComicsCount)
+	assert.Equal(t, map[string][]int{"feed": {1, 2, 3}}, added)
+}
+
 func TestService_Update_Concurrent(t *testing.T) {
 	log := slog.New(slog.NewTextHandler(os.Stdout, nil))
-	service, err := NewService(log, MockDB{}, MockXKCD{}, MockWords{}, 1)
+	service, err := NewService(log, MockDB{}, []Source{MockSource{}}, MockWords{}, nil, 1, "1")
 	require.NoError(t, err)
 
 	// Захватываем мьютекс
@@ -313,33 +623,476 @@ func TestService_Update_Concurrent(t *testing.T) {
 	service.mutex.Unlock()
 }
 
+func TestService_Reindex(t *testing.T) {
+	tests := []struct {
+		name        string
+		filter      ReindexFilter
+		ids         []int
+		idsError    error
+		batchErr    error
+		wantFetched []int
+		errorType   error
+	}{
+		{
+			name:        "all comics",
+			filter:      ReindexFilter{},
+			ids:         []int{1, 2, 3},
+			wantFetched: []int{1, 2, 3},
+		},
+		{
+			name:        "id range",
+			filter:      ReindexFilter{FromID: 2, ToID: 3},
+			ids:         []int{2, 3},
+			wantFetched: []int{2, 3},
+		},
+		{
+			name:      "inverted range",
+			filter:    ReindexFilter{FromID: 5, ToID: 3},
+			errorType: ErrBadArguments,
+		},
+		{
+			name:      "negative id",
+			filter:    ReindexFilter{FromID: -1},
+			errorType: ErrBadArguments,
+		},
+		{
+			name:      "db error",
+			filter:    ReindexFilter{},
+			idsError:  errors.New("db error"),
+			errorType: ErrGetDownloadedComics,
+		},
+		{
+			name:      "words unavailable",
+			filter:    ReindexFilter{},
+			ids:       []int{1, 2, 3},
+			batchErr:  errors.New("words unavailable"),
+			errorType: ErrNormalize,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			var mu sync.Mutex
+			var fetched []int
+
+			db := MockDB{
+				filtFunc: func(ctx context.Context, filter ReindexFilter) ([]int, error) {
+					assert.Equal(t, tt.filter, filter)
+					return tt.ids, tt.idsError
+				},
+			}
+			xkcd := MockSource{
+				getFunc: func(ctx context.Context, id int) (ComicInfo, error) {
+					mu.Lock()
+					defer mu.Unlock()
+					fetched = append(fetched, id)
+					return ComicInfo{ID: id}, nil
+				},
+			}
+
+			service, err := NewService(log, db, []Source{xkcd}, MockWords{batchErr: tt.batchErr}, nil, 2, "1")
+			require.NoError(t, err)
+
+			err = service.Reindex(context.Background(), tt.filter)
+			if tt.errorType != nil {
+				assert.ErrorIs(t, err, tt.errorType)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, tt.wantFetched, fetched)
+		})
+	}
+}
+
+func TestService_Reindex_Source(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	var mu sync.Mutex
+	fetched := make(map[string][]int)
+
+	newSource := func(id string) MockSource {
+		return MockSource{
+			id: id,
+			getFunc: func(ctx context.Context, i int) (ComicInfo, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				fetched[id] = append(fetched[id], i)
+				return ComicInfo{ID: i}, nil
+			},
+		}
+	}
+	db := MockDB{
+		filtFunc: func(ctx context.Context, filter ReindexFilter) ([]int, error) {
+			return []int{1}, nil
+		},
+	}
+
+	service, err := NewService(log, db, []Source{newSource("xkcd"), newSource("feed")}, MockWords{}, nil, 1, "1")
+	require.NoError(t, err)
+
+	require.NoError(t, service.Reindex(context.Background(), ReindexFilter{Source: "feed"}))
+	assert.Equal(t, map[string][]int{"feed": {1}}, fetched)
+
+	err = service.Reindex(context.Background(), ReindexFilter{Source: "unknown"})
+	assert.ErrorIs(t, err, ErrBadArguments)
+}
+
+func TestService_Reindex_Concurrent(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	service, err := NewService(log, MockDB{}, []Source{MockSource{}}, MockWords{}, nil, 1, "1")
+	require.NoError(t, err)
+
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	assert.ErrorIs(t, service.Reindex(context.Background(), ReindexFilter{}), ErrAlreadyExists)
+}
+
+func TestService_Renormalize(t *testing.T) {
+	stored := make([]Comics, 0, 250)
+	for i := 1; i <= 250; i++ {
+		stored = append(stored, Comics{ID: i, Title: "title", Alt: "alt", Transcript: "transcript", NormalizerVersion: "1"})
+	}
+
+	tests := []struct {
+		name          string
+		onlyStale     bool
+		wantStaleFor  string
+		rawError      error
+		normError     bool
+		batchErr      error
+		updateError   error
+		wantUpdated   int
+		wantBatches   int
+		expectedError error
+	}{
+		{
+			name:         "all comics",
+			onlyStale:    false,
+			wantStaleFor: "",
+			wantUpdated:  250,
+			wantBatches:  3,
+		},
+		{
+			name:         "only stale comics",
+			onlyStale:    true,
+			wantStaleFor: "2",
+			wantUpdated:  250,
+			wantBatches:  3,
+		},
+		{
+			name:        "norm error skips comics",
+			normError:   true,
+			wantUpdated: 0,
+			wantBatches: 3,
+		},
+		{
+			name:          "batch error stops renormalize",
+			batchErr:      errors.New("words unavailable"),
+			wantBatches:   1,
+			expectedError: ErrNormalize,
+		},
+		{
+			name:          "update error stops renormalize",
+			updateError:   errors.New("db error"),
+			wantBatches:   1,
+			expectedError: ErrUpdateWords,
+		},
+		{
+			name:          "read error",
+			rawError:      errors.New("db error"),
+			wantBatches:   1,
+			expectedError: ErrReadRawText,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			var mu sync.Mutex
+			updated := make(map[int]string)
+			batches := 0
+
+			db := MockDB{
+				rawFunc: func(ctx context.Context, afterID, limit int, staleFor string) ([]Comics, error) {
+					batches++
+					assert.Equal(t, tt.wantStaleFor, staleFor)
+					if tt.rawError != nil {
+						return nil, tt.rawError
+					}
+					end := min(afterID+limit, len(stored))
+					return stored[afterID:end], nil
+				},
+				wordsFunc: func(ctx context.Context, id int, words []string, version string) error {
+					if tt.updateError != nil {
+						return tt.updateError
+					}
+					mu.Lock()
+					defer mu.Unlock()
+					updated[id] = version
+					return nil
+				},
+			}
+			words := MockWords{
+				normFunc: func(ctx context.Context, phrase string) ([]string, error) {
+					if tt.normError {
+						return nil, errors.New("words norm error")
+					}
+					assert.Equal(t, "title transcript alt", phrase)
+					return []string{"titl"}, nil
+				},
+				batchErr: tt.batchErr,
+			}
+
+			service, err := NewService(log, db, []Source{MockSource{}}, words, nil, 4, "2")
+			require.NoError(t, err)
+
+			err = service.Renormalize(context.Background(), tt.onlyStale)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.wantBatches, batches)
+			assert.Len(t, updated, tt.wantUpdated)
+			for _, version := range updated {
+				assert.Equal(t, "2", version)
+			}
+		})
+	}
+}
+
+func TestService_Renormalize_Legacy(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	var mu sync.Mutex
+	var fetched []int
+	added := make(map[int]string)
+	updated := make(map[int]string)
+
+	db := MockDB{
+		rawFunc: func(ctx context.Context, afterID, limit int, staleFor string) ([]Comics, error) {
+			if afterID > 0 {
+				return nil, nil
+			}
+			// у старых записей нет текста, их нужно скачать заново
+			return []Comics{
+				{ID: 1, Source: "xkcd", Title: "title", NormalizerVersion: "1"},
+				{ID: 2, Source: "xkcd"},
+				{ID: 3, Source: "removed"},
+			}, nil
+		},
+		addFunc: func(ctx context.Context, comics Comics) error {
+			mu.Lock()
+			defer mu.Unlock()
+			added[comics.ID] = comics.NormalizerVersion
+			return nil
+		},
+		wordsFunc: func(ctx context.Context, id int, words []string, version string) error {
+			updated[id] = version
+			return nil
+		},
+	}
+	xkcd := MockSource{
+		getFunc: func(ctx context.Context, id int) (ComicInfo, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			fetched = append(fetched, id)
+			return ComicInfo{ID: id, Title: "title"}, nil
+		},
+	}
+
+	service, err := NewService(log, db, []Source{xkcd}, MockWords{}, nil, 2, "2")
+	require.NoError(t, err)
+
+	require.NoError(t, service.Renormalize(context.Background(), true))
+	assert.Equal(t, []int{2}, fetched)
+	assert.Equal(t, map[int]string{2: "2"}, added)
+	assert.Equal(t, map[int]string{1: "2"}, updated)
+}
+
+func TestService_Update_Images(t *testing.T) {
+	tests := []struct {
+		name      string
+		saveError error
+		wantImage ImageMeta
+	}{
+		{
+			name:      "image saved",
+			wantImage: ImageMeta{Hash: "abcd", Size: 10, Width: 2, Height: 3, ContentType: "image/png"},
+		},
+		{
+			name:      "image download error keeps comic",
+			saveError: errors.New("download error"),
+			wantImage: ImageMeta{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			var added []Comics
+			var mu sync.Mutex
+
+			db := MockDB{
+				addFunc: func(ctx context.Context, comics Comics) error {
+					mu.Lock()
+					defer mu.Unlock()
+					added = append(added, comics)
+					return nil
+				},
+			}
+			xkcd := MockSource{
+				lastIDFunc: func(ctx context.Context) (int, error) {
+					return 1, nil
+				},
+				getFunc: func(ctx context.Context, id int) (ComicInfo, error) {
+					return ComicInfo{ID: id, URL: "http://test.com/1.png"}, nil
+				},
+			}
+			images := MockImages{
+				saveFunc: func(ctx context.Context, url string) (ImageMeta, error) {
+					assert.Equal(t, "http://test.com/1.png", url)
+					if tt.saveError != nil {
+						return ImageMeta{}, tt.saveError
+					}
+					return tt.wantImage, nil
+				},
+			}
+
+			service, err := NewService(log, db, []Source{xkcd}, MockWords{}, images, 1, "1")
+			require.NoError(t, err)
+
+			require.NoError(t, service.Update(context.Background()))
+			require.Len(t, added, 1)
+			assert.Equal(t, tt.wantImage, added[0].Image)
+		})
+	}
+}
+
+func TestService_Image(t *testing.T) {
+	meta := ImageMeta{Hash: "abcd", Size: 10, Width: 2, Height: 3, ContentType: "image/png"}
+
+	tests := []struct {
+		name          string
+		disabled      bool
+		metaError     error
+		loadError     error
+		thumbnail     bool
+		expectedError error
+	}{
+		{
+			name: "original",
+		},
+		{
+			name:      "thumbnail",
+			thumbnail: true,
+		},
+		{
+			name:          "images disabled",
+			disabled:      true,
+			expectedError: ErrNotFound,
+		},
+		{
+			name:          "no image stored",
+			metaError:     ErrNotFound,
+			expectedError: ErrNotFound,
+		},
+		{
+			name:          "db error",
+			metaError:     errors.New("db error"),
+			expectedError: ErrReadImage,
+		},
+		{
+			name:          "file missing",
+			loadError:     ErrNotFound,
+			expectedError: ErrNotFound,
+		},
+		{
+			name:          "load error",
+			loadError:     errors.New("disk error"),
+			expectedError: ErrReadImage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			db := MockDB{
+				imageFunc: func(ctx context.Context, id int) (ImageMeta, error) {
+					assert.Equal(t, 7, id)
+					return meta, tt.metaError
+				},
+			}
+			var images Images
+			if !tt.disabled {
+				images = MockImages{
+					loadFunc: func(ctx context.Context, m ImageMeta, thumbnail bool) (Image, error) {
+						assert.Equal(t, meta, m)
+						assert.Equal(t, tt.thumbnail, thumbnail)
+						if tt.loadError != nil {
+							return Image{}, tt.loadError
+						}
+						return Image{ImageMeta: m, Data: []byte("png")}, nil
+					},
+				}
+			}
+
+			service, err := NewService(log, db, []Source{MockSource{}}, MockWords{}, images, 1, "1")
+			require.NoError(t, err)
+
+			image, err := service.Image(context.Background(), "", 7, tt.thumbnail)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, []byte("png"), image.Data)
+			assert.Equal(t, meta, image.ImageMeta)
+		})
+	}
+}
+
 func TestService_Stats(t *testing.T) {
 	tests := []struct {
 		name         string
-		lastID       int
-		lastIDError  error
+		count        int
+		countError   error
 		dbStats      DBStats
 		dbStatsError error
+		stale        int
+		staleError   error
 		wantError    bool
 		errorType    error
 	}{
 		{
 			name:      "successful stats",
-			lastID:    100,
+			count:     100,
 			dbStats:   DBStats{WordsTotal: 100, WordsUnique: 50, ComicsFetched: 10},
+			stale:     3,
 			wantError: false,
 		},
 		{
-			name:        "xkcd error",
-			lastID:      0,
-			lastIDError: errors.New("xkcd error"),
-			dbStats:     DBStats{},
-			wantError:   true,
-			errorType:   ErrComicsCount,
+			name:       "stale count error",
+			count:      100,
+			dbStats:    DBStats{},
+			staleError: errors.New("db error"),
+			wantError:  true,
+			errorType:  ErrGetDBStats,
+		},
+		{
+			name:       "xkcd error",
+			count:      0,
+			countError: errors.New("xkcd error"),
+			dbStats:    DBStats{},
+			wantError:  true,
+			errorType:  ErrComicsCount,
 		},
 		{
 			name:         "db stats error",
-			lastID:       100,
+			count:        100,
 			dbStats:      DBStats{},
 			dbStatsError: errors.New("db error"),
 			wantError:    true,
@@ -351,9 +1104,9 @@ func TestService_Stats(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			log := slog.New(slog.NewTextHandler(os.Stdout, nil))
 
-			xkcd := MockXKCD{
-				lastIDFunc: func(ctx context.Context) (int, error) {
-					return tt.lastID, tt.lastIDError
+			xkcd := MockSource{
+				countFunc: func(ctx context.Context) (int, error) {
+					return tt.count, tt.countError
 				},
 			}
 
@@ -361,9 +1114,13 @@ func TestService_Stats(t *testing.T) {
 				statsFunc: func(ctx context.Context) (DBStats, error) {
 					return tt.dbStats, tt.dbStatsError
 				},
+				staleFunc: func(ctx context.Context, version string) (int, error) {
+					assert.Equal(t, "1", version)
+					return tt.stale, tt.staleError
+				},
 			}
 
-			service, err := NewService(log, db, xkcd, MockWords{}, 1)
+			service, err := NewService(log, db, []Source{xkcd}, MockWords{}, nil, 1, "1")
 			require.NoError(t, err)
 
 			stats, err := service.Stats(context.Background())
@@ -376,7 +1133,8 @@ func TestService_Stats(t *testing.T) {
 			}
 
 			assert.NoError(t, err)
-			assert.Equal(t, tt.lastID, stats.ComicsTotal)
+			assert.Equal(t, tt.count, stats.ComicsTotal)
+			assert.Equal(t, tt.stale, stats.ComicsStale)
 			assert.Equal(t, tt.dbStats, stats.DBStats)
 		})
 	}
@@ -384,7 +1142,7 @@ func TestService_Stats(t *testing.T) {
 
 func TestService_Status(t *testing.T) {
 	log := slog.New(slog.NewTextHandler(os.Stdout, nil))
-	service, err := NewService(log, MockDB{}, MockXKCD{}, MockWords{}, 1)
+	service, err := NewService(log, MockDB{}, []Source{MockSource{}}, MockWords{}, nil, 1, "1")
 	require.NoError(t, err)
 
 	// Первый вызов должен вернуть StatusIdle
@@ -431,7 +1189,7 @@ func TestService_Drop(t *testing.T) {
 				},
 			}
 
-			service, err := NewService(log, db, MockXKCD{}, MockWords{}, 1)
+			service, err := NewService(log, db, []Source{MockSource{}}, MockWords{}, nil, 1, "1")
 			require.NoError(t, err)
 
 			err = service.Drop(context.Background())
@@ -444,3 +1202,75 @@ func TestService_Drop(t *testing.T) {
 		})
 	}
 }
+
+func TestService_Update_NormBatches(t *testing.T) {
+	tests := []struct {
+		name      string
+		batchErr  error
+		wantAdded int
+		wantCalls int
+	}{
+		{
+			name:      "comics normalized in batches",
+			wantAdded: 120,
+			// 120 комиксов — три вызова words вместо ста двадцати
+			wantCalls: 3,
+		},
+		{
+			name:      "batch error aborts update",
+			batchErr:  errors.New("words unavailable"),
+			wantAdded: 0,
+			wantCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := slog.New(slog.NewTextHandler(io.Discard, nil))
+			var mu sync.Mutex
+			added := 0
+			db := MockDB{
+				addFunc: func(ctx context.Context, comics Comics) error {
+					mu.Lock()
+					defer mu.Unlock()
+					added++
+					return nil
+				},
+			}
+			xkcd := MockSource{
+				lastIDFunc: func(ctx context.Context) (int, error) {
+					return 120, nil
+				},
+				getFunc: func(ctx context.Context, id int) (ComicInfo, error) {
+					return ComicInfo{ID: id, Title: "title"}, nil
+				},
+			}
+			words := &countingWords{MockWords: MockWords{batchErr: tt.batchErr}}
+
+			service, err := NewService(log, db, []Source{xkcd}, words, nil, 4, "1")
+			require.NoError(t, err)
+
+			err = service.Update(context.Background())
+			if tt.batchErr != nil {
+				assert.ErrorIs(t, err, ErrNormalize)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantAdded, added)
+			assert.Equal(t, tt.wantCalls, words.calls)
+		})
+	}
+}
+
+type countingWords struct {
+	MockWords
+	mu    sync.Mutex
+	calls int
+}
+
+func (c *countingWords) NormBatch(ctx context.Context, phrases []string) ([]NormResult, error) {
+	c.mu.Lock()
+	c.calls++
+	c.mu.Unlock()
+	return c.MockWords.NormBatch(ctx, phrases)
+}
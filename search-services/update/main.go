@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"log/slog"
 	"net"
 	"os"
@@ -12,13 +13,18 @@ import (
 	"google.golang.org/grpc/reflection"
 	updatepb "yadro.com/course/proto/update"
 	"yadro.com/course/update/adapters/db"
+	"yadro.com/course/update/adapters/feed"
 	updategrpc "yadro.com/course/update/adapters/grpc"
+	"yadro.com/course/update/adapters/images"
+	"yadro.com/course/update/adapters/mirror"
 	"yadro.com/course/update/adapters/words"
 	"yadro.com/course/update/adapters/xkcd"
 	"yadro.com/course/update/config"
 	"yadro.com/course/update/core"
 )
 
+const maxMessageSize = 32 << 20
+
 func main() {
 
 	// config
@@ -44,10 +50,10 @@ func main() {
 		return
 	}
 
-	// xkcd adapter
-	xkcd, err := xkcd.NewClient(cfg.XKCD.URL, cfg.XKCD.Timeout, log)
+	// comic source adapters
+	sources, err := makeSources(cfg, storage, log)
 	if err != nil {
-		log.Error("failed create XKCD client", "error", err)
+		log.Error("failed create comic sources", "error", err)
 		return
 	}
 
@@ -58,8 +64,22 @@ func main() {
 		return
 	}
 
+	// images adapter
+	var imageStore core.Images
+	if cfg.Images.Enabled {
+		imageStore, err = images.NewStore(
+			cfg.Images.Dir, cfg.Images.MaxSize, cfg.Images.MaxPixels, cfg.Images.ThumbnailSize, cfg.XKCD.Timeout, log,
+		)
+		if err != nil {
+			log.Error("failed create image store", "error", err)
+			return
+		}
+	}
+
 	// service
-	updater, err := core.NewService(log, storage, xkcd, words, cfg.XKCD.Concurrency)
+	updater, err := core.NewService(
+		log, storage, sources, words, imageStore, cfg.XKCD.Concurrency, cfg.NormalizerVersion,
+	)
 	if err != nil {
 		log.Error("failed create Update service", "error", err)
 		return
@@ -72,7 +92,7 @@ func main() {
 		return
 	}
 
-	s := grpc.NewServer()
+	s := grpc.NewServer(grpc.MaxSendMsgSize(maxMessageSize))
 	updatepb.RegisterUpdateServer(s, updategrpc.NewServer(updater))
 	reflection.Register(s)
 
@@ -92,6 +112,35 @@ func main() {
 	}
 }
 
+func makeSources(cfg config.Config, keys core.Keys, log *slog.Logger) ([]core.Source, error) {
+	sources := make([]core.Source, 0, len(cfg.Sources))
+	for _, src := range cfg.Sources {
+		source, err := makeSource(src, cfg.XKCD, keys, log)
+		if err != nil {
+			return nil, fmt.Errorf("source %q: %w", src.ID, err)
+		}
+		sources = append(sources, source)
+	}
+	return sources, nil
+}
+
+func makeSource(src config.Source, xkcdCfg config.XKCD, keys core.Keys, log *slog.Logger) (core.Source, error) {
+	timeout := src.Timeout
+	if timeout == 0 {
+		timeout = xkcdCfg.Timeout
+	}
+
+	switch src.Type {
+	case "http":
+		return xkcd.NewClient(src.ID, src.URL, timeout, xkcdCfg.Rate, xkcdCfg.CacheTTL, xkcdCfg.MaxRetries, log)
+	case "mirror":
+		return mirror.New(src.ID, src.Path, log)
+	case feed.FormatJSON, feed.FormatRSS:
+		return feed.New(src.ID, src.URL, src.Type, timeout, xkcdCfg.CacheTTL, keys, log)
+	}
+	return nil, fmt.Errorf("unknown source type: %q", src.Type)
+}
+
 func mustMakeLogger(logLevel string) *slog.Logger {
 	var level slog.Level
 	switch logLevel {
@@ -0,0 +1,99 @@
+package dictionary
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"yadro.com/course/words/core"
+)
+
+// Files reads dictionaries from text files with a word per line,
+// synonym files have a comma separated group of words or phrases per line.
+// Empty lines and lines starting with # are skipped.
+type Files struct {
+	stopWords []string
+	protected []string
+	synonyms  []string
+}
+
+func New(stopWords, protected, synonyms []string) Files {
+	return Files{
+		stopWords: stopWords,
+		protected: protected,
+		synonyms:  synonyms,
+	}
+}
+
+func (f Files) Load() (core.Dictionary, error) {
+	stopWords, err := readWords(f.stopWords)
+	if err != nil {
+		return core.Dictionary{}, err
+	}
+	protected, err := readWords(f.protected)
+	if err != nil {
+		return core.Dictionary{}, err
+	}
+	synonyms, err := readSynonyms(f.synonyms)
+	if err != nil {
+		return core.Dictionary{}, err
+	}
+	return core.Dictionary{
+		StopWords: stopWords,
+		Protected: protected,
+		Synonyms:  synonyms,
+	}, nil
+}
+
+func readWords(paths []string) (map[string]struct{}, error) {
+	words := make(map[string]struct{})
+	for _, path := range paths {
+		err := readFile(path, func(line string) {
+			words[strings.ToLower(line)] = struct{}{}
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read dictionary %s: %w", path, err)
+		}
+	}
+	return words, nil
+}
+
+func readSynonyms(paths []string) ([][]string, error) {
+	var groups [][]string
+	for _, path := range paths {
+		err := readFile(path, func(line string) {
+			var group []string
+			for _, phrase := range strings.Split(line, ",") {
+				if phrase = strings.TrimSpace(phrase); phrase != "" {
+					group = append(group, strings.ToLower(phrase))
+				}
+			}
+			if len(group) > 1 {
+				groups = append(groups, group)
+			}
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read synonyms %s: %w", path, err)
+		}
+	}
+	return groups, nil
+}
+
+func readFile(path string, add func(line string)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		add(line)
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,42 @@
+package dictionary
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestFiles_Load(t *testing.T) {
+	dir := t.TempDir()
+	stop1 := writeFile(t, dir, "stop1.txt", "# noise\nFoo\n\n  bar  \n")
+	stop2 := writeFile(t, dir, "stop2.txt", "baz\n")
+	protected := writeFile(t, dir, "protected.txt", "XKCD\nsudo")
+	synonyms := writeFile(t, dir, "synonyms.txt", "# groups\ncar, Automobile,auto\nML, machine learning\nlonely\n")
+
+	dictionary, err := New([]string{stop1, stop2}, []string{protected}, []string{synonyms}).Load()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]struct{}{"foo": {}, "bar": {}, "baz": {}}, dictionary.StopWords)
+	assert.Equal(t, map[string]struct{}{"xkcd": {}, "sudo": {}}, dictionary.Protected)
+	assert.Equal(t, [][]string{{"car", "automobile", "auto"}, {"ml", "machine learning"}}, dictionary.Synonyms)
+}
+
+func TestFiles_LoadEmpty(t *testing.T) {
+	dictionary, err := New(nil, nil, nil).Load()
+	require.NoError(t, err)
+	assert.Empty(t, dictionary.StopWords)
+	assert.Empty(t, dictionary.Protected)
+}
+
+func TestFiles_LoadMissing(t *testing.T) {
+	_, err := New(nil, nil, []string{filepath.Join(t.TempDir(), "missing.txt")}).Load()
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -2,10 +2,13 @@ package grpc
 
 import (
 	"context"
+	"errors"
+	"io"
 	"log/slog"
 	"strconv"
 	"time"
 
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
@@ -15,6 +18,7 @@ import (
 
 const (
 	maxPhraseLen    = 20000
+	maxBatchSize    = 1000
 	maxShutdownTime = 10 * time.Second
 )
 
@@ -34,15 +38,130 @@ func (s *Server) Ping(_ context.Context, in *emptypb.Empty) (*emptypb.Empty, err
 	return nil, nil
 }
 
+func (s *Server) Reload(_ context.Context, _ *emptypb.Empty) (*emptypb.Empty, error) {
+	if err := s.words.Reload(); err != nil {
+		return nil, status.Error(codes.FailedPrecondition, "failed to reload dictionaries")
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) CacheStats(_ context.Context, _ *emptypb.Empty) (*wordspb.CacheStatsReply, error) {
+	stats := s.words.CacheStats()
+	return &wordspb.CacheStatsReply{
+		Hits:      int64(stats.Hits),
+		Misses:    int64(stats.Misses),
+		Evictions: int64(stats.Evictions),
+		Size:      int64(stats.Size),
+	}, nil
+}
+
 func (s *Server) Norm(_ context.Context, in *wordspb.WordsRequest) (*wordspb.WordsReply, error) {
-	if len(in.GetPhrase()) > maxPhraseLen {
-		slog.Error("phrase is large than max phrase length", "phrase", in.GetPhrase(), "max phrase length", maxPhraseLen)
-		return nil, status.Error(
+	if err := checkPhrase(in.GetPhrase()); err != nil {
+		return nil, err
+	}
+	words, err := s.words.Norm(in.GetPhrase(), in.GetLanguage())
+	if err != nil {
+		return nil, normError(err, in.GetLanguage())
+	}
+	return &wordspb.WordsReply{
+		Words: words,
+	}, nil
+}
+
+func (s *Server) NormBatch(_ context.Context, in *wordspb.NormBatchRequest) (*wordspb.NormBatchReply, error) {
+	if len(in.GetItems()) > maxBatchSize {
+		return nil, status.Error(codes.ResourceExhausted, "batch is larger than "+strconv.Itoa(maxBatchSize))
+	}
+
+	reply := &wordspb.NormBatchReply{Results: make([]*wordspb.NormResult, 0, len(in.GetItems()))}
+	for _, item := range in.GetItems() {
+		reply.Results = append(reply.Results, s.normItem(item))
+	}
+	return reply, nil
+}
+
+func (s *Server) NormStream(stream grpc.ClientStreamingServer[wordspb.WordsRequest, wordspb.NormBatchReply]) error {
+	reply := &wordspb.NormBatchReply{}
+	for {
+		item, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return stream.SendAndClose(reply)
+		}
+		if err != nil {
+			return err
+		}
+		if len(reply.Results) == maxBatchSize {
+			return status.Error(codes.ResourceExhausted, "batch is larger than "+strconv.Itoa(maxBatchSize))
+		}
+		reply.Results = append(reply.Results, s.normItem(item))
+	}
+}
+
+// normItem reports errors in the result so one bad phrase does not fail the batch
+func (s *Server) normItem(item *wordspb.WordsRequest) *wordspb.NormResult {
+	if err := checkPhrase(item.GetPhrase()); err != nil {
+		return &wordspb.NormResult{Error: status.Convert(err).Message()}
+	}
+	words, err := s.words.Norm(item.GetPhrase(), item.GetLanguage())
+	if err != nil {
+		return &wordspb.NormResult{Error: status.Convert(normError(err, item.GetLanguage())).Message()}
+	}
+	return &wordspb.NormResult{Words: words}
+}
+
+func (s *Server) NormQuery(_ context.Context, in *wordspb.WordsRequest) (*wordspb.QueryReply, error) {
+	if err := checkPhrase(in.GetPhrase()); err != nil {
+		return nil, err
+	}
+	terms, err := s.words.NormQuery(in.GetPhrase(), in.GetLanguage())
+	if err != nil {
+		return nil, normError(err, in.GetLanguage())
+	}
+
+	reply := &wordspb.QueryReply{Terms: make([]*wordspb.Term, 0, len(terms))}
+	for _, term := range terms {
+		reply.Terms = append(reply.Terms, &wordspb.Term{Word: term.Word, Weight: term.Weight})
+	}
+	return reply, nil
+}
+
+func (s *Server) Tokenize(_ context.Context, in *wordspb.WordsRequest) (*wordspb.TokenizeReply, error) {
+	if err := checkPhrase(in.GetPhrase()); err != nil {
+		return nil, err
+	}
+	tokens, err := s.words.Tokenize(in.GetPhrase(), in.GetLanguage())
+	if err != nil {
+		return nil, normError(err, in.GetLanguage())
+	}
+
+	reply := &wordspb.TokenizeReply{Tokens: make([]*wordspb.Token, 0, len(tokens))}
+	for _, token := range tokens {
+		reply.Tokens = append(reply.Tokens, &wordspb.Token{
+			Surface:  token.Surface,
+			Stem:     token.Stem,
+			Start:    int64(token.Start),
+			End:      int64(token.End),
+			StopWord: token.StopWord,
+		})
+	}
+	return reply, nil
+}
+
+func checkPhrase(phrase string) error {
+	if len(phrase) > maxPhraseLen {
+		slog.Error("phrase is large than max phrase length", "phrase", phrase, "max phrase length", maxPhraseLen)
+		return status.Error(
 			codes.ResourceExhausted,
 			"phrase is large than "+strconv.Itoa(maxPhraseLen),
 		)
 	}
-	return &wordspb.WordsReply{
-		Words: s.words.Norm(in.GetPhrase()),
-	}, nil
+	return nil
+}
+
+func normError(err error, language string) error {
+	if errors.Is(err, core.ErrUnsupportedLanguage) {
+		return status.Error(codes.InvalidArgument, "unsupported language "+language)
+	}
+	slog.Error("failed to normalize phrase", "error", err)
+	return status.Error(codes.Internal, "failed to normalize phrase")
 }
@@ -2,25 +2,59 @@ package grpc
 
 import (
 	"context"
+	"errors"
+	"io"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
 	wordspb "yadro.com/course/proto/words"
+	"yadro.com/course/words/core"
 )
 
 type MockNormalizer struct {
-	normFunc func(phrase string) []string
+	normFunc  func(phrase string) []string
+	reloadErr error
 }
 
-func (m MockNormalizer) Norm(phrase string) []string {
+func (m MockNormalizer) NormQuery(phrase, language string) ([]core.Term, error) {
+	if language == "xx" {
+		return nil, core.ErrUnsupportedLanguage
+	}
+	return []core.Term{{Word: "car", Weight: 1}, {Word: "automobil", Weight: 0.5}}, nil
+}
+
+func (m MockNormalizer) Tokenize(phrase, language string) ([]core.Token, error) {
+	if language == "xx" {
+		return nil, core.ErrUnsupportedLanguage
+	}
+	return []core.Token{
+		{Surface: "The", Start: 0, End: 3, StopWord: true},
+		{Surface: "cats", Stem: "cat", Start: 4, End: 8},
+	}, nil
+}
+
+func (m MockNormalizer) CacheStats() core.CacheStats {
+	return core.CacheStats{Hits: 3, Misses: 2, Evictions: 1, Size: 2}
+}
+
+func (m MockNormalizer) Reload() error {
+	return m.reloadErr
+}
+
+func (m MockNormalizer) Norm(phrase, language string) ([]string, error) {
+	if language == "xx" {
+		return nil, core.ErrUnsupportedLanguage
+	}
 	if m.normFunc != nil {
-		return m.normFunc(phrase)
+		return m.normFunc(phrase), nil
 	}
-	return []string{}
+	return []string{}, nil
 }
 
 func TestServer_Ping(t *testing.T) {
@@ -32,6 +66,62 @@ func TestServer_Ping(t *testing.T) {
 	assert.Nil(t, resp)
 }
 
+func TestServer_CacheStats(t *testing.T) {
+	server := New(MockNormalizer{})
+	resp, err := server.CacheStats(context.Background(), &emptypb.Empty{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), resp.Hits)
+	assert.Equal(t, int64(2), resp.Misses)
+	assert.Equal(t, int64(1), resp.Evictions)
+	assert.Equal(t, int64(2), resp.Size)
+}
+
+func TestServer_Reload(t *testing.T) {
+	server := New(MockNormalizer{})
+	resp, err := server.Reload(context.Background(), &emptypb.Empty{})
+	require.NoError(t, err)
+	assert.NotNil(t, resp)
+
+	server = New(MockNormalizer{reloadErr: errors.New("no such file")})
+	_, err = server.Reload(context.Background(), &emptypb.Empty{})
+	require.Error(t, err)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestServer_NormQuery(t *testing.T) {
+	server := New(MockNormalizer{})
+
+	resp, err := server.NormQuery(context.Background(), &wordspb.WordsRequest{Phrase: "car"})
+	require.NoError(t, err)
+	require.Len(t, resp.Terms, 2)
+	assert.Equal(t, "car", resp.Terms[0].Word)
+	assert.Equal(t, 1.0, resp.Terms[0].Weight)
+	assert.Equal(t, "automobil", resp.Terms[1].Word)
+	assert.Equal(t, 0.5, resp.Terms[1].Weight)
+
+	_, err = server.NormQuery(context.Background(), &wordspb.WordsRequest{Phrase: "car", Language: "xx"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = server.NormQuery(context.Background(), &wordspb.WordsRequest{Phrase: strings.Repeat("a", maxPhraseLen+1)})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestServer_Tokenize(t *testing.T) {
+	server := New(MockNormalizer{})
+
+	resp, err := server.Tokenize(context.Background(), &wordspb.WordsRequest{Phrase: "The cats"})
+	require.NoError(t, err)
+	require.Len(t, resp.Tokens, 2)
+	assert.Equal(t, "The", resp.Tokens[0].Surface)
+	assert.True(t, resp.Tokens[0].StopWord)
+	assert.Equal(t, "cat", resp.Tokens[1].Stem)
+	assert.Equal(t, int64(4), resp.Tokens[1].Start)
+	assert.Equal(t, int64(8), resp.Tokens[1].End)
+
+	_, err = server.Tokenize(context.Background(), &wordspb.WordsRequest{Phrase: "cats", Language: "xx"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
 func TestServer_Norm(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -61,6 +151,12 @@ func TestServer_Norm(t *testing.T) {
 				Words: []string{},
 			},
 		},
+		{
+			name:      "unsupported language",
+			request:   &wordspb.WordsRequest{Phrase: "hello", Language: "xx"},
+			wantError: true,
+			errorCode: codes.InvalidArgument,
+		},
 		{
 			name: "phrase too long",
 			request: &wordspb.WordsRequest{
@@ -97,3 +193,72 @@ func TestServer_Norm(t *testing.T) {
 		})
 	}
 }
+
+func TestServer_NormBatch(t *testing.T) {
+	normalizer := MockNormalizer{normFunc: func(phrase string) []string {
+		return strings.Fields(phrase)
+	}}
+	server := New(normalizer)
+
+	resp, err := server.NormBatch(context.Background(), &wordspb.NormBatchRequest{Items: []*wordspb.WordsRequest{
+		{Phrase: "hello world"},
+		{Phrase: "hello", Language: "xx"},
+		{Phrase: strings.Repeat("a", maxPhraseLen+1)},
+		{Phrase: "bye"},
+	}})
+	require.NoError(t, err)
+	require.Len(t, resp.Results, 4)
+	assert.Equal(t, []string{"hello", "world"}, resp.Results[0].Words)
+	assert.Empty(t, resp.Results[0].Error)
+	assert.Equal(t, "unsupported language xx", resp.Results[1].Error)
+	assert.Contains(t, resp.Results[2].Error, "phrase is large than")
+	assert.Equal(t, []string{"bye"}, resp.Results[3].Words)
+
+	_, err = server.NormBatch(context.Background(), &wordspb.NormBatchRequest{
+		Items: make([]*wordspb.WordsRequest, maxBatchSize+1),
+	})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+type mockNormStream struct {
+	grpc.ServerStream
+	items []*wordspb.WordsRequest
+	reply *wordspb.NormBatchReply
+}
+
+func (m *mockNormStream) Recv() (*wordspb.WordsRequest, error) {
+	if len(m.items) == 0 {
+		return nil, io.EOF
+	}
+	item := m.items[0]
+	m.items = m.items[1:]
+	return item, nil
+}
+
+func (m *mockNormStream) SendAndClose(reply *wordspb.NormBatchReply) error {
+	m.reply = reply
+	return nil
+}
+
+func TestServer_NormStream(t *testing.T) {
+	normalizer := MockNormalizer{normFunc: func(phrase string) []string {
+		return strings.Fields(phrase)
+	}}
+	server := New(normalizer)
+
+	stream := &mockNormStream{items: []*wordspb.WordsRequest{
+		{Phrase: "hello world"},
+		{Phrase: "hi", Language: "xx"},
+	}}
+	require.NoError(t, server.NormStream(stream))
+	require.Len(t, stream.reply.Results, 2)
+	assert.Equal(t, []string{"hello", "world"}, stream.reply.Results[0].Words)
+	assert.Equal(t, "unsupported language xx", stream.reply.Results[1].Error)
+
+	items := make([]*wordspb.WordsRequest, maxBatchSize+1)
+	for i := range items {
+		items[i] = &wordspb.WordsRequest{Phrase: "a"}
+	}
+	err := server.NormStream(&mockNormStream{items: items})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
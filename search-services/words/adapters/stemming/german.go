@@ -0,0 +1,183 @@
+package stemming
+
+import "strings"
+
+// kljensen/snowball has no german stemmer, so this is a port of
+// https://snowballstem.org/algorithms/german/stemmer.html
+
+var germanStopWords = toSet(`aber alle allem allen aller alles als also am an ander andere anderem anderen
+	anderer anderes anderm andern anderr anders auch auf aus bei bin bis bist da damit dann der den des dem die
+	das dass daß derselbe derselben denselben desselben demselben dieselbe dieselben dasselbe dazu dein deine
+	deinem deinen deiner deines denn derer dessen dich dir du dies diese diesem diesen dieser dieses doch dort
+	durch ein eine einem einen einer eines einig einige einigem einigen einiger einiges einmal er ihn ihm es
+	etwas euer eure eurem euren eurer eures für gegen gewesen hab habe haben hat hatte hatten hier hin hinter
+	ich mich mir ihr ihre ihrem ihren ihrer ihres euch im in indem ins ist jede jedem jeden jeder jedes jene
+	jenem jenen jener jenes jetzt kann kein keine keinem keinen keiner keines können könnte machen man manche
+	manchem manchen mancher manches mein meine meinem meinen meiner meines mit muss musste nach nicht nichts
+	noch nun nur ob oder ohne sehr sein seine seinem seinen seiner seines selbst sich sie ihnen sind so solche
+	solchem solchen solcher solches soll sollte sondern sonst über um und uns unsere unserem unseren unser
+	unseres unter viel vom von vor während war waren warst was weg weil weiter welche welchem welchen welcher
+	welches wenn werde werden wie wieder will wir wird wirst wo wollen wollte würde würden zu zum zur zwar
+	zwischen`)
+
+func toSet(words string) map[string]struct{} {
+	set := make(map[string]struct{})
+	for _, word := range strings.Fields(words) {
+		set[word] = struct{}{}
+	}
+	return set
+}
+
+func germanIsStopWord(word string) bool {
+	_, ok := germanStopWords[word]
+	return ok
+}
+
+func germanStem(word string, stemStopWords bool) string {
+	if !stemStopWords && germanIsStopWord(word) {
+		return word
+	}
+
+	w := []rune(strings.ReplaceAll(word, "ß", "ss"))
+
+	// u and y between vowels are consonants
+	for i := 1; i < len(w)-1; i++ {
+		if !isGermanVowel(w[i-1]) || !isGermanVowel(w[i+1]) {
+			continue
+		}
+		switch w[i] {
+		case 'u':
+			w[i] = 'U'
+		case 'y':
+			w[i] = 'Y'
+		}
+	}
+
+	r1 := germanRegion(w, 0)
+	r2 := germanRegion(w, r1)
+	r1 = min(max(r1, 3), len(w))
+
+	w = germanStep1(w, r1)
+	w = germanStep2(w, r1)
+	w = germanStep3(w, r1, r2)
+
+	for i, r := range w {
+		switch r {
+		case 'U', 'ü':
+			w[i] = 'u'
+		case 'Y':
+			w[i] = 'y'
+		case 'ä':
+			w[i] = 'a'
+		case 'ö':
+			w[i] = 'o'
+		}
+	}
+	return string(w)
+}
+
+func isGermanVowel(r rune) bool {
+	return strings.ContainsRune("aeiouyäöü", r)
+}
+
+// germanRegion returns the position after the first non-vowel following a vowel
+func germanRegion(w []rune, start int) int {
+	for i := start + 1; i < len(w); i++ {
+		if isGermanVowel(w[i-1]) && !isGermanVowel(w[i]) {
+			return i + 1
+		}
+	}
+	return len(w)
+}
+
+// longestSuffix returns the longest of the suffixes the word ends with
+func longestSuffix(w []rune, suffixes ...string) string {
+	found := ""
+	for _, s := range suffixes {
+		if len([]rune(s)) > len([]rune(found)) && hasSuffix(w, s) {
+			found = s
+		}
+	}
+	return found
+}
+
+func hasSuffix(w []rune, s string) bool {
+	return strings.HasSuffix(string(w), s)
+}
+
+func trim(w []rune, s string) []rune {
+	return w[:len(w)-len([]rune(s))]
+}
+
+func inRegion(w []rune, s string, region int) bool {
+	return len(w)-len([]rune(s)) >= region
+}
+
+func germanStep1(w []rune, r1 int) []rune {
+	suffix := longestSuffix(w, "em", "ern", "er", "e", "en", "es", "s")
+	if suffix == "" || !inRegion(w, suffix, r1) {
+		return w
+	}
+
+	switch suffix {
+	case "em", "ern", "er":
+		return trim(w, suffix)
+	case "e", "en", "es":
+		w = trim(w, suffix)
+		if hasSuffix(w, "niss") {
+			w = w[:len(w)-1]
+		}
+		return w
+	default:
+		if i := len(w) - 2; i >= 0 && strings.ContainsRune("bdfghklmnrt", w[i]) {
+			return trim(w, suffix)
+		}
+		return w
+	}
+}
+
+func germanStep2(w []rune, r1 int) []rune {
+	suffix := longestSuffix(w, "en", "er", "est", "st")
+	if suffix == "" || !inRegion(w, suffix, r1) {
+		return w
+	}
+
+	if suffix != "st" {
+		return trim(w, suffix)
+	}
+	// st must follow a valid st-ending which itself is preceded by at least 3 letters
+	if i := len(w) - 3; i >= 3 && strings.ContainsRune("bdfghklmnt", w[i]) {
+		return trim(w, suffix)
+	}
+	return w
+}
+
+func germanStep3(w []rune, r1, r2 int) []rune {
+	suffix := longestSuffix(w, "end", "ung", "ig", "ik", "isch", "lich", "heit", "keit")
+	if suffix == "" || !inRegion(w, suffix, r2) {
+		return w
+	}
+
+	switch suffix {
+	case "end", "ung":
+		w = trim(w, suffix)
+		if hasSuffix(w, "ig") && inRegion(w, "ig", r2) && !hasSuffix(trim(w, "ig"), "e") {
+			w = trim(w, "ig")
+		}
+	case "ig", "ik", "isch":
+		if !hasSuffix(trim(w, suffix), "e") {
+			w = trim(w, suffix)
+		}
+	case "lich", "heit":
+		w = trim(w, suffix)
+		if s := longestSuffix(w, "er", "en"); s != "" && inRegion(w, s, r1) {
+			w = trim(w, s)
+		}
+	case "keit":
+		w = trim(w, suffix)
+		if s := longestSuffix(w, "lich", "ig"); s != "" && inRegion(w, s, r2) {
+			w = trim(w, s)
+		}
+	}
+	return w
+}
@@ -4,14 +4,40 @@ import (
 	"strings"
 
 	"github.com/kljensen/snowball/english"
+	"github.com/kljensen/snowball/french"
+	"github.com/kljensen/snowball/russian"
+	"github.com/kljensen/snowball/spanish"
+	"yadro.com/course/words/core"
 )
 
+type language struct {
+	stem       func(word string, stemStopWords bool) string
+	isStopWord func(word string) bool
+}
+
+var languages = map[string]language{
+	core.English: {stem: english.Stem, isStopWord: english.IsStopWord},
+	core.Russian: {stem: russian.Stem, isStopWord: russian.IsStopWord},
+	core.Spanish: {stem: spanish.Stem, isStopWord: spanish.IsStopWord},
+	core.French:  {stem: french.Stem, isStopWord: french.IsStopWord},
+	core.German:  {stem: germanStem, isStopWord: germanIsStopWord},
+}
+
 type Snowball struct{}
 
-func (s Snowball) Stem(word string) string {
+func (s Snowball) Supports(lang string) bool {
+	_, ok := languages[lang]
+	return ok
+}
+
+func (s Snowball) Stem(word, lang string) string {
 	word = strings.ToLower(word)
-	if english.IsStopWord(word) {
+	l, ok := languages[lang]
+	if !ok {
+		return word
+	}
+	if l.isStopWord(word) {
 		return ""
 	}
-	return english.Stem(word, false)
+	return l.stem(word, false)
 }
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"yadro.com/course/words/core"
 )
 
 func TestSnowball_Stem(t *testing.T) {
@@ -68,8 +69,43 @@ func TestSnowball_Stem(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := stemmer.Stem(tt.input)
+			result := stemmer.Stem(tt.input, core.English)
 			assert.Equal(t, tt.expected, result)
 		})
 	}
 }
+
+func TestSnowball_Languages(t *testing.T) {
+	tests := []struct {
+		name     string
+		language string
+		input    string
+		expected string
+	}{
+		{name: "russian", language: core.Russian, input: "Комиксы", expected: "комикс"},
+		{name: "russian stop word", language: core.Russian, input: "и", expected: ""},
+		{name: "spanish", language: core.Spanish, input: "gatos", expected: "gat"},
+		{name: "french", language: core.French, input: "chats", expected: "chat"},
+		{name: "german plural", language: core.German, input: "Häuser", expected: "haus"},
+		{name: "german verb", language: core.German, input: "laufen", expected: "lauf"},
+		{name: "german sharp s", language: core.German, input: "Straße", expected: "strass"},
+		{name: "german stop word", language: core.German, input: "und", expected: ""},
+		{name: "unknown language", language: "xx", input: "Word", expected: "word"},
+	}
+
+	stemmer := Snowball{}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, stemmer.Stem(tt.input, tt.language))
+		})
+	}
+}
+
+func TestSnowball_Supports(t *testing.T) {
+	stemmer := Snowball{}
+	for _, language := range []string{core.English, core.Russian, core.Spanish, core.French, core.German} {
+		assert.True(t, stemmer.Supports(language), language)
+	}
+	assert.False(t, stemmer.Supports("xx"))
+}
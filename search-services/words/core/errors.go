@@ -0,0 +1,5 @@
+package core
+
+import "errors"
+
+var ErrUnsupportedLanguage = errors.New("unsupported language")
@@ -0,0 +1,22 @@
+package core
+
+import "unicode"
+
+const (
+	English = "en"
+	Russian = "ru"
+	Spanish = "es"
+	French  = "fr"
+	German  = "de"
+)
+
+// DetectLanguage guesses the language of a word by its script.
+// Latin languages can't be told apart this way, so latin words are english.
+func DetectLanguage(word string) string {
+	for _, r := range word {
+		if unicode.Is(unicode.Cyrillic, r) {
+			return Russian
+		}
+	}
+	return English
+}
@@ -0,0 +1,33 @@
+package core
+
+// Dictionary holds lowercase words applied before stemming:
+// stop words are dropped, protected words are kept as is.
+// Synonyms are groups of interchangeable words or phrases used to expand queries.
+type Dictionary struct {
+	StopWords map[string]struct{}
+	Protected map[string]struct{}
+	Synonyms  [][]string
+}
+
+// Token is a phrase word in its original order, Start and End are byte offsets
+// of the surface form in the phrase. Stop words have an empty Stem.
+type Token struct {
+	Surface  string
+	Stem     string
+	Start    int
+	End      int
+	StopWord bool
+}
+
+type CacheStats struct {
+	Hits      int
+	Misses    int
+	Evictions int
+	Size      int
+}
+
+// Term is a normalized query word, synonyms weigh less than the query words
+type Term struct {
+	Word   string
+	Weight float64
+}
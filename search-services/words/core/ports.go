@@ -2,10 +2,28 @@ package core
 
 // our logic
 type Normalizer interface {
-	Norm(phrase string) []string
+	Norm(phrase, language string) ([]string, error)
+	NormQuery(phrase, language string) ([]Term, error)
+	Tokenize(phrase, language string) ([]Token, error)
+	Reload() error
+	CacheStats() CacheStats
 }
 
-// external stemmer
+// external stemmer, language is an ISO 639-1 code
 type Stemmer interface {
-	Stem(word string) string
+	Stem(word, language string) string
+	Supports(language string) bool
+}
+
+// custom dictionaries storage
+type DictionaryLoader interface {
+	Load() (Dictionary, error)
+}
+
+// normalization results cache
+type Cache interface {
+	Get(key string) (any, bool)
+	Add(key string, value any)
+	Purge()
+	Stats() CacheStats
 }
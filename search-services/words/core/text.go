@@ -0,0 +1,240 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// how hyphens and apostrophes between letters are treated
+const (
+	JoinerSplit = "split" // e-mail -> e, mail
+	JoinerJoin  = "join"  // e-mail -> email
+	JoinerKeep  = "keep"  // e-mail -> e-mail
+)
+
+// TextOptions configures how words are prepared before stemming
+type TextOptions struct {
+	// NFKC turns full-width forms and ligatures into plain letters
+	NFKC bool
+	// FoldAccents drops diacritics of latin letters, cyrillic й and ё are kept
+	FoldAccents bool
+	Hyphens     string
+	Apostrophes string
+	// Identifiers keeps numbers and tokens like C++, C#, IPv6, 2^64, 1.2.3 or HTTP 418 whole
+	Identifiers bool
+}
+
+func (o TextOptions) Validate() error {
+	for _, mode := range []string{o.Hyphens, o.Apostrophes} {
+		switch mode {
+		case "", JoinerSplit, JoinerJoin, JoinerKeep:
+		default:
+			return fmt.Errorf("unknown hyphen or apostrophe mode: %s", mode)
+		}
+	}
+	return nil
+}
+
+func isSeparator(r rune) bool {
+	return !unicode.IsDigit(r) && !unicode.IsLetter(r)
+}
+
+func isHyphen(r rune) bool {
+	return unicode.Is(unicode.Dash, r)
+}
+
+func isApostrophe(r rune) bool {
+	switch r {
+	case '\'', '’', 'ʼ', '＇':
+		return true
+	}
+	return false
+}
+
+// span is a word of a phrase, start and end are byte offsets
+type span struct {
+	start int
+	end   int
+}
+
+// spans splits the phrase into words, hyphens and apostrophes stay
+// inside a word only when surrounded by letters or digits and not split
+func (o TextOptions) spans(phrase string) []span {
+	type position struct {
+		r rune
+		i int
+	}
+	runes := make([]position, 0, len(phrase))
+	for i, r := range phrase {
+		runes = append(runes, position{r, i})
+	}
+
+	at := func(k int) rune {
+		if k < 0 || k >= len(runes) {
+			return 0
+		}
+		return runes[k].r
+	}
+
+	inWord := func(k int) bool {
+		r := runes[k].r
+		switch {
+		case !isSeparator(r):
+			return true
+		case isHyphen(r) && o.Hyphens != "" && o.Hyphens != JoinerSplit,
+			isApostrophe(r) && o.Apostrophes != "" && o.Apostrophes != JoinerSplit:
+			return !isSeparator(at(k-1)) && !isSeparator(at(k+1))
+		case o.Identifiers:
+			return identifierSymbol(r, k, at)
+		}
+		return false
+	}
+
+	var spans []span
+	start := -1
+	for k := range runes {
+		switch {
+		case inWord(k) && start < 0:
+			start = runes[k].i
+		case !inWord(k) && start >= 0:
+			spans = append(spans, span{start, runes[k].i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, span{start, len(phrase)})
+	}
+	return spans
+}
+
+// protocols are joined with the number after them, HTTP 418 is one word.
+// Only known names: transcripts are mostly upper case, I HAVE 3 CATS must stay apart.
+var protocols = map[string]struct{}{
+	"HTTP": {}, "HTTPS": {}, "FTP": {}, "SMTP": {}, "TLS": {}, "SSL": {}, "IPv": {},
+	"RFC": {}, "ISO": {}, "IEEE": {}, "ECMA": {}, "PEP": {}, "USB": {},
+}
+
+// identifierSymbol reports whether the symbol at k is a part of an identifier:
+// a dot between digits, a caret between letters or digits, ++ and # right after a letter,
+// or a space between a known protocol name and its number
+func identifierSymbol(r rune, k int, at func(int) rune) bool {
+	switch r {
+	case ' ':
+		var name []rune
+		for start := k - 1; unicode.IsLetter(at(start)); start-- {
+			name = append([]rune{at(start)}, name...)
+		}
+		if _, ok := protocols[string(name)]; !ok || !isSeparator(at(k-len(name)-1)) {
+			return false
+		}
+		end := k + 1
+		for unicode.IsDigit(at(end)) {
+			end++
+		}
+		return end > k+1 && !unicode.IsLetter(at(end))
+	case '.':
+		return unicode.IsDigit(at(k-1)) && unicode.IsDigit(at(k+1))
+	case '^':
+		return !isSeparator(at(k-1)) && !isSeparator(at(k+1))
+	case '+', '#':
+		start, end := k, k
+		for at(start-1) == r {
+			start--
+		}
+		for at(end+1) == r {
+			end++
+		}
+		length := end - start + 1
+		if length > 2 || r == '#' && length > 1 {
+			return false
+		}
+		return unicode.IsLetter(at(start-1)) && isSeparator(at(end+1))
+	}
+	return false
+}
+
+// identifier reports whether the prepared word is a number or contains symbols
+func (o TextOptions) identifier(word string) bool {
+	if !o.Identifiers {
+		return false
+	}
+	for _, r := range word {
+		if unicode.IsDigit(r) || isSeparator(r) && !isHyphen(r) && !isApostrophe(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// identifierParts splits an identifier into letter and digit runs: IPv6 -> IPv, 6
+func identifierParts(word string) []string {
+	var parts []string
+	start, digits := -1, false
+	for i, r := range word {
+		alnum := !isSeparator(r)
+		if start >= 0 && (!alnum || unicode.IsDigit(r) != digits) {
+			parts = append(parts, word[start:i])
+			start = -1
+		}
+		if alnum && start < 0 {
+			start, digits = i, unicode.IsDigit(r)
+		}
+	}
+	if start >= 0 {
+		parts = append(parts, word[start:])
+	}
+	return parts
+}
+
+func (o TextOptions) split(phrase string) []string {
+	spans := o.spans(phrase)
+	words := make([]string, 0, len(spans))
+	for _, s := range spans {
+		words = append(words, phrase[s.start:s.end])
+	}
+	return words
+}
+
+// prepare normalizes a word found by spans before stemming
+func (o TextOptions) prepare(word string) string {
+	if o.NFKC {
+		word = norm.NFKC.String(word)
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case isHyphen(r) && o.Hyphens == JoinerJoin, isApostrophe(r) && o.Apostrophes == JoinerJoin:
+			return -1
+		case isHyphen(r):
+			return '-'
+		case isApostrophe(r):
+			return '\''
+		case r == ' ':
+			// words are stored separated by spaces, HTTP 418 becomes HTTP_418
+			return '_'
+		}
+		return r
+	}, word)
+}
+
+// fold removes diacritics of latin letters
+func (o TextOptions) fold(word string) string {
+	if !o.FoldAccents {
+		return word
+	}
+	var b strings.Builder
+	latin := false
+	for _, r := range norm.NFD.String(word) {
+		if unicode.Is(unicode.Mn, r) {
+			if latin {
+				continue
+			}
+		} else {
+			latin = unicode.Is(unicode.Latin, r)
+		}
+		b.WriteRune(r)
+	}
+	return norm.NFC.String(b.String())
+}
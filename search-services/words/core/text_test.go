@@ -0,0 +1,36 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTextOptions_Split_ProtocolNumber(t *testing.T) {
+	tests := []struct {
+		name   string
+		phrase string
+		want   []string
+	}{
+		{name: "status code", phrase: "got HTTP 418 back", want: []string{"got", "HTTP 418", "back"}},
+		{name: "version", phrase: "HTTP 1.1.", want: []string{"HTTP 1.1"}},
+		{name: "standard", phrase: "(RFC 2324)", want: []string{"RFC 2324"}},
+		{name: "lower case", phrase: "http 418", want: []string{"http", "418"}},
+		{name: "single letter", phrase: "A 4", want: []string{"A", "4"}},
+		{name: "mixed case protocol", phrase: "IPv 6", want: []string{"IPv 6"}},
+		{name: "unknown name", phrase: "Python 3", want: []string{"Python", "3"}},
+		{name: "upper case sentence", phrase: "I HAVE 3 CATS AND 2 DOGS", want: []string{"I", "HAVE", "3", "CATS", "AND", "2", "DOGS"}},
+		{name: "protocol inside a word", phrase: "XHTTP 2", want: []string{"XHTTP", "2"}},
+		{name: "ordinal", phrase: "HTTP 2nd", want: []string{"HTTP", "2nd"}},
+		{name: "two spaces", phrase: "HTTP  418", want: []string{"HTTP", "418"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, TextOptions{Identifiers: true}.split(tt.phrase))
+		})
+	}
+
+	// без режима идентификаторов пробел всегда разделяет слова
+	assert.Equal(t, []string{"HTTP", "418"}, TextOptions{}.split("HTTP 418"))
+}
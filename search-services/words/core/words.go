@@ -1,37 +1,303 @@
 package core
 
 import (
+	"cmp"
 	"maps"
 	"slices"
+	"strconv"
 	"strings"
-	"unicode"
+	"sync"
 
 	"log/slog"
 )
 
+// synonymWeight is the weight of the terms added by synonym expansion
+const synonymWeight = 0.5
+
+// partWeight scales the weight of identifier parts, C++ finds C with a lower score
+const partWeight = 0.5
+
 type Words struct {
-	stemmer Stemmer
+	stemmer    Stemmer
+	loader     DictionaryLoader
+	cache      Cache
+	text       TextOptions
+	mu         *sync.RWMutex
+	dictionary Dictionary
+	synonyms   synonyms
+	// generation is a part of the cache key and changes with the dictionaries,
+	// so a call that was running during Reload can't put an old result back
+	generation uint64
 }
 
-func NewWords(stemmer Stemmer) Words {
-	return Words{stemmer: stemmer}
+// synonyms maps a lowercase phrase to the phrases it can be replaced with
+type synonyms struct {
+	phrases   map[string][]string
+	maxLength int
+}
+
+// NewWords loads the dictionaries right away, loader and cache may be nil
+func NewWords(stemmer Stemmer, loader DictionaryLoader, cache Cache, text TextOptions) (*Words, error) {
+	if err := text.Validate(); err != nil {
+		return nil, err
+	}
+	w := &Words{
+		stemmer: stemmer,
+		loader:  loader,
+		cache:   cache,
+		text:    text,
+		mu:      &sync.RWMutex{},
+	}
+	if err := w.Reload(); err != nil {
+		return nil, err
+	}
+	return w, nil
 }
 
-func (w Words) Norm(phrase string) []string {
+// Reload rereads the dictionaries, the old ones stay in use on error
+func (w *Words) Reload() error {
+	if w.loader == nil {
+		return nil
+	}
 
-	splitted := strings.FieldsFunc(phrase, func(r rune) bool {
-		return !unicode.IsDigit(r) && !unicode.IsLetter(r)
-	})
+	dictionary, err := w.loader.Load()
+	if err != nil {
+		slog.Error("failed to load dictionaries", "error", err)
+		return err
+	}
+
+	w.mu.Lock()
+	w.dictionary = dictionary
+	w.synonyms = w.newSynonyms(dictionary.Synonyms)
+	w.generation++
+	w.mu.Unlock()
+
+	// cached results were made with the old dictionaries
+	if w.cache != nil {
+		w.cache.Purge()
+	}
+
+	slog.Info("dictionaries loaded",
+		"stop_words", len(dictionary.StopWords),
+		"protected_words", len(dictionary.Protected),
+		"synonym_groups", len(dictionary.Synonyms))
+	return nil
+}
+
+// Norm stems the phrase words, when language is empty it is detected for every word
+func (w *Words) Norm(phrase, language string) ([]string, error) {
+	language = strings.ToLower(language)
+	if language != "" && !w.stemmer.Supports(language) {
+		return nil, ErrUnsupportedLanguage
+	}
+
+	w.mu.RLock()
+	dictionary, generation := w.dictionary, w.generation
+	w.mu.RUnlock()
+
+	key := cacheKey("norm", phrase, language, generation)
+	if cached, ok := w.cached(key); ok {
+		return slices.Clone(cached.([]string)), nil
+	}
 
 	words := make(map[string]bool)
-	for _, word := range splitted {
-		stemmed := w.stemmer.Stem(word)
-		if len(stemmed) > 0 {
+	for _, word := range w.text.split(phrase) {
+		stemmed, parts := w.normToken(word, language, dictionary)
+		if stemmed != "" {
 			words[stemmed] = true
 		}
+		for _, part := range parts {
+			words[part] = true
+		}
+	}
+
+	slog.Info("words normalized", "language", language, "words", words)
+
+	result := slices.Collect(maps.Keys(words))
+	w.store(key, slices.Clone(result))
+	return result, nil
+}
+
+// NormQuery normalizes a search query and expands it with synonyms,
+// the longest synonym phrase starting at a word wins.
+func (w *Words) NormQuery(phrase, language string) ([]Term, error) {
+	language = strings.ToLower(language)
+	if language != "" && !w.stemmer.Supports(language) {
+		return nil, ErrUnsupportedLanguage
+	}
+
+	w.mu.RLock()
+	dictionary, synonyms, generation := w.dictionary, w.synonyms, w.generation
+	w.mu.RUnlock()
+
+	key := cacheKey("query", phrase, language, generation)
+	if cached, ok := w.cached(key); ok {
+		return slices.Clone(cached.([]Term)), nil
+	}
+
+	weights := make(map[string]float64)
+	add := func(words []string, weight float64) {
+		for _, word := range words {
+			stemmed, parts := w.normToken(word, language, dictionary)
+			if stemmed != "" {
+				weights[stemmed] = max(weights[stemmed], weight)
+			}
+			for _, part := range parts {
+				weights[part] = max(weights[part], weight*partWeight)
+			}
+		}
+	}
+
+	tokens := w.text.split(phrase)
+	keys := w.keys(tokens)
+	for i := 0; i < len(tokens); {
+		n, alternatives := synonyms.match(keys[i:])
+		if n == 0 {
+			n = 1
+		}
+		add(tokens[i:i+n], 1)
+		for _, alternative := range alternatives {
+			add(w.text.split(alternative), synonymWeight)
+		}
+		i += n
+	}
+
+	terms := make([]Term, 0, len(weights))
+	for word, weight := range weights {
+		terms = append(terms, Term{Word: word, Weight: weight})
 	}
+	slices.SortFunc(terms, func(a, b Term) int {
+		if c := cmp.Compare(b.Weight, a.Weight); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Word, b.Word)
+	})
 
-	slog.Info("words normalized", "words", words)
+	slog.Info("query normalized", "language", language, "terms", terms)
 
-	return slices.Collect(maps.Keys(words))
+	w.store(key, slices.Clone(terms))
+	return terms, nil
+}
+
+// Tokenize keeps every word of the phrase with its position, unlike Norm
+func (w *Words) Tokenize(phrase, language string) ([]Token, error) {
+	language = strings.ToLower(language)
+	if language != "" && !w.stemmer.Supports(language) {
+		return nil, ErrUnsupportedLanguage
+	}
+
+	w.mu.RLock()
+	dictionary := w.dictionary
+	w.mu.RUnlock()
+
+	var tokens []Token
+	for _, s := range w.text.spans(phrase) {
+		surface := phrase[s.start:s.end]
+		stem := w.normWord(surface, language, dictionary)
+		tokens = append(tokens, Token{
+			Surface:  surface,
+			Stem:     stem,
+			Start:    s.start,
+			End:      s.end,
+			StopWord: stem == "",
+		})
+	}
+	return tokens, nil
+}
+
+func (w *Words) CacheStats() CacheStats {
+	if w.cache == nil {
+		return CacheStats{}
+	}
+	return w.cache.Stats()
+}
+
+func (w *Words) cached(key string) (any, bool) {
+	if w.cache == nil {
+		return nil, false
+	}
+	return w.cache.Get(key)
+}
+
+func (w *Words) store(key string, value any) {
+	if w.cache != nil {
+		w.cache.Add(key, value)
+	}
+}
+
+func cacheKey(method, phrase, language string, generation uint64) string {
+	return strconv.FormatUint(generation, 10) + "\x00" + method + "\x00" + language + "\x00" + phrase
+}
+
+func (w *Words) normWord(word, language string, dictionary Dictionary) string {
+	word = w.text.prepare(word)
+	lower := strings.ToLower(word)
+	if _, ok := dictionary.StopWords[lower]; ok {
+		return ""
+	}
+	if _, ok := dictionary.Protected[lower]; ok || w.text.identifier(word) {
+		return w.text.fold(lower)
+	}
+	if language == "" {
+		language = DetectLanguage(word)
+	}
+	return w.text.fold(w.stemmer.Stem(word, language))
+}
+
+// normToken normalizes a word, identifiers also yield their letter and digit parts
+func (w *Words) normToken(word, language string, dictionary Dictionary) (string, []string) {
+	stemmed := w.normWord(word, language, dictionary)
+	prepared := w.text.prepare(word)
+	if stemmed == "" || !w.text.identifier(prepared) {
+		return stemmed, nil
+	}
+
+	var parts []string
+	for _, part := range identifierParts(prepared) {
+		if p := w.normWord(part, language, dictionary); p != "" && p != stemmed {
+			parts = append(parts, p)
+		}
+	}
+	return stemmed, parts
+}
+
+// keys prepares words for synonym matching
+func (w *Words) keys(words []string) []string {
+	keys := make([]string, 0, len(words))
+	for _, word := range words {
+		keys = append(keys, strings.ToLower(w.text.prepare(word)))
+	}
+	return keys
+}
+
+func (w *Words) newSynonyms(groups [][]string) synonyms {
+	s := synonyms{phrases: make(map[string][]string)}
+	for _, group := range groups {
+		for i, phrase := range group {
+			words := w.keys(w.text.split(phrase))
+			if len(words) == 0 {
+				continue
+			}
+			key := strings.Join(words, " ")
+			for j, other := range group {
+				if i != j {
+					s.phrases[key] = append(s.phrases[key], other)
+				}
+			}
+			s.maxLength = max(s.maxLength, len(words))
+		}
+	}
+	return s
+}
+
+// match returns the number of keys matched by the longest synonym phrase
+// and the phrases to expand it with
+func (s synonyms) match(keys []string) (int, []string) {
+	for n := min(s.maxLength, len(keys)); n > 0; n-- {
+		key := strings.Join(keys[:n], " ")
+		if alternatives, ok := s.phrases[key]; ok {
+			return n, alternatives
+		}
+	}
+	return 0, nil
 }
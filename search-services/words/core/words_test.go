@@ -1,6 +1,8 @@
 package core
 
 import (
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -10,19 +12,25 @@ type MockStemmer struct {
 	stemFunc func(word string) string
 }
 
-func (m MockStemmer) Stem(word string) string {
+func (m MockStemmer) Stem(word, language string) string {
 	if m.stemFunc != nil {
 		return m.stemFunc(word)
 	}
-	return word
+	return language + ":" + word
+}
+
+func (m MockStemmer) Supports(language string) bool {
+	return language == English || language == Russian
 }
 
 func TestWords_Norm(t *testing.T) {
 	tests := []struct {
 		name     string
 		phrase   string
+		language string
 		stemFunc func(string) string
 		want     []string
+		wantErr  error
 	}{
 		{
 			name:   "empty string",
@@ -32,17 +40,17 @@ func TestWords_Norm(t *testing.T) {
 		{
 			name:   "simple words",
 			phrase: "hello world",
-			want:   []string{"hello", "world"},
+			want:   []string{"en:hello", "en:world"},
 		},
 		{
 			name:   "with punctuation",
 			phrase: "hello, world!",
-			want:   []string{"hello", "world"},
+			want:   []string{"en:hello", "en:world"},
 		},
 		{
 			name:   "with numbers",
 			phrase: "test123 456test",
-			want:   []string{"test123", "456test"},
+			want:   []string{"en:test123", "en:456test"},
 		},
 		{
 			name:   "with stemming",
@@ -61,21 +69,538 @@ func TestWords_Norm(t *testing.T) {
 		{
 			name:   "duplicate words",
 			phrase: "hello hello world world",
-			want:   []string{"hello", "world"},
+			want:   []string{"en:hello", "en:world"},
 		},
 		{
 			name:   "mixed case",
 			phrase: "Hello World",
-			want:   []string{"Hello", "World"},
+			want:   []string{"en:Hello", "en:World"},
+		},
+		{
+			name:   "detected languages",
+			phrase: "кот cat",
+			want:   []string{"ru:кот", "en:cat"},
+		},
+		{
+			name:     "explicit language",
+			phrase:   "кот cat",
+			language: "RU",
+			want:     []string{"ru:кот", "ru:cat"},
+		},
+		{
+			name:     "unsupported language",
+			phrase:   "cat",
+			language: "de",
+			wantErr:  ErrUnsupportedLanguage,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			stemmer := MockStemmer{stemFunc: tt.stemFunc}
-			words := NewWords(stemmer)
-			got := words.Norm(tt.phrase)
+			words, err := NewWords(stemmer, nil, nil, TextOptions{})
+			assert.NoError(t, err)
+			got, err := words.Norm(tt.phrase, tt.language)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, tt.want, got)
+		})
+	}
+}
+
+type MockLoader struct {
+	dictionaries []Dictionary
+	err          error
+	calls        int
+}
+
+func (m *MockLoader) Load() (Dictionary, error) {
+	if m.err != nil {
+		return Dictionary{}, m.err
+	}
+	d := m.dictionaries[min(m.calls, len(m.dictionaries)-1)]
+	m.calls++
+	return d, nil
+}
+
+func TestWords_Dictionary(t *testing.T) {
+	loader := &MockLoader{dictionaries: []Dictionary{
+		{
+			StopWords: map[string]struct{}{"noise": {}},
+			Protected: map[string]struct{}{"xkcd": {}, "sudo": {}},
+		},
+		{
+			StopWords: map[string]struct{}{"sudo": {}},
+		},
+	}}
+	words, err := NewWords(MockStemmer{}, loader, nil, TextOptions{})
+	assert.NoError(t, err)
+
+	got, err := words.Norm("Noise sudo XKCD cats", "")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"sudo", "xkcd", "en:cats"}, got)
+
+	// после перезагрузки применяются новые словари
+	assert.NoError(t, words.Reload())
+	got, err = words.Norm("Noise sudo XKCD cats", "")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"en:Noise", "en:XKCD", "en:cats"}, got)
+
+	// ошибка загрузки оставляет прежние словари
+	loader.err = errors.New("no such file")
+	assert.Error(t, words.Reload())
+	got, err = words.Norm("sudo", "")
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestNewWords_LoadError(t *testing.T) {
+	_, err := NewWords(MockStemmer{}, &MockLoader{err: errors.New("no such file")}, nil, TextOptions{})
+	assert.Error(t, err)
+}
+
+func TestWords_NormQuery(t *testing.T) {
+	loader := &MockLoader{dictionaries: []Dictionary{{
+		StopWords: map[string]struct{}{"the": {}},
+		Synonyms: [][]string{
+			{"car", "automobile"},
+			{"ml", "machine learning"},
+			{"machine", "engine"},
+		},
+	}}}
+	words, err := NewWords(MockStemmer{}, loader, nil, TextOptions{})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		query string
+		want  []Term
+	}{
+		{
+			name:  "no synonyms",
+			query: "the cat",
+			want:  []Term{{Word: "en:cat", Weight: 1}},
+		},
+		{
+			name:  "single word",
+			query: "red Car",
+			want: []Term{
+				{Word: "en:Car", Weight: 1},
+				{Word: "en:red", Weight: 1},
+				{Word: "en:automobile", Weight: synonymWeight},
+			},
+		},
+		{
+			name:  "word to phrase",
+			query: "ML",
+			want: []Term{
+				{Word: "en:ML", Weight: 1},
+				{Word: "en:learning", Weight: synonymWeight},
+				{Word: "en:machine", Weight: synonymWeight},
+			},
+		},
+		{
+			// длинная фраза важнее синонима отдельного слова
+			name:  "longest phrase wins",
+			query: "machine learning",
+			want: []Term{
+				{Word: "en:learning", Weight: 1},
+				{Word: "en:machine", Weight: 1},
+				{Word: "en:ml", Weight: synonymWeight},
+			},
+		},
+		{
+			name:  "query word outweighs synonym",
+			query: "car automobile",
+			want: []Term{
+				{Word: "en:automobile", Weight: 1},
+				{Word: "en:car", Weight: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := words.NormQuery(tt.query, "")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err = words.NormQuery("car", "de")
+	assert.ErrorIs(t, err, ErrUnsupportedLanguage)
+}
+
+func TestWords_Tokenize(t *testing.T) {
+	loader := &MockLoader{dictionaries: []Dictionary{{
+		StopWords: map[string]struct{}{"the": {}},
+		Protected: map[string]struct{}{"xkcd": {}},
+	}}}
+	words, err := NewWords(MockStemmer{}, loader, nil, TextOptions{})
+	assert.NoError(t, err)
+
+	phrase := "The cat, the Кот; XKCD cat"
+	got, err := words.Tokenize(phrase, "")
+	assert.NoError(t, err)
+	assert.Equal(t, []Token{
+		{Surface: "The", Start: 0, End: 3, StopWord: true},
+		{Surface: "cat", Stem: "en:cat", Start: 4, End: 7},
+		{Surface: "the", Start: 9, End: 12, StopWord: true},
+		{Surface: "Кот", Stem: "ru:Кот", Start: 13, End: 19},
+		{Surface: "XKCD", Stem: "xkcd", Start: 21, End: 25},
+		{Surface: "cat", Stem: "en:cat", Start: 26, End: 29},
+	}, got)
+	for _, token := range got {
+		assert.Equal(t, token.Surface, phrase[token.Start:token.End])
+	}
+
+	got, err = words.Tokenize("", "")
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+
+	_, err = words.Tokenize("cat", "de")
+	assert.ErrorIs(t, err, ErrUnsupportedLanguage)
+}
+
+type MockCache struct {
+	items  map[string]any
+	purged int
+}
+
+func (m *MockCache) Get(key string) (any, bool) {
+	value, ok := m.items[key]
+	return value, ok
+}
+
+func (m *MockCache) Add(key string, value any) {
+	m.items[key] = value
+}
+
+func (m *MockCache) Purge() {
+	m.items = make(map[string]any)
+	m.purged++
+}
+
+func (m *MockCache) Stats() CacheStats {
+	return CacheStats{Size: len(m.items)}
+}
+
+func TestWords_Cache(t *testing.T) {
+	stems := 0
+	stemmer := MockStemmer{stemFunc: func(word string) string {
+		stems++
+		return word
+	}}
+	loader := &MockLoader{dictionaries: []Dictionary{{}, {StopWords: map[string]struct{}{"cat": {}}}}}
+	cache := &MockCache{items: make(map[string]any)}
+	words, err := NewWords(stemmer, loader, cache, TextOptions{})
+	assert.NoError(t, err)
+
+	got, err := words.Norm("cat", "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"cat"}, got)
+	got, err = words.Norm("cat", "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"cat"}, got)
+	assert.Equal(t, 1, stems)
+
+	// другой язык и NormQuery кэшируются отдельно
+	_, err = words.Norm("cat", "en")
+	assert.NoError(t, err)
+	terms, err := words.NormQuery("cat", "")
+	assert.NoError(t, err)
+	assert.Equal(t, []Term{{Word: "cat", Weight: 1}}, terms)
+	assert.Equal(t, 3, stems)
+	assert.Equal(t, CacheStats{Size: 3}, words.CacheStats())
+
+	// изменение результата не портит кэш
+	got[0] = "dog"
+	got, err = words.Norm("cat", "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"cat"}, got)
+
+	// после перезагрузки словарей кэш сбрасывается
+	assert.NoError(t, words.Reload())
+	assert.Equal(t, 2, cache.purged)
+	got, err = words.Norm("cat", "")
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestWords_CacheReloadDuringNorm(t *testing.T) {
+	var words *Words
+	reloaded := false
+	stemmer := MockStemmer{stemFunc: func(word string) string {
+		// словари меняются, пока идёт нормализация
+		if !reloaded {
+			reloaded = true
+			assert.NoError(t, words.Reload())
+		}
+		return word
+	}}
+	loader := &MockLoader{dictionaries: []Dictionary{{}, {StopWords: map[string]struct{}{"cat": {}}}}}
+	cache := &MockCache{items: make(map[string]any)}
+	words, err := NewWords(stemmer, loader, cache, TextOptions{})
+	assert.NoError(t, err)
+
+	got, err := words.Norm("cat", "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"cat"}, got)
+
+	// результат по старым словарям не отдаётся из кэша
+	got, err = words.Norm("cat", "")
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestWords_TextOptions(t *testing.T) {
+	lower := func(word string) string {
+		return strings.ToLower(word)
+	}
+
+	tests := []struct {
+		name    string
+		options TextOptions
+		phrase  string
+		want    []string
+	}{
+		{
+			name:   "full-width without nfkc",
+			phrase: "ｃａｆｅ",
+			want:   []string{"ｃａｆｅ"},
+		},
+		{
+			name:    "full-width with nfkc",
+			options: TextOptions{NFKC: true},
+			phrase:  "ｃａｆｅ",
+			want:    []string{"cafe"},
+		},
+		{
+			name:    "ligature with nfkc",
+			options: TextOptions{NFKC: true},
+			phrase:  "ﬁle",
+			want:    []string{"file"},
+		},
+		{
+			name:   "accents kept",
+			phrase: "Café",
+			want:   []string{"café"},
+		},
+		{
+			name:    "accents folded",
+			options: TextOptions{FoldAccents: true},
+			phrase:  "Café naïve Ångström",
+			want:    []string{"cafe", "naive", "angstrom"},
+		},
+		{
+			name:    "cyrillic letters not folded",
+			options: TextOptions{FoldAccents: true},
+			phrase:  "Ёлка йод",
+			want:    []string{"ёлка", "йод"},
+		},
+		{
+			name:   "hyphen split by default",
+			phrase: "e-mail",
+			want:   []string{"e", "mail"},
+		},
+		{
+			name:    "hyphen join",
+			options: TextOptions{Hyphens: JoinerJoin},
+			phrase:  "e-mail",
+			want:    []string{"email"},
+		},
+		{
+			name:    "hyphen keep",
+			options: TextOptions{Hyphens: JoinerKeep},
+			phrase:  "e‐mail",
+			want:    []string{"e-mail"},
+		},
+		{
+			name:    "full-width hyphen keep",
+			options: TextOptions{NFKC: true, Hyphens: JoinerKeep},
+			phrase:  "e－mail",
+			want:    []string{"e-mail"},
+		},
+		{
+			name:    "hyphen outside word",
+			options: TextOptions{Hyphens: JoinerJoin},
+			phrase:  "-mail - box-",
+			want:    []string{"mail", "box"},
+		},
+		{
+			name:   "apostrophe split by default",
+			phrase: "don't",
+			want:   []string{"don", "t"},
+		},
+		{
+			name:    "apostrophe join",
+			options: TextOptions{Apostrophes: JoinerJoin},
+			phrase:  "don't",
+			want:    []string{"dont"},
+		},
+		{
+			name:    "curly apostrophe keep",
+			options: TextOptions{Apostrophes: JoinerKeep},
+			phrase:  "don’t rock'n'roll",
+			want:    []string{"don't", "rock'n'roll"},
+		},
+		{
+			name:    "quotes are not apostrophes",
+			options: TextOptions{Apostrophes: JoinerKeep},
+			phrase:  "'quoted'",
+			want:    []string{"quoted"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			words, err := NewWords(MockStemmer{stemFunc: lower}, nil, nil, tt.options)
+			assert.NoError(t, err)
+			got, err := words.Norm(tt.phrase, "")
+			assert.NoError(t, err)
 			assert.ElementsMatch(t, tt.want, got)
 		})
 	}
 }
+
+func TestWords_TokenizeTextOptions(t *testing.T) {
+	options := TextOptions{NFKC: true, FoldAccents: true, Hyphens: JoinerKeep, Apostrophes: JoinerJoin}
+	words, err := NewWords(MockStemmer{stemFunc: strings.ToLower}, nil, nil, options)
+	assert.NoError(t, err)
+
+	// смещения указывают на исходную фразу, а не на нормализованную
+	phrase := "Café e-mail don’t"
+	got, err := words.Tokenize(phrase, "")
+	assert.NoError(t, err)
+	assert.Equal(t, []Token{
+		{Surface: "Café", Stem: "cafe", Start: 0, End: 5},
+		{Surface: "e-mail", Stem: "e-mail", Start: 6, End: 12},
+		{Surface: "don’t", Stem: "dont", Start: 13, End: 20},
+	}, got)
+}
+
+func TestNewWords_BadTextOptions(t *testing.T) {
+	_, err := NewWords(MockStemmer{}, nil, nil, TextOptions{Hyphens: "drop"})
+	assert.Error(t, err)
+}
+
+func TestWords_Identifiers(t *testing.T) {
+	// стеммер отрезает "s", чтобы было видно, какие слова прошли через него
+	stem := func(word string) string {
+		return strings.TrimSuffix(strings.ToLower(word), "s")
+	}
+
+	tests := []struct {
+		name    string
+		options TextOptions
+		phrase  string
+		want    []string
+	}{
+		{
+			name:   "disabled",
+			phrase: "C++ IPv6",
+			want:   []string{"c", "ipv6"},
+		},
+		{
+			name:    "number",
+			options: TextOptions{Identifiers: true},
+			phrase:  "1337 hours",
+			want:    []string{"1337", "hour"},
+		},
+		{
+			name:    "programming languages",
+			options: TextOptions{Identifiers: true},
+			phrase:  "C++, C# and g++!",
+			want:    []string{"c++", "c", "c#", "and", "g++", "g"},
+		},
+		{
+			name:    "letters and digits",
+			options: TextOptions{Identifiers: true},
+			phrase:  "IPv6 addresses",
+			want:    []string{"ipv6", "ipv", "6", "addresse"},
+		},
+		{
+			name:    "power",
+			options: TextOptions{Identifiers: true},
+			phrase:  "2^64",
+			want:    []string{"2^64", "2", "64"},
+		},
+		{
+			name:    "version",
+			options: TextOptions{Identifiers: true},
+			phrase:  "Python 3.12.",
+			want:    []string{"python", "3.12", "3", "12"},
+		},
+		{
+			name:    "http status",
+			options: TextOptions{Identifiers: true},
+			phrase:  "HTTP 418",
+			want:    []string{"http_418", "http", "418"},
+		},
+		{
+			name:    "not a protocol number",
+			options: TextOptions{Identifiers: true},
+			phrase:  "Http 418, A 4",
+			want:    []string{"http", "418", "a", "4"},
+		},
+		{
+			name:    "upper case transcript",
+			options: TextOptions{Identifiers: true},
+			phrase:  "I HAVE 3 CATS",
+			want:    []string{"i", "have", "3", "cat"},
+		},
+		{
+			name:    "symbols outside identifiers",
+			options: TextOptions{Identifiers: true},
+			phrase:  "a+++ 1+1 #tag e.g. .5",
+			want:    []string{"a", "1", "tag", "e", "g", "5"},
+		},
+		{
+			name:    "full-width plus",
+			options: TextOptions{Identifiers: true, NFKC: true},
+			phrase:  "Ｃ++",
+			want:    []string{"c++", "c"},
+		},
+		{
+			name:    "kept hyphen",
+			options: TextOptions{Identifiers: true, Hyphens: JoinerKeep},
+			phrase:  "x86-64",
+			want:    []string{"x86-64", "x", "86", "64"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			words, err := NewWords(MockStemmer{stemFunc: stem}, nil, nil, tt.options)
+			assert.NoError(t, err)
+			got, err := words.Norm(tt.phrase, "")
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, tt.want, got)
+		})
+	}
+}
+
+func TestWords_NormQueryIdentifiers(t *testing.T) {
+	words, err := NewWords(MockStemmer{stemFunc: strings.ToLower}, nil, nil, TextOptions{Identifiers: true})
+	assert.NoError(t, err)
+
+	got, err := words.NormQuery("IPv6 C++", "")
+	assert.NoError(t, err)
+	assert.Equal(t, []Term{
+		{Word: "c++", Weight: 1},
+		{Word: "ipv6", Weight: 1},
+		{Word: "6", Weight: partWeight},
+		{Word: "c", Weight: partWeight},
+		{Word: "ipv", Weight: partWeight},
+	}, got)
+
+	tokens, err := words.Tokenize("C++ rocks", "")
+	assert.NoError(t, err)
+	assert.Equal(t, []Token{
+		{Surface: "C++", Stem: "c++", Start: 0, End: 3},
+		{Surface: "rocks", Stem: "rocks", Start: 4, End: 9},
+	}, tokens)
+}
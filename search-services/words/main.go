@@ -15,6 +15,8 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
 	wordspb "yadro.com/course/proto/words"
+	"yadro.com/course/words/adapters/cache"
+	"yadro.com/course/words/adapters/dictionary"
 	wordsgrpc "yadro.com/course/words/adapters/grpc"
 	"yadro.com/course/words/adapters/stemming"
 	"yadro.com/course/words/core"
@@ -25,7 +27,17 @@ const (
 )
 
 type Config struct {
-	Port string `yaml:"port" env:"WORDS_GRPC_PORT" env-default:"11111"`
+	Port           string        `yaml:"port" env:"WORDS_GRPC_PORT" env-default:"11111"`
+	StopWords      []string      `yaml:"stop_words" env:"WORDS_STOP_WORDS" env-separator:","`
+	ProtectedWords []string      `yaml:"protected_words" env:"WORDS_PROTECTED_WORDS" env-separator:","`
+	Synonyms       []string      `yaml:"synonyms" env:"WORDS_SYNONYMS" env-separator:","`
+	CacheSize      int           `yaml:"cache_size" env:"WORDS_CACHE_SIZE" env-default:"10000"`
+	CacheTTL       time.Duration `yaml:"cache_ttl" env:"WORDS_CACHE_TTL" env-default:"10m"`
+	NFKC           bool          `yaml:"nfkc" env:"WORDS_NFKC"`
+	FoldAccents    bool          `yaml:"fold_accents" env:"WORDS_FOLD_ACCENTS"`
+	Hyphens        string        `yaml:"hyphens" env:"WORDS_HYPHENS" env-default:"split"`
+	Apostrophes    string        `yaml:"apostrophes" env:"WORDS_APOSTROPHES" env-default:"keep"`
+	Identifiers    bool          `yaml:"identifiers" env:"WORDS_IDENTIFIERS"`
 }
 
 func main() {
@@ -57,8 +69,31 @@ func run(cfg Config, log *slog.Logger) error {
 	// adapter for stemmer
 	stemmer := stemming.Snowball{}
 
+	// adapter for custom stop words, protected words and synonyms
+	dictionaries := dictionary.New(cfg.StopWords, cfg.ProtectedWords, cfg.Synonyms)
+
+	// adapter for normalization cache, disabled by zero size
+	var normCache core.Cache
+	if cfg.CacheSize > 0 {
+		lru, err := cache.NewLRU(cfg.CacheSize, cfg.CacheTTL)
+		if err != nil {
+			return fmt.Errorf("failed to create cache: %v", err)
+		}
+		normCache = lru
+	}
+
 	// core service
-	words := core.NewWords(stemmer)
+	text := core.TextOptions{
+		NFKC:        cfg.NFKC,
+		FoldAccents: cfg.FoldAccents,
+		Hyphens:     cfg.Hyphens,
+		Apostrophes: cfg.Apostrophes,
+		Identifiers: cfg.Identifiers,
+	}
+	words, err := core.NewWords(stemmer, dictionaries, normCache, text)
+	if err != nil {
+		return fmt.Errorf("failed to create words service: %v", err)
+	}
 
 	// grpc adapter
 	server := wordsgrpc.New(words)